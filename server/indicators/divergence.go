@@ -0,0 +1,95 @@
+package indicators
+
+import "math"
+
+// Divergence kinds
+const (
+	DivergenceRegular = "regular" // reversal: price makes a new extreme the oscillator does not confirm
+	DivergenceHidden  = "hidden"  // continuation: the oscillator makes the new extreme, price does not
+)
+
+// Divergence is a disagreement between two consecutive price swings and an
+// oscillator at the same bars
+type Divergence struct {
+	Oscillator string  `json:"oscillator"`
+	Kind       string  `json:"kind"`
+	Bias       string  `json:"bias"`
+	FromIndex  int     `json:"fromIndex"`
+	ToIndex    int     `json:"toIndex"`
+	FromTime   int64   `json:"fromTime"`
+	ToTime     int64   `json:"toTime"`
+	PriceFrom  float64 `json:"priceFrom"`
+	PriceTo    float64 `json:"priceTo"`
+	OscFrom    float64 `json:"oscFrom"`
+	OscTo      float64 `json:"oscTo"`
+}
+
+// Divergences compares consecutive fractal swing lows (bullish) and swing highs
+// (bearish) of price with the oscillator values on the same bars. Swings further
+// than maxSpan bars apart are not compared. Like the swings themselves, a
+// divergence is only confirmed strength bars after its last swing.
+func Divergences(bars []Bar, oscillator []float64, name string, strength, maxSpan int) []Divergence {
+	if len(oscillator) != len(bars) {
+		return nil
+	}
+
+	var divergences []Divergence
+	var lastHigh, lastLow *SwingPoint
+	for _, s := range Swings(bars, strength) {
+		s := s
+		if math.IsNaN(oscillator[s.Index]) {
+			continue
+		}
+
+		prev := &lastLow
+		if s.Kind == SwingHigh {
+			prev = &lastHigh
+		}
+		if p := *prev; p != nil && (maxSpan <= 0 || s.Index-p.Index <= maxSpan) {
+			if d, ok := compareSwings(p, &s, oscillator); ok {
+				d.Oscillator = name
+				d.FromTime, d.ToTime = bars[p.Index].OpenTime, bars[s.Index].OpenTime
+				divergences = append(divergences, d)
+			}
+		}
+		*prev = &s
+	}
+	return divergences
+}
+
+func compareSwings(from, to *SwingPoint, oscillator []float64) (Divergence, bool) {
+	d := Divergence{
+		FromIndex: from.Index,
+		ToIndex:   to.Index,
+		PriceFrom: from.Price,
+		PriceTo:   to.Price,
+		OscFrom:   oscillator[from.Index],
+		OscTo:     oscillator[to.Index],
+	}
+	priceUp, oscUp := d.PriceTo > d.PriceFrom, d.OscTo > d.OscFrom
+	priceDown, oscDown := d.PriceTo < d.PriceFrom, d.OscTo < d.OscFrom
+
+	if to.Kind == SwingLow {
+		d.Bias = BiasBullish
+		switch {
+		case priceDown && oscUp:
+			d.Kind = DivergenceRegular
+		case priceUp && oscDown:
+			d.Kind = DivergenceHidden
+		default:
+			return d, false
+		}
+		return d, true
+	}
+
+	d.Bias = BiasBearish
+	switch {
+	case priceUp && oscDown:
+		d.Kind = DivergenceRegular
+	case priceDown && oscUp:
+		d.Kind = DivergenceHidden
+	default:
+		return d, false
+	}
+	return d, true
+}
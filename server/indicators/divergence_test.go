@@ -0,0 +1,98 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// barsFromLows builds bars one unit tall on top of the given lows
+func barsFromLows(lows []float64) []Bar {
+	bars := make([]Bar, len(lows))
+	for i, low := range lows {
+		bars[i] = Bar{OpenTime: int64(i), High: low + 1, Low: low, Close: low + 0.5}
+	}
+	return bars
+}
+
+func TestDivergences(t *testing.T) {
+	tests := []struct {
+		name       string
+		lows       []float64
+		oscillator []float64
+		maxSpan    int
+		wantKind   string
+		wantBias   string
+	}{
+		{
+			name:       "regular bullish: lower low in price, higher low in the oscillator",
+			lows:       []float64{5, 3, 5, 2, 5},
+			oscillator: []float64{50, 30, 50, 40, 50},
+			wantKind:   DivergenceRegular,
+			wantBias:   BiasBullish,
+		},
+		{
+			name:       "hidden bullish: higher low in price, lower low in the oscillator",
+			lows:       []float64{5, 2, 5, 3, 5},
+			oscillator: []float64{50, 40, 50, 30, 50},
+			wantKind:   DivergenceHidden,
+			wantBias:   BiasBullish,
+		},
+		{
+			name:       "regular bearish: higher high in price, lower high in the oscillator",
+			lows:       []float64{0, 4, 0, 5, 0},
+			oscillator: []float64{50, 70, 50, 60, 50},
+			wantKind:   DivergenceRegular,
+			wantBias:   BiasBearish,
+		},
+		{
+			name:       "hidden bearish: lower high in price, higher high in the oscillator",
+			lows:       []float64{0, 5, 0, 4, 0},
+			oscillator: []float64{50, 60, 50, 70, 50},
+			wantKind:   DivergenceHidden,
+			wantBias:   BiasBearish,
+		},
+		{
+			name:       "agreement is not a divergence",
+			lows:       []float64{5, 3, 5, 2, 5},
+			oscillator: []float64{50, 40, 50, 30, 50},
+		},
+		{
+			name:       "swings too far apart are not compared",
+			lows:       []float64{5, 3, 5, 2, 5},
+			oscillator: []float64{50, 30, 50, 40, 50},
+			maxSpan:    1,
+		},
+		{
+			name:       "swings without an oscillator value are skipped",
+			lows:       []float64{5, 3, 5, 2, 5},
+			oscillator: []float64{50, math.NaN(), 50, 40, 50},
+		},
+		{
+			name:       "a misaligned oscillator gives nothing",
+			lows:       []float64{5, 3, 5, 2, 5},
+			oscillator: []float64{30, 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Divergences(barsFromLows(tt.lows), tt.oscillator, "RSI", 1, tt.maxSpan)
+			if tt.wantKind == "" {
+				if len(got) != 0 {
+					t.Fatalf("expected no divergence, got %+v", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("divergences = %+v, want one", got)
+			}
+			d := got[0]
+			if d.Kind != tt.wantKind || d.Bias != tt.wantBias || d.Oscillator != "RSI" {
+				t.Errorf("divergence = %+v, want %s %s", d, tt.wantKind, tt.wantBias)
+			}
+			if d.FromIndex != 1 || d.ToIndex != 3 || d.OscFrom != tt.oscillator[1] || d.OscTo != tt.oscillator[3] {
+				t.Errorf("divergence should span the swings at 1 and 3, got %+v", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Package indicators computes technical indicators over OHLCV bars.
+//
+// Every function returns series aligned with its input; values that are not
+// defined yet (during the warm-up period) are NaN.
+package indicators
+
+import "math"
+
+// Bar is one OHLCV candle
+type Bar struct {
+	OpenTime int64 // unix milliseconds
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+}
+
+// Closes returns the close of every bar
+func Closes(bars []Bar) []float64 {
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	return closes
+}
+
+func nanSeries(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = math.NaN()
+	}
+	return s
+}
+
+// Last returns the last defined value of a series, or NaN
+func Last(series []float64) float64 {
+	for i := len(series) - 1; i >= 0; i-- {
+		if !math.IsNaN(series[i]) {
+			return series[i]
+		}
+	}
+	return math.NaN()
+}
@@ -0,0 +1,227 @@
+package indicators
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var nan = math.NaN()
+
+// checkSeries compares two series, treating NaN as equal to NaN
+func checkSeries(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s has %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// risingBars climbs by one per bar: high i+2, low i, close i+1
+func risingBars(n int) []Bar {
+	bars := make([]Bar, n)
+	for i := range bars {
+		f := float64(i)
+		bars[i] = Bar{OpenTime: int64(i) * 60000, Open: f, High: f + 2, Low: f, Close: f + 1, Volume: 1}
+	}
+	return bars
+}
+
+func TestMovingAverages(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func([]float64, int) []float64
+		values []float64
+		period int
+		want   []float64
+	}{
+		{"sma", SMA, []float64{1, 2, 3, 4, 5}, 3, []float64{nan, nan, 2, 3, 4}},
+		{"sma of too few values", SMA, []float64{1, 2}, 3, []float64{nan, nan}},
+		{"sma zero period", SMA, []float64{1, 2}, 0, []float64{nan, nan}},
+		{"ema seeded with the sma", EMA, []float64{1, 2, 3, 4, 5}, 3, []float64{nan, nan, 2, 3, 4}},
+		{"ema skips leading NaNs", EMA, []float64{nan, 1, 2, 3, 4}, 2, []float64{nan, nan, 1.5, 2.5, 3.5}},
+		{"ema weights recent values", EMA, []float64{2, 2, 2, 8}, 3, []float64{nan, nan, 2, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSeries(t, tt.name, tt.fn(tt.values, tt.period), tt.want)
+		})
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		{"only gains", []float64{1, 2, 3, 4}, 2, []float64{nan, nan, 100, 100}},
+		{"only losses", []float64{4, 3, 2}, 2, []float64{nan, nan, 0}},
+		// gains 1,0,1,0 and losses 0,1,0,1 smoothed over 2
+		{"alternating", []float64{1, 2, 1, 2, 1}, 2, []float64{nan, nan, 50, 75, 37.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSeries(t, "RSI", RSI(tt.values, tt.period), tt.want)
+		})
+	}
+
+	k, d := StochRSI([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 2, 2, 2, 2)
+	if Last(k) != 50 || Last(d) != 50 {
+		t.Errorf("a flat RSI should put StochRSI at 50, got %%K %g %%D %g", Last(k), Last(d))
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	bars := []Bar{
+		{High: 10, Low: 8, Close: 9},
+		{High: 11, Low: 9, Close: 10},  // TR 2
+		{High: 14, Low: 10, Close: 13}, // TR 4
+		{High: 12, Low: 11, Close: 11}, // TR 2, from the previous close
+	}
+	checkSeries(t, "TrueRange", TrueRange(bars), []float64{nan, 2, 4, 2})
+	checkSeries(t, "ATR", ATR(bars, 2), []float64{nan, nan, 3, 2.5})
+
+	sd := math.Sqrt(2.0 / 3)
+	b := Bollinger([]float64{1, 2, 3}, 3, 2)
+	checkSeries(t, "Bollinger upper", b.Upper, []float64{nan, nan, 2 + 2*sd})
+	checkSeries(t, "Bollinger lower", b.Lower, []float64{nan, nan, 2 - 2*sd})
+
+	k := Keltner(bars, 2, 2, 1)
+	// EMA(2) of closes 9,10,13,11 is 9.5, then 71/6, then 203/18
+	checkSeries(t, "Keltner upper", k.Upper, []float64{nan, nan, 71.0/6 + 3, 203.0/18 + 2.5})
+}
+
+func TestVWAP(t *testing.T) {
+	bars := []Bar{
+		{OpenTime: 0, High: 3, Low: 1, Close: 2, Volume: 1},           // typical 2
+		{OpenTime: 3600000, High: 6, Low: 2, Close: 4, Volume: 3},     // typical 4
+		{OpenTime: 86400000, High: 10, Low: 10, Close: 10, Volume: 1}, // next UTC day
+		{OpenTime: 90000000, High: 12, Low: 12, Close: 12, Volume: 0},
+	}
+	checkSeries(t, "SessionVWAP", SessionVWAP(bars), []float64{2, 3.5, 10, 10})
+	checkSeries(t, "RollingVWAP", RollingVWAP(bars, 2), []float64{nan, 3.5, 5.5, 10})
+}
+
+func TestADXInSteadyUptrend(t *testing.T) {
+	dmi := ADX(risingBars(10), 3)
+
+	// Every bar adds one of +DM over a true range of two and no -DM
+	if Last(dmi.PlusDI) != 50 || Last(dmi.MinusDI) != 0 || Last(dmi.ADX) != 100 {
+		t.Fatalf("+DI %g, -DI %g, ADX %g; want 50, 0, 100", Last(dmi.PlusDI), Last(dmi.MinusDI), Last(dmi.ADX))
+	}
+	// DMI needs period+1 bars and ADX another period-1
+	if !math.IsNaN(dmi.PlusDI[2]) || math.IsNaN(dmi.PlusDI[3]) || !math.IsNaN(dmi.ADX[4]) || math.IsNaN(dmi.ADX[5]) {
+		t.Fatalf("unexpected warm-up: +DI %v, ADX %v", dmi.PlusDI, dmi.ADX)
+	}
+}
+
+func TestIchimoku(t *testing.T) {
+	bars := make([]Bar, 5)
+	for i := range bars {
+		bars[i] = Bar{High: float64(i + 1), Low: float64(i)}
+	}
+	cloud := Ichimoku(bars, 1, 2, 2)
+
+	checkSeries(t, "tenkan", cloud.Tenkan, []float64{0.5, 1.5, 2.5, 3.5, 4.5})
+	checkSeries(t, "kijun", cloud.Kijun, []float64{nan, 1, 2, 3, 4})
+	// The spans are plotted kijun bars after the bar they are computed on
+	checkSeries(t, "senkou A", cloud.SenkouA, []float64{nan, nan, nan, 1.25, 2.25})
+	checkSeries(t, "senkou B", cloud.SenkouB, []float64{nan, nan, nan, 1, 2})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []Spec
+		wantErr string
+	}{
+		{name: "defaults", specs: []Spec{{Name: NameATR}, {Name: "EMA"}, {Name: NameIchimoku}}},
+		{name: "explicit parameters", specs: []Spec{{Name: NameBollinger, Period: 10, Multiplier: 2.5}, {Name: NameIchimoku, Periods: []int{10, 30, 60}}}},
+		{name: "unknown indicator", specs: []Spec{{Name: "macd"}}, wantErr: "unknown indicator"},
+		{name: "negative period", specs: []Spec{{Name: NameATR, Period: -1}}, wantErr: "negative parameter"},
+		{name: "zero ribbon period", specs: []Spec{{Name: NameSMA, Periods: []int{9, 0}}}, wantErr: "non-positive period"},
+		{name: "ichimoku with two periods", specs: []Spec{{Name: NameIchimoku, Periods: []int{9, 26}}}, wantErr: "three periods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.specs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	bars := risingBars(30)
+
+	tests := []struct {
+		name       string
+		spec       Spec
+		wantLabel  string // empty when the indicator has no value yet
+		wantFields []Field
+	}{
+		{
+			name:       "atr with its default period",
+			spec:       Spec{Name: "ATR"},
+			wantLabel:  "ATR(14)",
+			wantFields: []Field{{"value", 2}, {"% of price", 2.0 / 30 * 100}},
+		},
+		{
+			name:       "sma ribbon",
+			spec:       Spec{Name: NameSMA, Periods: []int{2, 4}},
+			wantLabel:  "SMA ribbon[2 4]",
+			wantFields: []Field{{"SMA2", 29.5}, {"SMA4", 28.5}},
+		},
+		{
+			name:       "rolling vwap",
+			spec:       Spec{Name: NameVWAP, Period: 2},
+			wantLabel:  "VWAP(2)",
+			wantFields: []Field{{"value", 29.5}, {"price vs VWAP %", 0.5 / 29.5 * 100}},
+		},
+		{
+			name: "not enough bars",
+			spec: Spec{Name: NameEMA, Periods: []int{50}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readings := Compute(bars, []Spec{tt.spec})
+			if tt.wantLabel == "" {
+				if len(readings) != 0 {
+					t.Fatalf("expected no reading, got %+v", readings)
+				}
+				return
+			}
+			if len(readings) != 1 || readings[0].Label != tt.wantLabel {
+				t.Fatalf("readings = %+v, want label %s", readings, tt.wantLabel)
+			}
+			for i, want := range tt.wantFields {
+				got := readings[0].Fields[i]
+				if got.Name != want.Name || math.Abs(got.Value-want.Value) > 1e-9 {
+					t.Errorf("field %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+
+	if readings := Compute(nil, []Spec{{Name: NameATR}}); readings != nil {
+		t.Errorf("no bars should give no readings, got %+v", readings)
+	}
+}
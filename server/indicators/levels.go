@@ -0,0 +1,228 @@
+package indicators
+
+import (
+	"math"
+	"sort"
+)
+
+// Swing kinds
+const (
+	SwingHigh = "high"
+	SwingLow  = "low"
+)
+
+// Zone kinds, relative to the last close
+const (
+	ZoneSupport    = "support"
+	ZoneResistance = "resistance"
+	ZoneInside     = "inside" // price is trading inside the zone
+)
+
+// SwingPoint is a confirmed fractal: a bar whose high (low) is above (below) the
+// highs (lows) of the strength bars on each side
+type SwingPoint struct {
+	Kind     string  `json:"kind"`
+	Index    int     `json:"index"`
+	OpenTime int64   `json:"openTime"`
+	Price    float64 `json:"price"`
+}
+
+// Zone is a cluster of swing points at about the same price
+type Zone struct {
+	Kind          string  `json:"kind"`
+	Low           float64 `json:"low"`
+	High          float64 `json:"high"`
+	Touches       int     `json:"touches"` // swing points inside the zone
+	LastIndex     int     `json:"lastIndex"`
+	LastTouchTime int64   `json:"lastTouchTime"`
+}
+
+// Mid returns the middle of the zone
+func (z Zone) Mid() float64 {
+	return (z.Low + z.High) / 2
+}
+
+// ClassicPivots are floor-trader pivots from the previous bar's high, low and close
+type ClassicPivots struct {
+	Pivot float64 `json:"pivot"`
+	R1    float64 `json:"r1"`
+	R2    float64 `json:"r2"`
+	R3    float64 `json:"r3"`
+	S1    float64 `json:"s1"`
+	S2    float64 `json:"s2"`
+	S3    float64 `json:"s3"`
+}
+
+// CamarillaPivots are Camarilla levels from the previous bar's high, low and close
+type CamarillaPivots struct {
+	R1 float64 `json:"r1"`
+	R2 float64 `json:"r2"`
+	R3 float64 `json:"r3"`
+	R4 float64 `json:"r4"`
+	S1 float64 `json:"s1"`
+	S2 float64 `json:"s2"`
+	S3 float64 `json:"s3"`
+	S4 float64 `json:"s4"`
+}
+
+// LevelOptions tunes FindLevels; zero values use the defaults
+type LevelOptions struct {
+	Strength  int     // bars on each side of a fractal, default 2
+	ZoneWidth float64 // widest zone in price units, default half of ATR(14)
+	MaxZones  int     // zones kept, strongest first, default 6
+}
+
+// Levels is the support/resistance picture of one timeframe
+type Levels struct {
+	Bars      int             `json:"bars"` // bars analysed; swing and zone indices are into this window
+	Swings    []SwingPoint    `json:"swings"`
+	Zones     []Zone          `json:"zones"`
+	Classic   ClassicPivots   `json:"classic"`
+	Camarilla CamarillaPivots `json:"camarilla"`
+}
+
+// Swings finds fractal highs and lows. A fractal needs strength bars on its right,
+// so the latest strength bars are never reported.
+func Swings(bars []Bar, strength int) []SwingPoint {
+	if strength <= 0 {
+		strength = 2
+	}
+
+	var swings []SwingPoint
+	for i := strength; i < len(bars)-strength; i++ {
+		isHigh, isLow := true, true
+		for j := i - strength; j <= i+strength && (isHigh || isLow); j++ {
+			if j == i {
+				continue
+			}
+			// Strict on the left and loose on the right, so a flat top is reported once
+			if j < i {
+				isHigh = isHigh && bars[i].High > bars[j].High
+				isLow = isLow && bars[i].Low < bars[j].Low
+			} else {
+				isHigh = isHigh && bars[i].High >= bars[j].High
+				isLow = isLow && bars[i].Low <= bars[j].Low
+			}
+		}
+		if isHigh {
+			swings = append(swings, SwingPoint{Kind: SwingHigh, Index: i, OpenTime: bars[i].OpenTime, Price: bars[i].High})
+		}
+		if isLow {
+			swings = append(swings, SwingPoint{Kind: SwingLow, Index: i, OpenTime: bars[i].OpenTime, Price: bars[i].Low})
+		}
+	}
+	return swings
+}
+
+// Zones groups swing points whose prices lie within width of each other and labels
+// each zone against price. Zones are ordered from the highest price down.
+func Zones(swings []SwingPoint, width, price float64, maxZones int) []Zone {
+	if len(swings) == 0 {
+		return nil
+	}
+
+	sorted := make([]SwingPoint, len(swings))
+	copy(sorted, swings)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Price < sorted[j].Price })
+
+	var zones []Zone
+	for _, s := range sorted {
+		if n := len(zones); n > 0 && s.Price-zones[n-1].Low <= width {
+			z := &zones[n-1]
+			z.High = s.Price
+			z.Touches++
+			if s.Index > z.LastIndex {
+				z.LastIndex, z.LastTouchTime = s.Index, s.OpenTime
+			}
+			continue
+		}
+		zones = append(zones, Zone{Low: s.Price, High: s.Price, Touches: 1, LastIndex: s.Index, LastTouchTime: s.OpenTime})
+	}
+
+	// Keep the most touched zones, breaking ties by distance to price
+	sort.SliceStable(zones, func(i, j int) bool {
+		if zones[i].Touches != zones[j].Touches {
+			return zones[i].Touches > zones[j].Touches
+		}
+		return math.Abs(zones[i].Mid()-price) < math.Abs(zones[j].Mid()-price)
+	})
+	if maxZones > 0 && len(zones) > maxZones {
+		zones = zones[:maxZones]
+	}
+
+	for i := range zones {
+		switch {
+		case price > zones[i].High:
+			zones[i].Kind = ZoneSupport
+		case price < zones[i].Low:
+			zones[i].Kind = ZoneResistance
+		default:
+			zones[i].Kind = ZoneInside
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].High > zones[j].High })
+	return zones
+}
+
+// Classic returns floor-trader pivots
+func Classic(high, low, close float64) ClassicPivots {
+	p := (high + low + close) / 3
+	r := high - low
+	return ClassicPivots{
+		Pivot: p,
+		R1:    2*p - low,
+		R2:    p + r,
+		R3:    high + 2*(p-low),
+		S1:    2*p - high,
+		S2:    p - r,
+		S3:    low - 2*(high-p),
+	}
+}
+
+// Camarilla returns Camarilla pivots
+func Camarilla(high, low, close float64) CamarillaPivots {
+	r := (high - low) * 1.1
+	return CamarillaPivots{
+		R1: close + r/12,
+		R2: close + r/6,
+		R3: close + r/4,
+		R4: close + r/2,
+		S1: close - r/12,
+		S2: close - r/6,
+		S3: close - r/4,
+		S4: close - r/2,
+	}
+}
+
+// FindLevels detects swings, clusters them into zones and computes pivots from the
+// previous bar, since the last bar may still be forming
+func FindLevels(bars []Bar, opts LevelOptions) *Levels {
+	levels := &Levels{Bars: len(bars)}
+	if len(bars) == 0 {
+		return levels
+	}
+
+	if opts.Strength <= 0 {
+		opts.Strength = 2
+	}
+	if opts.MaxZones <= 0 {
+		opts.MaxZones = 6
+	}
+	price := bars[len(bars)-1].Close
+	if opts.ZoneWidth <= 0 {
+		opts.ZoneWidth = price * 0.0025
+		if atr := Last(ATR(bars, 14)); !math.IsNaN(atr) && atr > 0 {
+			opts.ZoneWidth = atr / 2
+		}
+	}
+
+	levels.Swings = Swings(bars, opts.Strength)
+	levels.Zones = Zones(levels.Swings, opts.ZoneWidth, price, opts.MaxZones)
+
+	if len(bars) >= 2 {
+		prev := bars[len(bars)-2]
+		levels.Classic = Classic(prev.High, prev.Low, prev.Close)
+		levels.Camarilla = Camarilla(prev.High, prev.Low, prev.Close)
+	}
+	return levels
+}
@@ -0,0 +1,183 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// barsHL builds bars from highs and lows, closing mid-range
+func barsHL(highs, lows []float64) []Bar {
+	bars := make([]Bar, len(highs))
+	for i := range highs {
+		bars[i] = Bar{OpenTime: int64(i) * 60000, High: highs[i], Low: lows[i], Close: (highs[i] + lows[i]) / 2}
+	}
+	return bars
+}
+
+func TestSwings(t *testing.T) {
+	tests := []struct {
+		name     string
+		highs    []float64
+		lows     []float64
+		strength int
+		want     []SwingPoint
+	}{
+		{
+			name:  "fractal high",
+			highs: []float64{1, 2, 5, 2, 1},
+			lows:  []float64{0, 1, 4, 1, 0},
+			want:  []SwingPoint{{Kind: SwingHigh, Index: 2, OpenTime: 120000, Price: 5}},
+		},
+		{
+			name:  "fractal low",
+			highs: []float64{5, 4, 2, 4, 5},
+			lows:  []float64{4, 3, 1, 3, 4},
+			want:  []SwingPoint{{Kind: SwingLow, Index: 2, OpenTime: 120000, Price: 1}},
+		},
+		{
+			name:  "a flat top is reported once, at its first bar",
+			highs: []float64{1, 2, 5, 5, 2, 1, 0},
+			lows:  []float64{0, 1, 4, 4, 1, 0, -1},
+			want:  []SwingPoint{{Kind: SwingHigh, Index: 2, OpenTime: 120000, Price: 5}},
+		},
+		{
+			name:     "wider strength needs more bars on each side",
+			highs:    []float64{1, 2, 5, 2, 1},
+			lows:     []float64{0, 1, 4, 1, 0},
+			strength: 3,
+		},
+		{
+			name:  "the latest bars are never swings",
+			highs: []float64{1, 2, 3, 4, 5, 9},
+			lows:  []float64{0, 1, 2, 3, 4, 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Swings(barsHL(tt.highs, tt.lows), tt.strength)
+			if len(got) != len(tt.want) {
+				t.Fatalf("swings = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("swing %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestZones(t *testing.T) {
+	swings := []SwingPoint{
+		{Kind: SwingHigh, Index: 1, Price: 100},
+		{Kind: SwingHigh, Index: 3, Price: 105},
+		{Kind: SwingLow, Index: 5, OpenTime: 5, Price: 100.4},
+		{Kind: SwingLow, Index: 7, Price: 95},
+	}
+
+	tests := []struct {
+		name     string
+		swings   []SwingPoint
+		width    float64
+		price    float64
+		maxZones int
+		want     []Zone
+	}{
+		{
+			name:   "clusters within the width and labels against price",
+			swings: swings,
+			width:  1,
+			price:  101,
+			want: []Zone{
+				{Kind: ZoneResistance, Low: 105, High: 105, Touches: 1, LastIndex: 3},
+				{Kind: ZoneSupport, Low: 100, High: 100.4, Touches: 2, LastIndex: 5, LastTouchTime: 5},
+				{Kind: ZoneSupport, Low: 95, High: 95, Touches: 1, LastIndex: 7},
+			},
+		},
+		{
+			name:     "keeps the most touched, then the nearest zones",
+			swings:   swings,
+			width:    1,
+			price:    101,
+			maxZones: 2,
+			want: []Zone{
+				{Kind: ZoneResistance, Low: 105, High: 105, Touches: 1, LastIndex: 3},
+				{Kind: ZoneSupport, Low: 100, High: 100.4, Touches: 2, LastIndex: 5, LastTouchTime: 5},
+			},
+		},
+		{
+			name:     "price inside a zone",
+			swings:   swings,
+			width:    1,
+			price:    100.2,
+			maxZones: 1,
+			want:     []Zone{{Kind: ZoneInside, Low: 100, High: 100.4, Touches: 2, LastIndex: 5, LastTouchTime: 5}},
+		},
+		{
+			name:   "width is measured from the zone's low",
+			swings: []SwingPoint{{Price: 100}, {Price: 100.8}, {Price: 101.6}},
+			width:  1,
+			price:  90,
+			want: []Zone{
+				{Kind: ZoneResistance, Low: 101.6, High: 101.6, Touches: 1},
+				{Kind: ZoneResistance, Low: 100, High: 100.8, Touches: 2},
+			},
+		},
+		{name: "no swings", price: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Zones(tt.swings, tt.width, tt.price, tt.maxZones)
+			if len(got) != len(tt.want) {
+				t.Fatalf("zones = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("zone %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPivots(t *testing.T) {
+	classic := Classic(110, 90, 100)
+	wantClassic := ClassicPivots{Pivot: 100, R1: 110, R2: 120, R3: 130, S1: 90, S2: 80, S3: 70}
+	if classic != wantClassic {
+		t.Errorf("Classic = %+v, want %+v", classic, wantClassic)
+	}
+
+	// Camarilla steps are 1.1 * range / 12, 6, 4 and 2 around the close
+	camarilla := Camarilla(110, 90, 100)
+	wantCamarilla := CamarillaPivots{R1: 100 + 22.0/12, R2: 100 + 22.0/6, R3: 105.5, R4: 111, S1: 100 - 22.0/12, S2: 100 - 22.0/6, S3: 94.5, S4: 89}
+	for _, pair := range [][2]float64{
+		{camarilla.R1, wantCamarilla.R1}, {camarilla.R2, wantCamarilla.R2}, {camarilla.R3, wantCamarilla.R3}, {camarilla.R4, wantCamarilla.R4},
+		{camarilla.S1, wantCamarilla.S1}, {camarilla.S2, wantCamarilla.S2}, {camarilla.S3, wantCamarilla.S3}, {camarilla.S4, wantCamarilla.S4},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Fatalf("Camarilla = %+v, want %+v", camarilla, wantCamarilla)
+		}
+	}
+}
+
+func TestFindLevels(t *testing.T) {
+	bars := barsHL([]float64{1, 2, 5, 2, 1, 3}, []float64{0, 1, 4, 1, 0, 2})
+	levels := FindLevels(bars, LevelOptions{ZoneWidth: 1})
+
+	if levels.Bars != 6 || len(levels.Swings) != 1 || len(levels.Zones) != 1 {
+		t.Fatalf("levels = %+v", levels)
+	}
+	if levels.Zones[0].Kind != ZoneResistance {
+		t.Errorf("a swing high above the close should be resistance, got %s", levels.Zones[0].Kind)
+	}
+	// Pivots come from the previous bar, as the last one may still be forming
+	if want := Classic(1, 0, 0.5); levels.Classic != want {
+		t.Errorf("Classic = %+v, want %+v", levels.Classic, want)
+	}
+
+	if empty := FindLevels(nil, LevelOptions{}); empty.Bars != 0 || empty.Swings != nil {
+		t.Errorf("no bars should give empty levels, got %+v", empty)
+	}
+}
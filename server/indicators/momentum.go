@@ -0,0 +1,80 @@
+package indicators
+
+import "math"
+
+// RSI is Wilder's relative strength index
+func RSI(values []float64, period int) []float64 {
+	n := len(values)
+	gains := nanSeries(n)
+	losses := nanSeries(n)
+	for i := 1; i < n; i++ {
+		diff := values[i] - values[i-1]
+		gains[i] = math.Max(diff, 0)
+		losses[i] = math.Max(-diff, 0)
+	}
+
+	avgGain := wilderSmooth(gains, period, 1)
+	avgLoss := wilderSmooth(losses, period, 1)
+
+	rsi := nanSeries(n)
+	for i := range rsi {
+		if math.IsNaN(avgGain[i]) || math.IsNaN(avgLoss[i]) {
+			continue
+		}
+		if avgLoss[i] == 0 {
+			rsi[i] = 100
+			continue
+		}
+		rsi[i] = 100 - 100/(1+avgGain[i]/avgLoss[i])
+	}
+	return rsi
+}
+
+// StochRSI applies the stochastic oscillator to RSI and smooths it into %K and %D (0-100)
+func StochRSI(values []float64, rsiPeriod, stochPeriod, kSmooth, dSmooth int) (k, d []float64) {
+	rsi := RSI(values, rsiPeriod)
+
+	raw := nanSeries(len(values))
+	for i := range rsi {
+		if i < stochPeriod-1 || stochPeriod <= 0 {
+			continue
+		}
+		window := rsi[i-stochPeriod+1 : i+1]
+		lo, hi := math.Inf(1), math.Inf(-1)
+		defined := true
+		for _, v := range window {
+			if math.IsNaN(v) {
+				defined = false
+				break
+			}
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+		if !defined {
+			continue
+		}
+		if hi == lo {
+			raw[i] = 50
+		} else {
+			raw[i] = (rsi[i] - lo) / (hi - lo) * 100
+		}
+	}
+
+	k = smaSkippingNaN(raw, kSmooth)
+	d = smaSkippingNaN(k, dSmooth)
+	return k, d
+}
+
+// smaSkippingNaN is an SMA that starts after the input's leading NaNs
+func smaSkippingNaN(values []float64, period int) []float64 {
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	out := nanSeries(len(values))
+	if start >= len(values) {
+		return out
+	}
+	copy(out[start:], SMA(values[start:], period))
+	return out
+}
@@ -0,0 +1,89 @@
+package indicators
+
+import "math"
+
+// SMA is the simple moving average over period values
+func SMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 || len(values) < period {
+		return out
+	}
+
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average, seeded with the SMA of the first period
+// values. Leading NaNs in the input are skipped.
+func EMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 {
+		return out
+	}
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < period {
+		return out
+	}
+
+	seed := 0.0
+	for _, v := range values[start : start+period] {
+		seed += v
+	}
+	prev := seed / float64(period)
+	out[start+period-1] = prev
+
+	k := 2.0 / float64(period+1)
+	for i := start + period; i < len(values); i++ {
+		prev = values[i]*k + prev*(1-k)
+		out[i] = prev
+	}
+	return out
+}
+
+// wilderSmooth is the running moving average used by RSI, ATR and ADX, seeded
+// with the mean of the first period values after start
+func wilderSmooth(values []float64, period, start int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+
+	sum := 0.0
+	for _, v := range values[start : start+period] {
+		sum += v
+	}
+	prev := sum / float64(period)
+	out[start+period-1] = prev
+
+	for i := start + period; i < len(values); i++ {
+		prev = (prev*float64(period-1) + values[i]) / float64(period)
+		out[i] = prev
+	}
+	return out
+}
+
+// Ribbon computes one moving average per period, SMA or EMA
+func Ribbon(values []float64, periods []int, exponential bool) [][]float64 {
+	ribbon := make([][]float64, len(periods))
+	for i, p := range periods {
+		if exponential {
+			ribbon[i] = EMA(values, p)
+		} else {
+			ribbon[i] = SMA(values, p)
+		}
+	}
+	return ribbon
+}
@@ -0,0 +1,92 @@
+package indicators
+
+import "math"
+
+// Biases of patterns and divergences
+const (
+	BiasBullish = "bullish"
+	BiasBearish = "bearish"
+	BiasNeutral = "neutral"
+)
+
+// Candlestick pattern names
+const (
+	PatternPinBar      = "pin bar"
+	PatternEngulfing   = "engulfing"
+	PatternInsideBar   = "inside bar"
+	PatternDoji        = "doji"
+	PatternMorningStar = "morning star"
+	PatternEveningStar = "evening star"
+)
+
+// Pattern is a candlestick pattern completed on the bar at Index
+type Pattern struct {
+	Name     string `json:"name"`
+	Bias     string `json:"bias"`
+	Index    int    `json:"index"`
+	Bars     int    `json:"bars"` // bars that make up the pattern, ending at Index
+	OpenTime int64  `json:"openTime"`
+}
+
+// Patterns finds pin bars, engulfing bars, inside bars, dojis and morning/evening
+// stars. A bar can complete more than one pattern.
+func Patterns(bars []Bar) []Pattern {
+	var patterns []Pattern
+	add := func(name, bias string, i, n int) {
+		patterns = append(patterns, Pattern{Name: name, Bias: bias, Index: i, Bars: n, OpenTime: bars[i].OpenTime})
+	}
+
+	for i, b := range bars {
+		rng := b.High - b.Low
+		if rng <= 0 {
+			continue
+		}
+		body := math.Abs(b.Close - b.Open)
+		upperWick := b.High - math.Max(b.Open, b.Close)
+		lowerWick := math.Min(b.Open, b.Close) - b.Low
+
+		// A long wick rejecting one side; a pin bar is not also reported as a doji
+		switch {
+		case lowerWick >= rng*2/3:
+			add(PatternPinBar, BiasBullish, i, 1)
+		case upperWick >= rng*2/3:
+			add(PatternPinBar, BiasBearish, i, 1)
+		case body <= rng*0.1:
+			add(PatternDoji, BiasNeutral, i, 1)
+		}
+
+		if i < 1 {
+			continue
+		}
+		prev := bars[i-1]
+		prevBody := math.Abs(prev.Close - prev.Open)
+
+		if prev.Close < prev.Open && b.Close > b.Open && b.Open <= prev.Close && b.Close >= prev.Open && body > prevBody {
+			add(PatternEngulfing, BiasBullish, i, 2)
+		}
+		if prev.Close > prev.Open && b.Close < b.Open && b.Open >= prev.Close && b.Close <= prev.Open && body > prevBody {
+			add(PatternEngulfing, BiasBearish, i, 2)
+		}
+		if b.High < prev.High && b.Low > prev.Low {
+			add(PatternInsideBar, BiasNeutral, i, 2)
+		}
+
+		if i < 2 {
+			continue
+		}
+		first, star := bars[i-2], prev
+		firstBody := math.Abs(first.Close - first.Open)
+		firstRange := first.High - first.Low
+		if firstRange <= 0 || firstBody < firstRange/2 || math.Abs(star.Close-star.Open) > firstBody*0.3 {
+			continue
+		}
+		firstMid := (first.Open + first.Close) / 2
+		if first.Close < first.Open && b.Close > b.Open && b.Close > firstMid {
+			add(PatternMorningStar, BiasBullish, i, 3)
+		}
+		if first.Close > first.Open && b.Close < b.Open && b.Close < firstMid {
+			add(PatternEveningStar, BiasBearish, i, 3)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,85 @@
+package indicators
+
+import (
+	"testing"
+)
+
+func ohlc(o, h, l, c float64) Bar {
+	return Bar{Open: o, High: h, Low: l, Close: c}
+}
+
+func TestPatterns(t *testing.T) {
+	type found struct {
+		name, bias string
+		index      int
+	}
+
+	tests := []struct {
+		name string
+		bars []Bar
+		want []found
+	}{
+		{
+			name: "bullish pin bar",
+			bars: []Bar{ohlc(9, 10, 0, 9.5)},
+			want: []found{{PatternPinBar, BiasBullish, 0}},
+		},
+		{
+			name: "bearish pin bar",
+			bars: []Bar{ohlc(1, 10, 0, 0.5)},
+			want: []found{{PatternPinBar, BiasBearish, 0}},
+		},
+		{
+			name: "doji",
+			bars: []Bar{ohlc(5, 10, 0, 5.5)},
+			want: []found{{PatternDoji, BiasNeutral, 0}},
+		},
+		{
+			name: "a bar without range is skipped",
+			bars: []Bar{ohlc(5, 5, 5, 5)},
+		},
+		{
+			name: "bullish engulfing",
+			bars: []Bar{ohlc(10, 10.5, 8.5, 9), ohlc(8.8, 11, 8.5, 10.5)},
+			want: []found{{PatternEngulfing, BiasBullish, 1}},
+		},
+		{
+			name: "bearish engulfing",
+			bars: []Bar{ohlc(9, 10.5, 8.5, 10), ohlc(10.2, 10.6, 8, 8.5)},
+			want: []found{{PatternEngulfing, BiasBearish, 1}},
+		},
+		{
+			name: "inside bar",
+			bars: []Bar{ohlc(3, 10, 0, 7), ohlc(4, 8, 2, 6)},
+			want: []found{{PatternInsideBar, BiasNeutral, 1}},
+		},
+		{
+			name: "morning star",
+			bars: []Bar{ohlc(10, 10.2, 5.8, 6), ohlc(5.5, 5.8, 5.1, 5.3), ohlc(5.5, 9.2, 5.4, 9)},
+			want: []found{{PatternMorningStar, BiasBullish, 2}},
+		},
+		{
+			name: "evening star",
+			bars: []Bar{ohlc(6, 10.2, 5.8, 10), ohlc(10.5, 10.9, 10.2, 10.7), ohlc(10.5, 10.6, 6.8, 7)},
+			want: []found{{PatternEveningStar, BiasBearish, 2}},
+		},
+		{
+			name: "no star when the recovery stays below the first body's middle",
+			bars: []Bar{ohlc(10, 10.2, 5.8, 6), ohlc(5.5, 5.8, 5.1, 5.3), ohlc(5.5, 7.7, 5.4, 7.5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Patterns(tt.bars)
+			if len(got) != len(tt.want) {
+				t.Fatalf("patterns = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Name != want.name || got[i].Bias != want.bias || got[i].Index != want.index {
+					t.Errorf("pattern %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Indicator names accepted in a Spec
+const (
+	NameATR       = "atr"
+	NameBollinger = "bollinger"
+	NameVWAP      = "vwap"
+	NameStochRSI  = "stochrsi"
+	NameADX       = "adx"
+	NameSMA       = "sma"
+	NameEMA       = "ema"
+	NameIchimoku  = "ichimoku"
+	NameKeltner   = "keltner"
+)
+
+// Spec selects an indicator and its parameters. Zero values use the defaults:
+//
+//	atr        period 14
+//	bollinger  period 20, multiplier 2
+//	vwap       period 0 (session VWAP reset daily; >0 for a rolling window)
+//	stochrsi   period 14 (RSI), stochPeriod 14, k 3, d 3
+//	adx        period 14
+//	sma, ema   periods [9 21 50]
+//	ichimoku   periods [9 26 52] (tenkan, kijun, senkou B)
+//	keltner    period 20 (EMA), atrPeriod 10, multiplier 2
+type Spec struct {
+	Name        string  `json:"name"`
+	Period      int     `json:"period,omitempty"`
+	Periods     []int   `json:"periods,omitempty"`
+	Multiplier  float64 `json:"multiplier,omitempty"`
+	ATRPeriod   int     `json:"atrPeriod,omitempty"`
+	StochPeriod int     `json:"stochPeriod,omitempty"`
+	K           int     `json:"k,omitempty"`
+	D           int     `json:"d,omitempty"`
+}
+
+// Field is one named value of a reading
+type Field struct {
+	Name  string
+	Value float64
+}
+
+// Reading is the latest value of an indicator, ready to be described in a prompt
+type Reading struct {
+	Label  string
+	Fields []Field
+}
+
+// Validate rejects unknown indicators and negative parameters
+func Validate(specs []Spec) error {
+	for _, spec := range specs {
+		switch strings.ToLower(spec.Name) {
+		case NameATR, NameBollinger, NameVWAP, NameStochRSI, NameADX, NameSMA, NameEMA, NameIchimoku, NameKeltner:
+		default:
+			return fmt.Errorf("unknown indicator %q", spec.Name)
+		}
+		if spec.Period < 0 || spec.Multiplier < 0 || spec.ATRPeriod < 0 || spec.StochPeriod < 0 || spec.K < 0 || spec.D < 0 {
+			return fmt.Errorf("indicator %s has a negative parameter", spec.Name)
+		}
+		for _, p := range spec.Periods {
+			if p <= 0 {
+				return fmt.Errorf("indicator %s has a non-positive period", spec.Name)
+			}
+		}
+		if strings.ToLower(spec.Name) == NameIchimoku && len(spec.Periods) != 0 && len(spec.Periods) != 3 {
+			return fmt.Errorf("ichimoku takes three periods: tenkan, kijun, senkou B")
+		}
+	}
+	return nil
+}
+
+// WithDefaults fills unset parameters
+func (s Spec) WithDefaults() Spec {
+	s.Name = strings.ToLower(s.Name)
+	switch s.Name {
+	case NameATR, NameADX:
+		s.Period = orDefault(s.Period, 14)
+	case NameBollinger:
+		s.Period = orDefault(s.Period, 20)
+		if s.Multiplier == 0 {
+			s.Multiplier = 2
+		}
+	case NameStochRSI:
+		s.Period = orDefault(s.Period, 14)
+		s.StochPeriod = orDefault(s.StochPeriod, 14)
+		s.K = orDefault(s.K, 3)
+		s.D = orDefault(s.D, 3)
+	case NameSMA, NameEMA:
+		if len(s.Periods) == 0 {
+			s.Periods = []int{9, 21, 50}
+		}
+	case NameIchimoku:
+		if len(s.Periods) != 3 {
+			s.Periods = []int{9, 26, 52}
+		}
+	case NameKeltner:
+		s.Period = orDefault(s.Period, 20)
+		s.ATRPeriod = orDefault(s.ATRPeriod, 10)
+		if s.Multiplier == 0 {
+			s.Multiplier = 2
+		}
+	}
+	return s
+}
+
+// Compute evaluates each spec over the bars and returns its latest reading.
+// Indicators without enough bars to produce a value are left out.
+func Compute(bars []Bar, specs []Spec) []Reading {
+	if len(bars) == 0 {
+		return nil
+	}
+	closes := Closes(bars)
+	last := len(bars) - 1
+	price := closes[last]
+
+	readings := make([]Reading, 0, len(specs))
+	for _, spec := range specs {
+		spec = spec.WithDefaults()
+
+		var r Reading
+		switch spec.Name {
+		case NameATR:
+			atr := ATR(bars, spec.Period)[last]
+			r = Reading{Label: fmt.Sprintf("ATR(%d)", spec.Period), Fields: []Field{
+				{"value", atr},
+				{"% of price", atr / price * 100},
+			}}
+		case NameBollinger:
+			b := Bollinger(closes, spec.Period, spec.Multiplier)
+			upper, middle, lower := b.Upper[last], b.Middle[last], b.Lower[last]
+			r = Reading{Label: fmt.Sprintf("Bollinger(%d, %g)", spec.Period, spec.Multiplier), Fields: []Field{
+				{"upper", upper},
+				{"middle", middle},
+				{"lower", lower},
+				{"%B", (price - lower) / (upper - lower)},
+				{"bandwidth %", (upper - lower) / middle * 100},
+			}}
+		case NameVWAP:
+			label, vwap := "VWAP(session)", SessionVWAP(bars)[last]
+			if spec.Period > 0 {
+				label, vwap = fmt.Sprintf("VWAP(%d)", spec.Period), RollingVWAP(bars, spec.Period)[last]
+			}
+			r = Reading{Label: label, Fields: []Field{
+				{"value", vwap},
+				{"price vs VWAP %", percentOf(price, vwap)},
+			}}
+		case NameStochRSI:
+			k, d := StochRSI(closes, spec.Period, spec.StochPeriod, spec.K, spec.D)
+			r = Reading{Label: fmt.Sprintf("StochRSI(%d, %d, %d, %d)", spec.Period, spec.StochPeriod, spec.K, spec.D), Fields: []Field{
+				{"%K", k[last]},
+				{"%D", d[last]},
+			}}
+		case NameADX:
+			dmi := ADX(bars, spec.Period)
+			r = Reading{Label: fmt.Sprintf("ADX/DMI(%d)", spec.Period), Fields: []Field{
+				{"ADX", dmi.ADX[last]},
+				{"+DI", dmi.PlusDI[last]},
+				{"-DI", dmi.MinusDI[last]},
+			}}
+		case NameSMA, NameEMA:
+			name := strings.ToUpper(spec.Name)
+			ribbon := Ribbon(closes, spec.Periods, spec.Name == NameEMA)
+			r = Reading{Label: fmt.Sprintf("%s ribbon%v", name, spec.Periods)}
+			for i, p := range spec.Periods {
+				r.Fields = append(r.Fields, Field{fmt.Sprintf("%s%d", name, p), ribbon[i][last]})
+			}
+		case NameIchimoku:
+			c := Ichimoku(bars, spec.Periods[0], spec.Periods[1], spec.Periods[2])
+			r = Reading{Label: fmt.Sprintf("Ichimoku(%d, %d, %d)", spec.Periods[0], spec.Periods[1], spec.Periods[2]), Fields: []Field{
+				{"tenkan", c.Tenkan[last]},
+				{"kijun", c.Kijun[last]},
+				{"senkou A", c.SenkouA[last]},
+				{"senkou B", c.SenkouB[last]},
+			}}
+		case NameKeltner:
+			k := Keltner(bars, spec.Period, spec.ATRPeriod, spec.Multiplier)
+			r = Reading{Label: fmt.Sprintf("Keltner(%d, %d, %g)", spec.Period, spec.ATRPeriod, spec.Multiplier), Fields: []Field{
+				{"upper", k.Upper[last]},
+				{"middle", k.Middle[last]},
+				{"lower", k.Lower[last]},
+			}}
+		default:
+			continue
+		}
+
+		if hasValue(r.Fields) {
+			readings = append(readings, r)
+		}
+	}
+	return readings
+}
+
+func hasValue(fields []Field) bool {
+	for _, f := range fields {
+		if !math.IsNaN(f.Value) && !math.IsInf(f.Value, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
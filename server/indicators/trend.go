@@ -0,0 +1,110 @@
+package indicators
+
+import "math"
+
+// DMI holds the directional movement indicators and ADX
+type DMI struct {
+	PlusDI  []float64
+	MinusDI []float64
+	ADX     []float64
+}
+
+// ADX computes Wilder's +DI, -DI and ADX; ADX needs roughly 2*period bars
+func ADX(bars []Bar, period int) DMI {
+	n := len(bars)
+	plusDM := nanSeries(n)
+	minusDM := nanSeries(n)
+	for i := 1; i < n; i++ {
+		up := bars[i].High - bars[i-1].High
+		down := bars[i-1].Low - bars[i].Low
+		plusDM[i], minusDM[i] = 0, 0
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+	}
+
+	atr := ATR(bars, period)
+	smoothPlus := wilderSmooth(plusDM, period, 1)
+	smoothMinus := wilderSmooth(minusDM, period, 1)
+
+	dmi := DMI{
+		PlusDI:  nanSeries(n),
+		MinusDI: nanSeries(n),
+	}
+	dx := nanSeries(n)
+	firstDX := -1
+	for i := 0; i < n; i++ {
+		if math.IsNaN(atr[i]) || atr[i] == 0 || math.IsNaN(smoothPlus[i]) {
+			continue
+		}
+		dmi.PlusDI[i] = 100 * smoothPlus[i] / atr[i]
+		dmi.MinusDI[i] = 100 * smoothMinus[i] / atr[i]
+		sum := dmi.PlusDI[i] + dmi.MinusDI[i]
+		if sum == 0 {
+			dx[i] = 0
+		} else {
+			dx[i] = 100 * math.Abs(dmi.PlusDI[i]-dmi.MinusDI[i]) / sum
+		}
+		if firstDX < 0 {
+			firstDX = i
+		}
+	}
+
+	dmi.ADX = nanSeries(n)
+	if firstDX >= 0 {
+		dmi.ADX = wilderSmooth(dx, period, firstDX)
+	}
+	return dmi
+}
+
+// IchimokuCloud holds the Ichimoku lines. SenkouA and SenkouB are aligned with
+// the bar they are plotted on (computed kijun bars earlier), so comparing price
+// with the cloud at index i needs no shifting.
+type IchimokuCloud struct {
+	Tenkan  []float64
+	Kijun   []float64
+	SenkouA []float64
+	SenkouB []float64
+}
+
+// Ichimoku computes the cloud with the given conversion, base and leading span B periods
+func Ichimoku(bars []Bar, tenkanPeriod, kijunPeriod, senkouBPeriod int) IchimokuCloud {
+	n := len(bars)
+	tenkan := midpoint(bars, tenkanPeriod)
+	kijun := midpoint(bars, kijunPeriod)
+	spanB := midpoint(bars, senkouBPeriod)
+
+	cloud := IchimokuCloud{
+		Tenkan:  tenkan,
+		Kijun:   kijun,
+		SenkouA: nanSeries(n),
+		SenkouB: nanSeries(n),
+	}
+
+	displacement := kijunPeriod
+	for i := displacement; i < n; i++ {
+		src := i - displacement
+		if !math.IsNaN(tenkan[src]) && !math.IsNaN(kijun[src]) {
+			cloud.SenkouA[i] = (tenkan[src] + kijun[src]) / 2
+		}
+		cloud.SenkouB[i] = spanB[src]
+	}
+	return cloud
+}
+
+// midpoint is (highest high + lowest low) / 2 over period bars
+func midpoint(bars []Bar, period int) []float64 {
+	out := nanSeries(len(bars))
+	for i := period - 1; i < len(bars) && period > 0; i++ {
+		hi, lo := math.Inf(-1), math.Inf(1)
+		for _, b := range bars[i-period+1 : i+1] {
+			hi = math.Max(hi, b.High)
+			lo = math.Min(lo, b.Low)
+		}
+		out[i] = (hi + lo) / 2
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package indicators
+
+import "math"
+
+// Bands is an upper/middle/lower envelope such as Bollinger or Keltner
+type Bands struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+// TrueRange is max(high-low, |high-prevClose|, |low-prevClose|); the first bar has no
+// previous close and is NaN
+func TrueRange(bars []Bar) []float64 {
+	tr := nanSeries(len(bars))
+	for i := 1; i < len(bars); i++ {
+		h, l, pc := bars[i].High, bars[i].Low, bars[i-1].Close
+		tr[i] = math.Max(h-l, math.Max(math.Abs(h-pc), math.Abs(l-pc)))
+	}
+	return tr
+}
+
+// ATR is Wilder's average true range; the first value needs period+1 bars
+func ATR(bars []Bar, period int) []float64 {
+	return wilderSmooth(TrueRange(bars), period, 1)
+}
+
+// Bollinger returns an SMA with bands multiplier standard deviations away
+func Bollinger(values []float64, period int, multiplier float64) Bands {
+	middle := SMA(values, period)
+	bands := Bands{
+		Upper:  nanSeries(len(values)),
+		Middle: middle,
+		Lower:  nanSeries(len(values)),
+	}
+
+	for i := period - 1; i < len(values) && period > 0; i++ {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+		variance := 0.0
+		for _, v := range values[i-period+1 : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		sd := math.Sqrt(variance / float64(period))
+		bands.Upper[i] = middle[i] + multiplier*sd
+		bands.Lower[i] = middle[i] - multiplier*sd
+	}
+	return bands
+}
+
+// Keltner returns an EMA of closes with bands multiplier ATRs away
+func Keltner(bars []Bar, emaPeriod, atrPeriod int, multiplier float64) Bands {
+	middle := EMA(Closes(bars), emaPeriod)
+	atr := ATR(bars, atrPeriod)
+	bands := Bands{
+		Upper:  nanSeries(len(bars)),
+		Middle: middle,
+		Lower:  nanSeries(len(bars)),
+	}
+
+	for i := range bars {
+		if math.IsNaN(middle[i]) || math.IsNaN(atr[i]) {
+			continue
+		}
+		bands.Upper[i] = middle[i] + multiplier*atr[i]
+		bands.Lower[i] = middle[i] - multiplier*atr[i]
+	}
+	return bands
+}
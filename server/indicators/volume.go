@@ -0,0 +1,47 @@
+package indicators
+
+import "math"
+
+// SessionVWAP is the volume-weighted average typical price, reset at each UTC day
+func SessionVWAP(bars []Bar) []float64 {
+	out := nanSeries(len(bars))
+	var pv, vol float64
+	var day int64 = -1
+	for i, b := range bars {
+		if d := b.OpenTime / 86_400_000; d != day {
+			day = d
+			pv, vol = 0, 0
+		}
+		typical := (b.High + b.Low + b.Close) / 3
+		pv += typical * b.Volume
+		vol += b.Volume
+		if vol > 0 {
+			out[i] = pv / vol
+		}
+	}
+	return out
+}
+
+// RollingVWAP is the volume-weighted average typical price over the last period bars
+func RollingVWAP(bars []Bar, period int) []float64 {
+	out := nanSeries(len(bars))
+	for i := period - 1; i < len(bars) && period > 0; i++ {
+		var pv, vol float64
+		for _, b := range bars[i-period+1 : i+1] {
+			pv += (b.High + b.Low + b.Close) / 3 * b.Volume
+			vol += b.Volume
+		}
+		if vol > 0 {
+			out[i] = pv / vol
+		}
+	}
+	return out
+}
+
+// percentOf returns how far value is from ref, in percent of ref
+func percentOf(value, ref float64) float64 {
+	if ref == 0 || math.IsNaN(value) || math.IsNaN(ref) {
+		return math.NaN()
+	}
+	return (value - ref) / ref * 100
+}
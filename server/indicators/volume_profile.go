@@ -0,0 +1,157 @@
+package indicators
+
+import "math"
+
+// Value area and node detection defaults
+const (
+	DefaultProfileBins = 24
+	DefaultValueArea   = 0.7 // share of volume inside the value area
+
+	// Node thresholds against the mean bin volume, after 3-bin smoothing
+	highVolumeNodeRatio = 1.0
+	lowVolumeNodeRatio  = 0.6
+)
+
+// ProfileBin is one price row of a volume profile
+type ProfileBin struct {
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	Volume float64 `json:"volume"`
+}
+
+// VolumeNode is a local peak (high volume node) or trough (low volume node) of the profile
+type VolumeNode struct {
+	Price  float64 `json:"price"`
+	Volume float64 `json:"volume"`
+}
+
+// VolumeProfile is the traded volume per price over a window of bars
+type VolumeProfile struct {
+	Bars            int          `json:"bars"`
+	Low             float64      `json:"low"`
+	High            float64      `json:"high"`
+	TotalVolume     float64      `json:"totalVolume"`
+	Bins            []ProfileBin `json:"bins"`
+	POC             float64      `json:"poc"` // middle of the bin with the most volume
+	ValueAreaHigh   float64      `json:"valueAreaHigh"`
+	ValueAreaLow    float64      `json:"valueAreaLow"`
+	HighVolumeNodes []VolumeNode `json:"highVolumeNodes"`
+	LowVolumeNodes  []VolumeNode `json:"lowVolumeNodes"`
+}
+
+// ComputeVolumeProfile spreads each bar's volume evenly over its high-low range
+// across the given number of price bins, then finds the point of control, the
+// value area holding valueArea of the volume, and the high/low volume nodes.
+// It returns nil when the bars carry no volume or no price range.
+func ComputeVolumeProfile(bars []Bar, bins int, valueArea float64) *VolumeProfile {
+	if bins <= 0 {
+		bins = DefaultProfileBins
+	}
+	if valueArea <= 0 || valueArea > 1 {
+		valueArea = DefaultValueArea
+	}
+	if len(bars) == 0 {
+		return nil
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, b := range bars {
+		low = math.Min(low, b.Low)
+		high = math.Max(high, b.High)
+	}
+	if !(high > low) {
+		return nil
+	}
+
+	step := (high - low) / float64(bins)
+	profile := &VolumeProfile{Bars: len(bars), Low: low, High: high, Bins: make([]ProfileBin, bins)}
+	for i := range profile.Bins {
+		profile.Bins[i].Low = low + float64(i)*step
+		profile.Bins[i].High = low + float64(i+1)*step
+	}
+
+	binOf := func(price float64) int {
+		i := int((price - low) / step)
+		return min(max(i, 0), bins-1)
+	}
+	for _, b := range bars {
+		if b.Volume <= 0 {
+			continue
+		}
+		profile.TotalVolume += b.Volume
+		rng := b.High - b.Low
+		if rng <= 0 {
+			profile.Bins[binOf(b.Close)].Volume += b.Volume
+			continue
+		}
+		for i := binOf(b.Low); i <= binOf(b.High); i++ {
+			overlap := math.Min(b.High, profile.Bins[i].High) - math.Max(b.Low, profile.Bins[i].Low)
+			if overlap > 0 {
+				profile.Bins[i].Volume += b.Volume * overlap / rng
+			}
+		}
+	}
+	if profile.TotalVolume == 0 {
+		return nil
+	}
+
+	poc := 0
+	for i, bin := range profile.Bins {
+		if bin.Volume > profile.Bins[poc].Volume {
+			poc = i
+		}
+	}
+	profile.POC = (profile.Bins[poc].Low + profile.Bins[poc].High) / 2
+
+	// Grow the value area from the POC towards whichever neighbour holds more volume
+	lo, hi := poc, poc
+	inside := profile.Bins[poc].Volume
+	for inside < profile.TotalVolume*valueArea && (lo > 0 || hi < bins-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = profile.Bins[lo-1].Volume
+		}
+		if hi < bins-1 {
+			above = profile.Bins[hi+1].Volume
+		}
+		if above >= below {
+			hi++
+			inside += above
+		} else {
+			lo--
+			inside += below
+		}
+	}
+	profile.ValueAreaLow = profile.Bins[lo].Low
+	profile.ValueAreaHigh = profile.Bins[hi].High
+
+	profile.HighVolumeNodes, profile.LowVolumeNodes = volumeNodes(profile.Bins, profile.TotalVolume/float64(bins))
+	return profile
+}
+
+// volumeNodes finds local peaks and troughs of the smoothed profile
+func volumeNodes(bins []ProfileBin, mean float64) ([]VolumeNode, []VolumeNode) {
+	smoothed := make([]float64, len(bins))
+	for i := range bins {
+		sum, n := 0.0, 0
+		for j := i - 1; j <= i+1; j++ {
+			if j >= 0 && j < len(bins) {
+				sum += bins[j].Volume
+				n++
+			}
+		}
+		smoothed[i] = sum / float64(n)
+	}
+
+	var hvn, lvn []VolumeNode
+	for i := 1; i < len(bins)-1; i++ {
+		node := VolumeNode{Price: (bins[i].Low + bins[i].High) / 2, Volume: bins[i].Volume}
+		if smoothed[i] > smoothed[i-1] && smoothed[i] >= smoothed[i+1] && smoothed[i] >= mean*highVolumeNodeRatio {
+			hvn = append(hvn, node)
+		}
+		if smoothed[i] < smoothed[i-1] && smoothed[i] <= smoothed[i+1] && smoothed[i] <= mean*lowVolumeNodeRatio {
+			lvn = append(lvn, node)
+		}
+	}
+	return hvn, lvn
+}
@@ -0,0 +1,109 @@
+package indicators
+
+import "testing"
+
+// volumeBar spreads volume over a high-low range
+func volumeBar(low, high, volume float64) Bar {
+	return Bar{High: high, Low: low, Close: (high + low) / 2, Volume: volume}
+}
+
+func TestComputeVolumeProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		bars     []Bar
+		wantBins []float64
+		wantPOC  float64
+		wantVAL  float64
+		wantVAH  float64
+		wantHVN  []VolumeNode
+		wantLVN  []VolumeNode
+		wantNil  bool
+	}{
+		{
+			// A unit per bin from the wide bar, plus 10 in each of bins 4 and 5
+			name:     "single peak",
+			bars:     []Bar{volumeBar(0, 10, 10), volumeBar(4, 6, 20)},
+			wantBins: []float64{1, 1, 1, 1, 11, 11, 1, 1, 1, 1},
+			wantPOC:  4.5,
+			wantVAL:  4,
+			wantVAH:  6,
+			wantHVN:  []VolumeNode{{Price: 4.5, Volume: 11}},
+			wantLVN:  []VolumeNode{{Price: 7.5, Volume: 1}}, // where the smoothed peak flattens out
+		},
+		{
+			// The value area grows from the first peak through the trough to the second
+			name:     "two peaks around a trough",
+			bars:     []Bar{volumeBar(0, 10, 10), volumeBar(1, 3, 20), volumeBar(7, 9, 20)},
+			wantBins: []float64{1, 11, 11, 1, 1, 1, 1, 11, 11, 1},
+			wantPOC:  1.5,
+			wantVAL:  1,
+			wantVAH:  8,
+			wantHVN:  []VolumeNode{{Price: 1.5, Volume: 11}, {Price: 7.5, Volume: 11}},
+			wantLVN:  []VolumeNode{{Price: 4.5, Volume: 1}},
+		},
+		{
+			name:     "a bar without range lands in the bin of its close",
+			bars:     []Bar{volumeBar(0, 10, 10), volumeBar(5, 5, 4)},
+			wantBins: []float64{1, 1, 1, 1, 1, 5, 1, 1, 1, 1},
+			wantPOC:  5.5,
+			wantVAL:  4,
+			wantVAH:  10,
+			// Smoothing spreads a one-bin spike, so its node is where the plateau starts
+			wantHVN: []VolumeNode{{Price: 4.5, Volume: 1}},
+		},
+		{name: "no volume", bars: []Bar{volumeBar(0, 10, 0)}, wantNil: true},
+		{name: "no price range", bars: []Bar{volumeBar(5, 5, 10)}, wantNil: true},
+		{name: "no bars", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := ComputeVolumeProfile(tt.bars, 10, 0.7)
+			if tt.wantNil {
+				if profile != nil {
+					t.Fatalf("expected no profile, got %+v", profile)
+				}
+				return
+			}
+			if profile == nil {
+				t.Fatal("expected a profile")
+			}
+
+			volumes := make([]float64, len(profile.Bins))
+			for i, bin := range profile.Bins {
+				volumes[i] = bin.Volume
+			}
+			checkSeries(t, "bin volumes", volumes, tt.wantBins)
+
+			if profile.POC != tt.wantPOC || profile.ValueAreaLow != tt.wantVAL || profile.ValueAreaHigh != tt.wantVAH {
+				t.Errorf("POC %g, value area %g-%g; want %g, %g-%g",
+					profile.POC, profile.ValueAreaLow, profile.ValueAreaHigh, tt.wantPOC, tt.wantVAL, tt.wantVAH)
+			}
+			checkNodes(t, "high volume nodes", profile.HighVolumeNodes, tt.wantHVN)
+			checkNodes(t, "low volume nodes", profile.LowVolumeNodes, tt.wantLVN)
+		})
+	}
+}
+
+func checkNodes(t *testing.T, name string, got, want []VolumeNode) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", name, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s[%d] = %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestComputeVolumeProfileDefaults(t *testing.T) {
+	profile := ComputeVolumeProfile([]Bar{volumeBar(0, 24, 24)}, 0, 2)
+	if profile == nil || len(profile.Bins) != DefaultProfileBins {
+		t.Fatalf("expected %d bins, got %+v", DefaultProfileBins, profile)
+	}
+	// An even profile grows the value area one bin at a time until it holds 70%
+	if width := profile.ValueAreaHigh - profile.ValueAreaLow; width != 17 {
+		t.Errorf("value area spans %g bins, want 17", width)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Candle is a persisted OHLCV candle, unique per symbol/interval/openTime
+type Candle struct {
+	ID                       primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Symbol                   string             `json:"symbol" bson:"symbol"`
+	Interval                 string             `json:"interval" bson:"interval"`
+	OpenTime                 int64              `json:"openTime" bson:"openTime"`
+	Open                     float64            `json:"open" bson:"open"`
+	High                     float64            `json:"high" bson:"high"`
+	Low                      float64            `json:"low" bson:"low"`
+	Close                    float64            `json:"close" bson:"close"`
+	Volume                   float64            `json:"volume" bson:"volume"`
+	CloseTime                int64              `json:"closeTime" bson:"closeTime"`
+	QuoteAssetVolume         float64            `json:"quoteAssetVolume" bson:"quoteAssetVolume"`
+	NumberOfTrades           int                `json:"numberOfTrades" bson:"numberOfTrades"`
+	TakerBuyBaseAssetVolume  float64            `json:"takerBuyBaseAssetVolume" bson:"takerBuyBaseAssetVolume"`
+	TakerBuyQuoteAssetVolume float64            `json:"takerBuyQuoteAssetVolume" bson:"takerBuyQuoteAssetVolume"`
+	UpdatedAt                time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type BackfillCandlesRequest struct {
+	Symbol    string `json:"symbol" binding:"required"`
+	Interval  string `json:"interval" binding:"required"`
+	StartTime int64  `json:"startTime" binding:"required"`
+	EndTime   int64  `json:"endTime"`
+}
+
+type BackfillCandlesResponse struct {
+	Success bool   `json:"success"`
+	Stored  int    `json:"stored"`
+	Message string `json:"message,omitempty"`
+}
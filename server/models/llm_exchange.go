@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LLMExchange is one agent's conversation with a model during a signal run
+type LLMExchange struct {
+	ID            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	SignalID      primitive.ObjectID `json:"signalId" bson:"signalId"`
+	Symbol        string             `json:"symbol" bson:"symbol"`
+	Agent         string             `json:"agent" bson:"agent"` // agent name, or meta for the meta-agent
+	Model         string             `json:"model" bson:"model"` // catalog name
+	Provider      string             `json:"provider" bson:"provider"`
+	ProviderModel string             `json:"providerModel" bson:"providerModel"`
+	Prompt        string             `json:"prompt" bson:"prompt"`
+	Response      string             `json:"response" bson:"response"` // validated answer handed to the run
+	Error         string             `json:"error,omitempty" bson:"error,omitempty"`
+	LatencyMs     int64              `json:"latencyMs" bson:"latencyMs"` // all attempts together
+	Usage         LLMUsage           `json:"usage" bson:"usage"`         // all attempts together
+	Attempts      []LLMAttempt       `json:"attempts" bson:"attempts"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// LLMAttempt is one request of an exchange; repair requests follow the first
+type LLMAttempt struct {
+	Response  string   `json:"response" bson:"response"`               // raw text or function arguments
+	Error     string   `json:"error,omitempty" bson:"error,omitempty"` // API or validation error
+	LatencyMs int64    `json:"latencyMs" bson:"latencyMs"`
+	Usage     LLMUsage `json:"usage" bson:"usage"`
+}
+
+// LLMUsage is token usage and its cost at the configured model prices
+type LLMUsage struct {
+	PromptTokens     int     `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens" bson:"completionTokens"`
+	CostUSD          float64 `json:"costUsd" bson:"costUsd"`
+}
+
+// Add accumulates another usage
+func (u *LLMUsage) Add(other LLMUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.CostUSD += other.CostUSD
+}
+
+// LLMSpend is the LLM usage of one day, model and symbol
+type LLMSpend struct {
+	Day              string  `json:"day" bson:"day"` // UTC, YYYY-MM-DD
+	Model            string  `json:"model" bson:"model"`
+	Symbol           string  `json:"symbol" bson:"symbol"`
+	Requests         int     `json:"requests" bson:"requests"` // agent and meta-agent exchanges
+	Signals          int     `json:"signals" bson:"signals"`   // signal runs, including runs that failed
+	PromptTokens     int     `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens" bson:"completionTokens"`
+	CostUSD          float64 `json:"costUsd" bson:"costUsd"`
+}
@@ -18,6 +18,7 @@ type Position struct {
 	Leverage     int                `json:"leverage" bson:"leverage" binding:"required,min=1,max=125"`
 	Status       string             `json:"status" bson:"status"`
 	IsTestnet    bool               `json:"isTestnet" bson:"isTestnet"`
+	Venue        string             `json:"venue,omitempty" bson:"venue,omitempty"`
 	CreatedAt    time.Time          `json:"timestamp" bson:"createdAt"`
 	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
 
@@ -27,6 +28,12 @@ type Position struct {
 	TakeProfit   float64   `json:"takeProfit,omitempty" bson:"takeProfit,omitempty"`
 	ClosedAt     *time.Time `json:"closedAt,omitempty" bson:"closedAt,omitempty"`
 	ClosePrice   float64   `json:"closePrice,omitempty" bson:"closePrice,omitempty"`
+
+	// Margin engine output, refreshed with the mark price
+	MarginMode        string  `json:"marginMode,omitempty" bson:"marginMode,omitempty"`
+	LiquidationPrice  float64 `json:"liquidationPrice,omitempty" bson:"liquidationPrice,omitempty"`
+	MaintenanceMargin float64 `json:"maintenanceMargin,omitempty" bson:"maintenanceMargin,omitempty"`
+	MarginRatio       float64 `json:"marginRatio,omitempty" bson:"marginRatio,omitempty"`
 }
 
 type PositionResponse struct {
@@ -40,9 +47,15 @@ type PositionResponse struct {
 	PnLPercentage float64 `json:"pnlPercentage"`
 	Leverage     int     `json:"leverage"`
 	Status       string  `json:"status"`
+	Venue        string  `json:"venue"`
 	Timestamp    string  `json:"timestamp"`
 	ClosedAt     *string `json:"closedAt,omitempty"`
 	ClosePrice   float64 `json:"closePrice,omitempty"`
+
+	MarginMode        string  `json:"marginMode,omitempty"`
+	LiquidationPrice  float64 `json:"liquidationPrice"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	MarginRatio       float64 `json:"marginRatio"`
 }
 
 func (p *Position) ToResponse() PositionResponse {
@@ -57,8 +70,14 @@ func (p *Position) ToResponse() PositionResponse {
 		PnLPercentage: p.PnLPercentage,
 		Leverage:     p.Leverage,
 		Status:       p.Status,
+		Venue:        p.Venue,
 		Timestamp:    p.CreatedAt.Format(time.RFC3339),
 		ClosePrice:   p.ClosePrice,
+
+		MarginMode:        p.MarginMode,
+		LiquidationPrice:  p.LiquidationPrice,
+		MaintenanceMargin: p.MaintenanceMargin,
+		MarginRatio:       p.MarginRatio,
 	}
 	
 	if p.ClosedAt != nil {
@@ -76,6 +95,8 @@ type CreatePositionRequest struct {
 	EntryPrice float64 `json:"entryPrice" binding:"required,gt=0"`
 	Leverage   int     `json:"leverage" binding:"required,min=1,max=125"`
 	IsTestnet  bool    `json:"isTestnet"`
+	Venue      string  `json:"venue,omitempty"`
+	MarginMode string  `json:"marginMode,omitempty" binding:"omitempty,oneof=ISOLATED CROSSED"`
 	StopLoss   float64 `json:"stopLoss,omitempty"`
 	TakeProfit float64 `json:"takeProfit,omitempty"`
 }
@@ -94,4 +115,19 @@ type ClosePositionResponse struct {
 	Success  bool             `json:"success"`
 	Position PositionResponse `json:"position"`
 	Message  string           `json:"message,omitempty"`
+}
+
+// MarginPreviewRequest describes a proposed position for the margin engine.
+// With a SignalID, missing fields are taken from the signal and the size from
+// the same balance-based sizing used at execution.
+type MarginPreviewRequest struct {
+	SignalID      string  `json:"signalId,omitempty"`
+	Symbol        string  `json:"symbol,omitempty"`
+	Direction     string  `json:"direction,omitempty" binding:"omitempty,oneof=LONG SHORT"`
+	Size          float64 `json:"size,omitempty"`
+	EntryPrice    float64 `json:"entryPrice,omitempty"`
+	Leverage      int     `json:"leverage,omitempty" binding:"omitempty,min=1,max=125"`
+	MarginMode    string  `json:"marginMode,omitempty" binding:"omitempty,oneof=ISOLATED CROSSED"`
+	WalletBalance float64 `json:"walletBalance,omitempty"` // cross margin only; defaults to the venue balance
+	IsTestnet     bool    `json:"isTestnet"`
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"saturday-autotrade/indicators"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -28,7 +29,75 @@ type TradingSignal struct {
 	TransactionId      string     `json:"transactionId,omitempty" bson:"transactionId,omitempty"`
 	ExecutionPrice     float64    `json:"executionPrice,omitempty" bson:"executionPrice,omitempty"`
 	IsTestnet          bool       `json:"isTestnet" bson:"isTestnet"`
+	Venue              string     `json:"venue,omitempty" bson:"venue,omitempty"`
 	TimeframesAnalyzed []string   `json:"timeframesAnalyzed,omitempty" bson:"timeframesAnalyzed,omitempty"`
+
+	// Derivatives context seen by the agents
+	FundingRate     float64    `json:"fundingRate,omitempty" bson:"fundingRate,omitempty"`
+	NextFundingTime *time.Time `json:"nextFundingTime,omitempty" bson:"nextFundingTime,omitempty"`
+
+	// Patterns and divergences detected in the data shown to the Reversal agent
+	DetectedEvents []DetectedEvent `json:"detectedEvents,omitempty" bson:"detectedEvents,omitempty"`
+
+	// Each agent's own signal, before aggregation
+	Agents []AgentVerdict `json:"agents,omitempty" bson:"agents,omitempty"`
+
+	// How the agents were combined: llm, deterministic or llm_veto; MetaRule is the
+	// consensus rule that fired when the deterministic rules ran
+	MetaMode string `json:"metaMode,omitempty" bson:"metaMode,omitempty"`
+	MetaRule string `json:"metaRule,omitempty" bson:"metaRule,omitempty"`
+	Vetoed   bool   `json:"vetoed,omitempty" bson:"vetoed,omitempty"` // the rules overruled the meta-agent's trade
+
+	// Tokens and cost of every LLM request of the run, repairs included
+	Usage *LLMUsage `json:"usage,omitempty" bson:"usage,omitempty"`
+
+	// Sanity checks run before the signal was saved; execution is refused when not executable
+	Validation *SignalValidation `json:"validation,omitempty" bson:"validation,omitempty"`
+}
+
+// DetectedEvent is a candlestick pattern or oscillator divergence found by the
+// deterministic detectors, kept so agent claims can be audited
+type DetectedEvent struct {
+	Timeframe string `json:"timeframe" bson:"timeframe"`
+	Type      string `json:"type" bson:"type"` // pattern or divergence
+	Name      string `json:"name" bson:"name"`
+	Bias      string `json:"bias" bson:"bias"`
+	Candle    int    `json:"candle" bson:"candle"` // candle number in the prompt
+	OpenTime  int64  `json:"openTime" bson:"openTime"`
+	Detail    string `json:"detail,omitempty" bson:"detail,omitempty"`
+}
+
+// AgentVerdict is one agent's signal as it was handed to the meta step
+type AgentVerdict struct {
+	Agent      string  `json:"agent" bson:"agent"`
+	Direction  string  `json:"direction" bson:"direction"`
+	Entry      float64 `json:"entry" bson:"entry"` // prices are 0 when the agent saw no setup
+	SL         float64 `json:"sl" bson:"sl"`
+	TP         float64 `json:"tp" bson:"tp"`
+	RR         float64 `json:"rr" bson:"rr"`
+	Confidence int     `json:"confidence" bson:"confidence"`
+	Thoughts   string  `json:"thoughts" bson:"thoughts"`
+	Model      string  `json:"model" bson:"model"`
+	LatencyMs  int64   `json:"latencyMs" bson:"latencyMs"`
+}
+
+// SignalValidation is the outcome of the deterministic checks on a signal's prices
+type SignalValidation struct {
+	Executable   bool              `json:"executable" bson:"executable"`
+	NoSetup      bool              `json:"noSetup" bson:"noSetup"` // zero prices or zero confidence
+	Issues       []ValidationIssue `json:"issues,omitempty" bson:"issues,omitempty"`
+	CurrentPrice float64           `json:"currentPrice" bson:"currentPrice"`
+	RangeLow     float64           `json:"rangeLow,omitempty" bson:"rangeLow,omitempty"` // recent low of the analysed candles
+	RangeHigh    float64           `json:"rangeHigh,omitempty" bson:"rangeHigh,omitempty"`
+	CheckedAt    time.Time         `json:"checkedAt" bson:"checkedAt"`
+}
+
+// ValidationIssue is one failed check. Errors block execution; warnings record
+// a value that was repaired or could not be checked.
+type ValidationIssue struct {
+	Code     string `json:"code" bson:"code"`
+	Severity string `json:"severity" bson:"severity"` // error or warning
+	Message  string `json:"message" bson:"message"`
 }
 
 type TradingSignalResponse struct {
@@ -48,6 +117,21 @@ type TradingSignalResponse struct {
 	TransactionId  string  `json:"transactionId,omitempty"`
 	ExecutionPrice float64 `json:"executionPrice,omitempty"`
 	IsTestnet      bool    `json:"isTestnet"`
+	Venue          string  `json:"venue"`
+
+	FundingRate     float64 `json:"fundingRate,omitempty"`
+	NextFundingTime *string `json:"nextFundingTime,omitempty"`
+
+	DetectedEvents []DetectedEvent `json:"detectedEvents,omitempty"`
+
+	Agents []AgentVerdict `json:"agents,omitempty"`
+
+	MetaMode string `json:"metaMode,omitempty"`
+	MetaRule string `json:"metaRule,omitempty"`
+	Vetoed   bool   `json:"vetoed,omitempty"`
+
+	Usage      *LLMUsage         `json:"usage,omitempty"`
+	Validation *SignalValidation `json:"validation,omitempty"`
 }
 
 func (ts *TradingSignal) ToResponse() TradingSignalResponse {
@@ -67,6 +151,15 @@ func (ts *TradingSignal) ToResponse() TradingSignalResponse {
 		TransactionId:  ts.TransactionId,
 		ExecutionPrice: ts.ExecutionPrice,
 		IsTestnet:      ts.IsTestnet,
+		Venue:          ts.Venue,
+		FundingRate:    ts.FundingRate,
+		DetectedEvents: ts.DetectedEvents,
+		Agents:         ts.Agents,
+		MetaMode:       ts.MetaMode,
+		MetaRule:       ts.MetaRule,
+		Vetoed:         ts.Vetoed,
+		Usage:          ts.Usage,
+		Validation:     ts.Validation,
 	}
 
 	if ts.ExecutedAt != nil {
@@ -74,13 +167,42 @@ func (ts *TradingSignal) ToResponse() TradingSignalResponse {
 		response.ExecutedAt = &executedAtStr
 	}
 
+	if ts.NextFundingTime != nil {
+		nextFundingStr := ts.NextFundingTime.Format(time.RFC3339)
+		response.NextFundingTime = &nextFundingStr
+	}
+
 	return response
 }
 
 type GenerateSignalRequest struct {
 	Symbol     string   `json:"symbol" binding:"required"`
-	Model      string   `json:"model"`
-	Timeframes []string `json:"timeframes"`
+	Model      string   `json:"model"`                                         // a name from the model catalog, defaults to the catalog's default
+	Timeframes []string `json:"timeframes"`                                    // native intervals or derived series such as "3h", "4h@15m", "ha:1h", "renko:1h"
+	Venue      string   `json:"venue" binding:"omitempty,oneof=binance bybit"` // where the signal will be executed, defaults to binance
+
+	// Agents to run, e.g. ["trend", "reversal", "derivatives"]. Defaults to the symbol's
+	// configured agents, or trend, reversal and volume.
+	Agents []string `json:"agents,omitempty"`
+	// Prompt agents defined for this request only; they always run
+	CustomAgents []CustomAgent `json:"customAgents,omitempty" binding:"omitempty,dive"`
+
+	// Indicators shown to each agent, keyed by agent name. Agents left out use their
+	// defaults; an empty list disables indicators for that agent.
+	Indicators map[string][]indicators.Spec `json:"indicators,omitempty"`
+
+	// How the agents' signals are combined: "llm" (meta-agent), "deterministic" (consensus
+	// rules) or "llm_veto" (meta-agent, vetoed when the rules disagree). Defaults to META_MODE, then llm.
+	MetaMode string `json:"metaMode,omitempty" binding:"omitempty,oneof=llm deterministic llm_veto"`
+	// How the consensus rules pick prices: "highest_confidence", "weighted" or "conservative".
+	// Defaults to META_PRICE_POLICY, then highest_confidence.
+	PricePolicy string `json:"pricePolicy,omitempty" binding:"omitempty,oneof=highest_confidence weighted conservative"`
+}
+
+// CustomAgent is a user-defined agent whose instructions replace the specialist briefing
+type CustomAgent struct {
+	Name         string `json:"name" binding:"required"`
+	Instructions string `json:"instructions" binding:"required"`
 }
 
 type GenerateSignalResponse struct {
@@ -93,9 +215,11 @@ type ExecuteTradeRequest struct {
 }
 
 type ExecuteTradeResponse struct {
-	Success       bool   `json:"success"`
-	TransactionId string `json:"transactionId"`
-	Message       string `json:"message,omitempty"`
+	Success       bool    `json:"success"`
+	TransactionId string  `json:"transactionId"`
+	Message       string  `json:"message,omitempty"`
+	MarginMode    string  `json:"marginMode,omitempty"` // as the venue reports it for the symbol
+	Quantity      float64 `json:"quantity,omitempty"`   // filled size of the entry order
 }
 
 type ExecuteManualSignalRequest struct {
@@ -21,6 +21,7 @@ type Transaction struct {
 	PositionID   *primitive.ObjectID `json:"positionId,omitempty" bson:"positionId,omitempty"`
 	SignalID     *primitive.ObjectID `json:"signalId,omitempty" bson:"signalId,omitempty"`
 	IsTestnet    bool                `json:"isTestnet" bson:"isTestnet"`
+	Venue        string              `json:"venue,omitempty" bson:"venue,omitempty"`
 	OrderID      string              `json:"orderId,omitempty" bson:"orderId,omitempty"`
 	Description  string              `json:"description,omitempty" bson:"description,omitempty"`
 }
@@ -34,6 +35,7 @@ type TransactionResponse struct {
 	Timestamp string  `json:"timestamp"`
 	Status    string  `json:"status"`
 	PnL       float64 `json:"pnl,omitempty"`
+	Venue     string  `json:"venue"`
 }
 
 func (t *Transaction) ToResponse() TransactionResponse {
@@ -46,6 +48,7 @@ func (t *Transaction) ToResponse() TransactionResponse {
 		Timestamp: t.CreatedAt.Format(time.RFC3339),
 		Status:    t.Status,
 		PnL:       t.PnL,
+		Venue:     t.Venue,
 	}
 }
 
@@ -59,6 +62,7 @@ type CreateTransactionRequest struct {
 	PositionID  string  `json:"positionId,omitempty"`
 	SignalID    string  `json:"signalId,omitempty"`
 	IsTestnet   bool    `json:"isTestnet"`
+	Venue       string  `json:"venue,omitempty"`
 	OrderID     string  `json:"orderId,omitempty"`
 	Description string  `json:"description,omitempty"`
 }
\ No newline at end of file
@@ -1,7 +1,10 @@
 package routes
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"saturday-autotrade/indicators"
 	"saturday-autotrade/models"
 	"saturday-autotrade/services"
 	"strconv"
@@ -25,30 +28,21 @@ func SetupTradingRoutes(router *gin.Engine) {
 			return
 		}
 
-		if req.Model == "" {
-			req.Model = "gpt-3.5-turbo"
-		} else {
-			validModels := map[string]bool{
-				"gpt-3.5-turbo": true,
-				"gpt-4":         true,
-				"gpt-4-turbo":   true,
-				"gpt-4o":        true,
-				"gpt-4o-mini":   true,
-				"gpt-4.1":       true}
-			if !validModels[req.Model] {
-				req.Model = "gpt-3.5-turbo"
-			}
+		// Default to 1h if not provided
+		if len(req.Timeframes) == 0 {
+			req.Timeframes = []string{"1h"}
 		}
 
-		// Default to 1h if not provided
-		selectedTimeframes := req.Timeframes
-		if len(selectedTimeframes) == 0 {
-			selectedTimeframes = []string{"1h"}
+		for _, tf := range req.Timeframes {
+			if _, err := services.ParseTimeframe(tf); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 		}
 
-		signal, err := tradingService.GenerateTradingSignalFromAI(req.Symbol, req.Model, selectedTimeframes)
+		signal, err := tradingService.GenerateTradingSignalFromAI(&req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -83,7 +77,7 @@ func SetupTradingRoutes(router *gin.Engine) {
 		// Execute the trade
 		result, err := tradingService.ExecuteTrade(signal, req.IsTestnet)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -121,7 +115,7 @@ func SetupTradingRoutes(router *gin.Engine) {
 		// Execute the manual signal
 		result, err := tradingService.ExecuteManualSignal(req.SignalJson, req.IsTestnet)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -172,6 +166,34 @@ func SetupTradingRoutes(router *gin.Engine) {
 		c.JSON(http.StatusOK, gin.H{"signal": signal.ToResponse()})
 	})
 
+	// Recorded LLM exchanges (full prompts and responses) of a signal
+	api.GET("/signals/:id/llm-exchanges", func(c *gin.Context) {
+		exchanges, err := tradingService.GetLLMExchanges(c.Param("id"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"exchanges": exchanges})
+	})
+
+	// Re-run a recorded signal from its stored market data and LLM answers
+	api.POST("/signals/:id/replay", func(c *gin.Context) {
+		replay, err := tradingService.ReplaySignal(c.Param("id"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"signal":        replay.Signal.ToResponse(),
+			"promptChanges": replay.PromptChanges,
+		}
+		if replay.Original != nil {
+			response["original"] = replay.Original.ToResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
 	// Get Binance price endpoint
 	api.GET("/binance-price/:symbol", func(c *gin.Context) {
 		defer func() {
@@ -184,7 +206,7 @@ func SetupTradingRoutes(router *gin.Engine) {
 
 		price, err := tradingService.GetBinancePrice(symbol)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -234,7 +256,7 @@ func SetupTradingRoutes(router *gin.Engine) {
 		// Create the position
 		position, err := tradingService.CreatePosition(&req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
@@ -272,6 +294,23 @@ func SetupTradingRoutes(router *gin.Engine) {
 		c.JSON(http.StatusOK, response)
 	})
 
+	// Preview liquidation price and margin for a proposed position
+	api.POST("/positions/margin-preview", func(c *gin.Context) {
+		var req models.MarginPreviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		estimate, err := tradingService.PreviewMargin(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"margin": estimate})
+	})
+
 	// Close trading position
 	api.POST("/positions/:id/close", func(c *gin.Context) {
 		defer func() {
@@ -379,26 +418,12 @@ func SetupTradingRoutes(router *gin.Engine) {
 
 		symbol := c.Param("symbol")
 
-		// Validate symbol format - should be uppercase and likely end with USDT
-		if len(symbol) < 3 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid symbol format"})
+		standardSymbol, err := services.SharedSymbolRegistry(false).Normalize(symbol)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
-		// Convert symbol to standard format (e.g., BTC -> BTCUSDT)
-		standardSymbol := symbol
-		if symbol == "BTC" {
-			standardSymbol = "BTCUSDT"
-		} else if symbol == "ETH" {
-			standardSymbol = "ETHUSDT"
-		} else if symbol == "XRP" {
-			standardSymbol = "XRPUSDT"
-		} else if symbol == "DOGE" {
-			standardSymbol = "DOGEUSDT"
-		} else if !strings.HasSuffix(strings.ToUpper(symbol), "USDT") {
-			standardSymbol = strings.ToUpper(symbol) + "USDT"
-		}
-
 		binanceService := services.NewBinanceService()
 		priceData, err := binanceService.GetPrice(standardSymbol)
 		if err != nil {
@@ -420,20 +445,218 @@ func SetupTradingRoutes(router *gin.Engine) {
 
 	// Get real USDT balance endpoint
 	api.GET("/balance", func(c *gin.Context) {
-		futuresService := services.NewBinanceFuturesService(false) // false = mainnet
-		accountInfo, err := futuresService.GetAccountInfo()
+		exchange, err := services.NewExchange(c.Query("venue"), false) // false = mainnet
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		balance, err := exchange.GetBalance("USDT")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		usdtBalance := 0.0
-		for _, asset := range accountInfo.Assets {
-			if asset.Asset == "USDT" {
-				usdtBalance, _ = strconv.ParseFloat(asset.WalletBalance, 64)
-				break
+		c.JSON(http.StatusOK, gin.H{"usdtBalance": balance.WalletBalance, "venue": exchange.Name()})
+	})
+
+	// Scan the symbol universe for signal candidates
+	api.POST("/scan", func(c *gin.Context) {
+		var criteria services.ScanCriteria
+		if err := c.ShouldBindJSON(&criteria); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		candidates, err := tradingService.ScanCandidates(criteria)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+	})
+
+	// Get stored candles by time range
+	api.GET("/candles", func(c *gin.Context) {
+		symbol := c.Query("symbol")
+		interval := c.DefaultQuery("interval", "1h")
+		if symbol == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
+			return
+		}
+
+		end := time.Now()
+		if endStr := c.Query("end"); endStr != "" {
+			endMs, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end time"})
+				return
+			}
+			end = time.UnixMilli(endMs)
+		}
+		start := end.Add(-24 * time.Hour)
+		if startStr := c.Query("start"); startStr != "" {
+			startMs, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time"})
+				return
 			}
+			start = time.UnixMilli(startMs)
+		}
+
+		candles, err := tradingService.GetStoredCandles(symbol, interval, start, end)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"usdtBalance": usdtBalance})
+		if candles == nil {
+			candles = []services.Kline{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"candles": candles})
+	})
+
+	// Get swing points, support/resistance zones and pivots per timeframe
+	api.GET("/levels/:symbol", func(c *gin.Context) {
+		symbol, err := services.SharedSymbolRegistry(false).Normalize(c.Param("symbol"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		timeframes := strings.Split(c.DefaultQuery("timeframes", "1h"), ",")
+		levels, err := tradingService.GetLevels(symbol, timeframes)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "levels": levels})
+	})
+
+	// Get the volume profile of the latest candles for the chart
+	api.GET("/volume-profile/:symbol", func(c *gin.Context) {
+		symbol, err := services.SharedSymbolRegistry(false).Normalize(c.Param("symbol"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		lookback, err := strconv.Atoi(c.DefaultQuery("lookback", "100"))
+		if err != nil || lookback < 10 || lookback > 1500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lookback must be between 10 and 1500"})
+			return
+		}
+		bins, err := strconv.Atoi(c.DefaultQuery("bins", strconv.Itoa(indicators.DefaultProfileBins)))
+		if err != nil || bins < 5 || bins > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bins must be between 5 and 200"})
+			return
+		}
+
+		timeframe := c.DefaultQuery("timeframe", "1h")
+		profile, err := tradingService.GetVolumeProfile(symbol, timeframe, lookback, bins)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "timeframe": timeframe, "profile": profile})
+	})
+
+	// Backfill candle history into the store
+	api.POST("/candles/backfill", func(c *gin.Context) {
+		var req models.BackfillCandlesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := tradingService.BackfillCandles(&req)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error(), "stored": result.Stored})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	})
+
+	// Get cached trading rules for a symbol
+	api.GET("/exchange-info/:symbol", func(c *gin.Context) {
+		isTestnet := c.Query("testnet") == "true"
+
+		symbol, err := services.SharedSymbolRegistry(isTestnet).Normalize(c.Param("symbol"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		info, err := services.SharedExchangeInfo(isTestnet).GetSymbol(symbol)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"symbol": info})
+	})
+
+	// Force a refresh of the exchange info cache
+	api.POST("/exchange-info/refresh", func(c *gin.Context) {
+		isTestnet := c.Query("testnet") == "true"
+
+		exchangeInfo := services.SharedExchangeInfo(isTestnet)
+		if err := exchangeInfo.Refresh(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":   true,
+			"fetchedAt": exchangeInfo.FetchedAt().UTC().Format(time.RFC3339),
+		})
+	})
+
+	// List the agents a signal can use
+	api.GET("/agents", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"agents": services.SharedAgentRegistry().Names()})
+	})
+
+	// LLM spend by UTC day, model and symbol over the last days (1-365, default 30)
+	api.GET("/llm-spend", func(c *gin.Context) {
+		days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+		if err != nil || days < 1 || days > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+
+		spend, err := tradingService.GetLLMSpend(days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		today := time.Now().UTC().Format("2006-01-02")
+		total, todayTotal := 0.0, 0.0
+		for _, row := range spend {
+			total += row.CostUSD
+			if row.Day == today {
+				todayTotal += row.CostUSD
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"spend":          spend,
+			"totalCostUsd":   total,
+			"todayCostUsd":   todayTotal,
+			"dailyBudgetUsd": services.DailyLLMBudget(),
+		})
+	})
+
+	// Models the signal endpoints accept, from the configured catalog
+	api.GET("/models", func(c *gin.Context) {
+		models, defaultModel, err := tradingService.GetModels()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"models": models, "default": defaultModel})
 	})
 
 	// Chart data prompt endpoint
@@ -448,9 +671,28 @@ func SetupTradingRoutes(router *gin.Engine) {
 		}
 		prompt, err := tradingService.BuildChartDataPrompt(req.Symbol, req.Timeframes)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"prompt": prompt})
 	})
 }
+
+// errorStatus maps invalid or delisted symbols, unsupported timeframes, invalid
+// candle ranges, invalid agent selections, unknown or unavailable models, invalid
+// signal IDs and signals that failed validation to 400, signals without a
+// recording to 404, an exhausted daily LLM budget to 429 and anything else to 500
+func errorStatus(err error) int {
+	if errors.Is(err, services.ErrNotRecorded) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrBudgetExceeded) {
+		return http.StatusTooManyRequests
+	}
+	if services.IsSymbolError(err) || errors.Is(err, services.ErrUnsupportedTimeframe) || errors.Is(err, services.ErrInvalidCandleRange) ||
+		errors.Is(err, services.ErrInvalidAgentConfig) || errors.Is(err, services.ErrUnknownModel) || errors.Is(err, services.ErrModelUnavailable) ||
+		errors.Is(err, services.ErrInvalidSignalID) || errors.Is(err, services.ErrSignalNotExecutable) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"saturday-autotrade/indicators"
+	"saturday-autotrade/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidAgentConfig is wrapped by errors about the agents or indicators a request selects
+var ErrInvalidAgentConfig = errors.New("invalid agent configuration")
+
+// defaultAgents run when neither the request nor the symbol's configuration choose
+var defaultAgents = []string{AgentTrend, AgentReversal, AgentVolume}
+
+var agentNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+const (
+	// maxAgentsPerRun caps the agents of one signal run, each of which is an LLM call
+	maxAgentsPerRun = 8
+	// maxCustomAgentInstructions caps the length of a custom agent's instructions
+	maxCustomAgentInstructions = 4000
+)
+
+// AgentInput is the market context shared by every agent of a signal run
+type AgentInput struct {
+	Symbol       string
+	CurrentPrice float64
+	Candles      map[string][]Kline // latest agentPromptCandles per timeframe
+	Levels       map[string]*indicators.Levels
+	Events       []models.DetectedEvent
+	Profiles     map[string]*indicators.VolumeProfile
+	OrderBook    *OrderBookFeatures
+	Derivatives  *DerivativesContext
+
+	// Readings are the indicators selected for the agent the input is given to
+	Readings map[string][]indicators.Reading
+}
+
+// Agent is a specialist whose JSON signal is handed to the meta-agent
+type Agent interface {
+	Name() string
+	BuildPrompt(in *AgentInput) string
+}
+
+// AgentOutput is the raw JSON answer of one agent, labeled for the meta-agent
+type AgentOutput struct {
+	Name string
+	JSON string
+}
+
+// AgentRegistry holds the agents a signal run can choose from
+type AgentRegistry struct {
+	mu     sync.RWMutex
+	agents map[string]Agent
+}
+
+var (
+	sharedAgentRegistryOnce sync.Once
+	sharedAgentRegistry     *AgentRegistry
+)
+
+// SharedAgentRegistry returns the process-wide registry with the built-in agents
+func SharedAgentRegistry() *AgentRegistry {
+	sharedAgentRegistryOnce.Do(func() {
+		sharedAgentRegistry = NewAgentRegistry()
+		for _, agent := range []Agent{trendAgent{}, reversalAgent{}, volumeAgent{}, derivativesAgent{}, structureAgent{}} {
+			if err := sharedAgentRegistry.Register(agent); err != nil {
+				panic(err)
+			}
+		}
+	})
+	return sharedAgentRegistry
+}
+
+func NewAgentRegistry() *AgentRegistry {
+	return &AgentRegistry{agents: make(map[string]Agent)}
+}
+
+// Register adds an agent; names are unique
+func (r *AgentRegistry) Register(agent Agent) error {
+	name := agent.Name()
+	if !agentNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: agent name %q must be 2-32 lowercase letters, digits, - or _", ErrInvalidAgentConfig, name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.agents[name]; ok {
+		return fmt.Errorf("%w: agent %s is already registered", ErrInvalidAgentConfig, name)
+	}
+	r.agents[name] = agent
+	return nil
+}
+
+// Unregister removes an agent
+func (r *AgentRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.agents, name)
+}
+
+// Get returns a registered agent
+func (r *AgentRegistry) Get(name string) (Agent, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	agent, ok := r.agents[name]
+	return agent, ok
+}
+
+// Names returns the registered agent names, sorted
+func (r *AgentRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.agents))
+	for name := range r.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve picks the agents of a signal run. The request's list wins, then the
+// symbol's SIGNAL_AGENTS_<SYMBOL> setting, then SIGNAL_AGENTS, then the built-in
+// trend/reversal/volume trio. Custom prompt agents from the request are usable by
+// name and run even when not listed.
+func (r *AgentRegistry) Resolve(symbol string, names []string, custom []models.CustomAgent) ([]Agent, error) {
+	customAgents := make(map[string]Agent, len(custom))
+	for _, c := range custom {
+		if !agentNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("%w: agent name %q must be 2-32 lowercase letters, digits, - or _", ErrInvalidAgentConfig, c.Name)
+		}
+		if _, ok := r.Get(c.Name); ok || c.Name == AgentMeta {
+			return nil, fmt.Errorf("%w: custom agent %s clashes with a registered agent", ErrInvalidAgentConfig, c.Name)
+		}
+		if _, ok := customAgents[c.Name]; ok {
+			return nil, fmt.Errorf("%w: custom agent %s is defined twice", ErrInvalidAgentConfig, c.Name)
+		}
+		if len(c.Instructions) > maxCustomAgentInstructions {
+			return nil, fmt.Errorf("%w: instructions of custom agent %s exceed %d characters", ErrInvalidAgentConfig, c.Name, maxCustomAgentInstructions)
+		}
+		customAgents[c.Name] = NewPromptAgent(c.Name, c.Instructions)
+	}
+
+	if len(names) == 0 {
+		names = configuredAgents(symbol)
+	} else {
+		names = append([]string(nil), names...)
+	}
+	for _, c := range custom {
+		if !containsString(names, c.Name) {
+			names = append(names, c.Name)
+		}
+	}
+
+	agents := make([]Agent, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if agent, ok := customAgents[name]; ok {
+			agents = append(agents, agent)
+		} else if agent, ok := r.Get(name); ok {
+			agents = append(agents, agent)
+		} else {
+			return nil, fmt.Errorf("%w: unknown agent %s, available: %s", ErrInvalidAgentConfig, name, strings.Join(r.Names(), ", "))
+		}
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("%w: at least one agent is required", ErrInvalidAgentConfig)
+	}
+	if len(agents) > maxAgentsPerRun {
+		return nil, fmt.Errorf("%w: %d agents selected, at most %d can run at once", ErrInvalidAgentConfig, len(agents), maxAgentsPerRun)
+	}
+	return agents, nil
+}
+
+// configuredAgents reads the agent list for a symbol from the environment
+func configuredAgents(symbol string) []string {
+	value := os.Getenv("SIGNAL_AGENTS_" + strings.ToUpper(symbol))
+	if value == "" {
+		value = os.Getenv("SIGNAL_AGENTS")
+	}
+	if value == "" {
+		return append([]string(nil), defaultAgents...)
+	}
+
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+	"saturday-autotrade/models"
+	"sort"
+	"strings"
+)
+
+// Types of models.DetectedEvent
+const (
+	EventPattern    = "pattern"
+	EventDivergence = "divergence"
+)
+
+const (
+	divergenceSwingStrength = 2
+	divergenceMaxSpan       = 30 // candles between the two swings compared
+)
+
+// detectReversalEvents runs the candlestick pattern and RSI/MACD divergence detectors
+// on every timeframe. Candles must already carry RSI and MACD. Only events completed
+// within the candles shown to the agents are kept, so each has a candle number.
+func detectReversalEvents(candles map[string][]Kline) []models.DetectedEvent {
+	var events []models.DetectedEvent
+	for tf, klines := range candles {
+		bars := klinesToBars(klines)
+		total := len(bars)
+
+		for _, p := range indicators.Patterns(bars) {
+			n := promptCandleNumber(p.Index, total)
+			if n == 0 {
+				continue
+			}
+			events = append(events, models.DetectedEvent{
+				Timeframe: tf,
+				Type:      EventPattern,
+				Name:      p.Name,
+				Bias:      p.Bias,
+				Candle:    n,
+				OpenTime:  p.OpenTime,
+			})
+		}
+
+		rsi := make([]float64, total)
+		macd := make([]float64, total)
+		for i, k := range klines {
+			rsi[i], macd[i] = k.RSI, k.MACD
+		}
+		divergences := indicators.Divergences(bars, rsi, "RSI", divergenceSwingStrength, divergenceMaxSpan)
+		divergences = append(divergences, indicators.Divergences(bars, macd, "MACD", divergenceSwingStrength, divergenceMaxSpan)...)
+		for _, d := range divergences {
+			n := promptCandleNumber(d.ToIndex, total)
+			if n == 0 {
+				continue
+			}
+			events = append(events, models.DetectedEvent{
+				Timeframe: tf,
+				Type:      EventDivergence,
+				Name:      fmt.Sprintf("%s %s divergence", d.Kind, d.Oscillator),
+				Bias:      d.Bias,
+				Candle:    n,
+				OpenTime:  d.ToTime,
+				Detail: fmt.Sprintf("from %s: price %s -> %s, %s %s -> %s", candleLabel(d.FromIndex, total),
+					formatIndicatorValue(d.PriceFrom), formatIndicatorValue(d.PriceTo),
+					d.Oscillator, formatIndicatorValue(d.OscFrom), formatIndicatorValue(d.OscTo)),
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timeframe != events[j].Timeframe {
+			return events[i].Timeframe < events[j].Timeframe
+		}
+		return events[i].Candle < events[j].Candle
+	})
+	return events
+}
+
+// formatReversalEventSection lists detected patterns and divergences per timeframe
+func formatReversalEventSection(events []models.DetectedEvent, timeframes []string) string {
+	if len(timeframes) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), timeframes...)
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	b.WriteString("Detected patterns and divergences (deterministic detectors; swings need 2 candles on their right, so the last 2 candles cannot complete a divergence):\n")
+	for _, tf := range sorted {
+		fmt.Fprintf(&b, "%s:\n", tf)
+		found := false
+		for _, e := range events {
+			if e.Timeframe != tf {
+				continue
+			}
+			found = true
+			fmt.Fprintf(&b, "- Candle %d: %s %s", e.Candle, e.Bias, e.Name)
+			if e.Detail != "" {
+				fmt.Fprintf(&b, " (%s)", e.Detail)
+			}
+			b.WriteString("\n")
+		}
+		if !found {
+			b.WriteString("- none detected\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
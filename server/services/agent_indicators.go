@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"saturday-autotrade/indicators"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Built-in agent names, also used as keys when selecting indicators per agent
+const (
+	AgentTrend       = "trend"
+	AgentReversal    = "reversal"
+	AgentVolume      = "volume"
+	AgentDerivatives = "derivatives"
+	AgentStructure   = "structure"
+)
+
+// defaultAgentIndicators is what each agent sees when the request does not choose;
+// agents not listed get no indicators by default. Every built-in agent gets ATR so
+// volatility-based stops never fall back to the model computing it.
+var defaultAgentIndicators = map[string][]indicators.Spec{
+	AgentTrend:       {{Name: indicators.NameEMA}, {Name: indicators.NameADX}, {Name: indicators.NameIchimoku}, {Name: indicators.NameATR}},
+	AgentReversal:    {{Name: indicators.NameBollinger}, {Name: indicators.NameStochRSI}, {Name: indicators.NameKeltner}, {Name: indicators.NameATR}},
+	AgentVolume:      {{Name: indicators.NameVWAP}, {Name: indicators.NameATR}},
+	AgentDerivatives: {{Name: indicators.NameATR}},
+	AgentStructure:   {{Name: indicators.NameATR}},
+}
+
+// ValidateAgentIndicators checks an indicator selection keyed by agent name against
+// the agents of a signal run. Agent names are matched case-insensitively, like in
+// the agent list; the selection is returned keyed by the lowercase names.
+func ValidateAgentIndicators(selection map[string][]indicators.Spec, agents []Agent) (map[string][]indicators.Spec, error) {
+	if selection == nil {
+		return nil, nil
+	}
+	normalized := make(map[string][]indicators.Spec, len(selection))
+	for key, specs := range selection {
+		name := strings.ToLower(strings.TrimSpace(key))
+		if _, ok := normalized[name]; ok {
+			return nil, fmt.Errorf("%w: indicators given twice for agent %q", ErrInvalidAgentConfig, name)
+		}
+		found := false
+		for _, agent := range agents {
+			found = found || agent.Name() == name
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: indicators given for agent %q, which is not part of this run", ErrInvalidAgentConfig, key)
+		}
+		if err := indicators.Validate(specs); err != nil {
+			return nil, fmt.Errorf("%w: %s agent: %v", ErrInvalidAgentConfig, name, err)
+		}
+		normalized[name] = specs
+	}
+	return normalized, nil
+}
+
+// agentIndicatorSpecs returns the indicators chosen for an agent. An agent listed
+// with an empty array gets no indicators; an agent left out gets its defaults.
+func agentIndicatorSpecs(agent string, selection map[string][]indicators.Spec) []indicators.Spec {
+	if specs, ok := selection[agent]; ok {
+		return specs
+	}
+	return defaultAgentIndicators[agent]
+}
+
+// computeAgentIndicators evaluates an agent's indicators on every timeframe
+func computeAgentIndicators(agent string, selection map[string][]indicators.Spec, candles map[string][]Kline) map[string][]indicators.Reading {
+	specs := agentIndicatorSpecs(agent, selection)
+	if len(specs) == 0 {
+		return nil
+	}
+	readings := make(map[string][]indicators.Reading, len(candles))
+	for tf, klines := range candles {
+		readings[tf] = indicators.Compute(klinesToBars(klines), specs)
+	}
+	return readings
+}
+
+func klinesToBars(klines []Kline) []indicators.Bar {
+	bars := make([]indicators.Bar, len(klines))
+	for i, k := range klines {
+		bars[i] = indicators.Bar{OpenTime: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume}
+	}
+	return bars
+}
+
+// formatIndicatorSection renders the latest indicator values per timeframe for a prompt
+func formatIndicatorSection(readings map[string][]indicators.Reading) string {
+	timeframes := make([]string, 0, len(readings))
+	for tf, r := range readings {
+		if len(r) > 0 {
+			timeframes = append(timeframes, tf)
+		}
+	}
+	if len(timeframes) == 0 {
+		return ""
+	}
+	sort.Strings(timeframes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Indicators (value at the latest candle, computed from up to %d candles):\n", signalCandleHistory)
+	for _, tf := range timeframes {
+		fmt.Fprintf(&b, "%s:\n", tf)
+		for _, r := range readings[tf] {
+			fields := make([]string, 0, len(r.Fields))
+			for _, f := range r.Fields {
+				if math.IsNaN(f.Value) || math.IsInf(f.Value, 0) {
+					continue
+				}
+				fields = append(fields, f.Name+": "+formatIndicatorValue(f.Value))
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", r.Label, strings.Join(fields, ", "))
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func formatIndicatorValue(v float64) string {
+	if math.Abs(v) >= 1000 {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
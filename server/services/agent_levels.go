@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+	"sort"
+	"strings"
+)
+
+// swingsInPrompt is how many of the latest swing highs and lows are listed per timeframe
+const swingsInPrompt = 4
+
+// computeLevels finds swing points, S/R zones and pivots on every timeframe
+func computeLevels(candles map[string][]Kline) map[string]*indicators.Levels {
+	levels := make(map[string]*indicators.Levels, len(candles))
+	for tf, klines := range candles {
+		levels[tf] = indicators.FindLevels(klinesToBars(klines), indicators.LevelOptions{})
+	}
+	return levels
+}
+
+// promptCandleNumber is the number the prompt gives a bar of the analysed history,
+// or 0 when the bar is older than the candles shown
+func promptCandleNumber(index, total int) int {
+	first := total - agentPromptCandles
+	if first < 0 {
+		first = 0
+	}
+	if index < first {
+		return 0
+	}
+	return index - first + 1
+}
+
+// candleLabel refers to a bar the way the prompt numbers candles, falling back to
+// its age when it is older than the candles shown
+func candleLabel(index, total int) string {
+	if n := promptCandleNumber(index, total); n > 0 {
+		return fmt.Sprintf("Candle %d", n)
+	}
+	return fmt.Sprintf("%d candles ago", total-1-index)
+}
+
+// formatLevelSection renders the detected levels per timeframe for a prompt
+func formatLevelSection(levels map[string]*indicators.Levels) string {
+	timeframes := make([]string, 0, len(levels))
+	for tf, l := range levels {
+		if l != nil && l.Bars >= 2 {
+			timeframes = append(timeframes, tf)
+		}
+	}
+	if len(timeframes) == 0 {
+		return ""
+	}
+	sort.Strings(timeframes)
+
+	var b strings.Builder
+	b.WriteString("Key levels (detected from fractal swings over the full history, pivots from the previous candle):\n")
+	for _, tf := range timeframes {
+		l := levels[tf]
+		fmt.Fprintf(&b, "%s:\n", tf)
+		for _, z := range l.Zones {
+			fmt.Fprintf(&b, "- %s zone %s-%s: %d touches, last at %s\n",
+				z.Kind, formatIndicatorValue(z.Low), formatIndicatorValue(z.High), z.Touches, candleLabel(z.LastIndex, l.Bars))
+		}
+
+		var highs, lows []string
+		for i := len(l.Swings) - 1; i >= 0; i-- {
+			s := l.Swings[i]
+			entry := fmt.Sprintf("%s (%s)", formatIndicatorValue(s.Price), candleLabel(s.Index, l.Bars))
+			if s.Kind == indicators.SwingHigh && len(highs) < swingsInPrompt {
+				highs = append(highs, entry)
+			} else if s.Kind == indicators.SwingLow && len(lows) < swingsInPrompt {
+				lows = append(lows, entry)
+			}
+		}
+		if len(highs) > 0 {
+			fmt.Fprintf(&b, "- latest swing highs: %s\n", strings.Join(highs, ", "))
+		}
+		if len(lows) > 0 {
+			fmt.Fprintf(&b, "- latest swing lows: %s\n", strings.Join(lows, ", "))
+		}
+
+		c, cam := l.Classic, l.Camarilla
+		fmt.Fprintf(&b, "- classic pivots: P %s, R1 %s, R2 %s, R3 %s, S1 %s, S2 %s, S3 %s\n",
+			formatIndicatorValue(c.Pivot), formatIndicatorValue(c.R1), formatIndicatorValue(c.R2), formatIndicatorValue(c.R3),
+			formatIndicatorValue(c.S1), formatIndicatorValue(c.S2), formatIndicatorValue(c.S3))
+		fmt.Fprintf(&b, "- camarilla: R3 %s, R4 %s, S3 %s, S4 %s\n",
+			formatIndicatorValue(cam.R3), formatIndicatorValue(cam.R4), formatIndicatorValue(cam.S3), formatIndicatorValue(cam.S4))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"saturday-autotrade/indicators"
+	"saturday-autotrade/models"
+	"strings"
+	"testing"
+)
+
+func agentNames(agents []Agent) string {
+	names := make([]string, len(agents))
+	for i, agent := range agents {
+		names[i] = agent.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func TestAgentRegistryResolve(t *testing.T) {
+	tooMany := make([]models.CustomAgent, maxAgentsPerRun)
+	for i := range tooMany {
+		tooMany[i] = models.CustomAgent{Name: "custom" + string(rune('a'+i)), Instructions: "look"}
+	}
+
+	tests := []struct {
+		name       string
+		symbolEnv  string
+		globalEnv  string
+		names      []string
+		custom     []models.CustomAgent
+		want       string
+		wantErrMsg string
+	}{
+		{name: "built-in defaults", want: "trend,reversal,volume"},
+		{name: "global setting", globalEnv: "structure, derivatives", want: "structure,derivatives"},
+		{name: "symbol setting beats the global one", symbolEnv: "volume", globalEnv: "structure", want: "volume"},
+		{name: "request beats the settings", symbolEnv: "volume", names: []string{"Trend", " trend "}, want: "trend"},
+		{
+			name:   "custom agents run even when not listed",
+			names:  []string{"trend"},
+			custom: []models.CustomAgent{{Name: "whales", Instructions: "watch large trades"}},
+			want:   "trend,whales",
+		},
+		{name: "unknown agent", names: []string{"astrology"}, wantErrMsg: "unknown agent"},
+		{
+			name:       "custom agent shadowing a built-in",
+			custom:     []models.CustomAgent{{Name: "trend", Instructions: "x"}},
+			wantErrMsg: "clashes",
+		},
+		{
+			name:       "custom agent named after the meta-agent",
+			custom:     []models.CustomAgent{{Name: AgentMeta, Instructions: "x"}},
+			wantErrMsg: "clashes",
+		},
+		{
+			name:       "custom agent defined twice",
+			custom:     []models.CustomAgent{{Name: "whales", Instructions: "x"}, {Name: "whales", Instructions: "y"}},
+			wantErrMsg: "defined twice",
+		},
+		{
+			name:       "invalid custom agent name",
+			custom:     []models.CustomAgent{{Name: "Whales!", Instructions: "x"}},
+			wantErrMsg: "agent name",
+		},
+		{
+			name:       "custom instructions too long",
+			custom:     []models.CustomAgent{{Name: "whales", Instructions: strings.Repeat("x", maxCustomAgentInstructions+1)}},
+			wantErrMsg: "exceed",
+		},
+		{name: "too many agents", names: []string{"trend"}, custom: tooMany, wantErrMsg: "at most"},
+	}
+
+	registry := SharedAgentRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SIGNAL_AGENTS_BTCUSDT", tt.symbolEnv)
+			t.Setenv("SIGNAL_AGENTS", tt.globalEnv)
+
+			agents, err := registry.Resolve("btcusdt", tt.names, tt.custom)
+			if tt.wantErrMsg != "" {
+				if !errors.Is(err, ErrInvalidAgentConfig) || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := agentNames(agents); got != tt.want {
+				t.Errorf("agents = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAgentIndicators(t *testing.T) {
+	agents := []Agent{trendAgent{}, volumeAgent{}}
+	atr := []indicators.Spec{{Name: indicators.NameATR}}
+
+	tests := []struct {
+		name       string
+		selection  map[string][]indicators.Spec
+		wantKeys   string
+		wantErrMsg string
+	}{
+		{name: "no selection"},
+		{name: "keys are normalized", selection: map[string][]indicators.Spec{" Trend ": atr, "volume": nil}, wantKeys: "trend,volume"},
+		{name: "the same agent twice", selection: map[string][]indicators.Spec{"trend": atr, "TREND": atr}, wantErrMsg: "twice"},
+		{name: "agent outside the run", selection: map[string][]indicators.Spec{"structure": atr}, wantErrMsg: "not part of this run"},
+		{name: "invalid indicator", selection: map[string][]indicators.Spec{"trend": {{Name: "macd"}}}, wantErrMsg: "unknown indicator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateAgentIndicators(tt.selection, agents)
+			if tt.wantErrMsg != "" {
+				if !errors.Is(err, ErrInvalidAgentConfig) || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var keys []string
+			for _, agent := range []string{AgentTrend, AgentVolume} {
+				if _, ok := got[agent]; ok {
+					keys = append(keys, agent)
+				}
+			}
+			if strings.Join(keys, ",") != tt.wantKeys || len(got) != len(keys) {
+				t.Errorf("selection = %v, want keys %s", got, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+	"sort"
+	"strings"
+)
+
+// computeVolumeProfiles builds a volume profile of every timeframe's full history
+func computeVolumeProfiles(candles map[string][]Kline) map[string]*indicators.VolumeProfile {
+	profiles := make(map[string]*indicators.VolumeProfile, len(candles))
+	for tf, klines := range candles {
+		if profile := indicators.ComputeVolumeProfile(klinesToBars(klines), indicators.DefaultProfileBins, indicators.DefaultValueArea); profile != nil {
+			profiles[tf] = profile
+		}
+	}
+	return profiles
+}
+
+// formatVolumeProfileSection renders the POC, value area and volume nodes per timeframe
+func formatVolumeProfileSection(profiles map[string]*indicators.VolumeProfile) string {
+	if len(profiles) == 0 {
+		return ""
+	}
+	timeframes := make([]string, 0, len(profiles))
+	for tf := range profiles {
+		timeframes = append(timeframes, tf)
+	}
+	sort.Strings(timeframes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Volume profile (each candle's volume spread over its range, %d price bins, value area = %.0f%% of volume):\n",
+		indicators.DefaultProfileBins, indicators.DefaultValueArea*100)
+	for _, tf := range timeframes {
+		p := profiles[tf]
+		fmt.Fprintf(&b, "%s (last %d candles, range %s-%s): POC %s, value area %s-%s",
+			tf, p.Bars, formatIndicatorValue(p.Low), formatIndicatorValue(p.High), formatIndicatorValue(p.POC),
+			formatIndicatorValue(p.ValueAreaLow), formatIndicatorValue(p.ValueAreaHigh))
+		if len(p.HighVolumeNodes) > 0 {
+			fmt.Fprintf(&b, ", HVN %s", formatVolumeNodes(p.HighVolumeNodes))
+		}
+		if len(p.LowVolumeNodes) > 0 {
+			fmt.Fprintf(&b, ", LVN %s", formatVolumeNodes(p.LowVolumeNodes))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func formatVolumeNodes(nodes []indicators.VolumeNode) string {
+	prices := make([]string, len(nodes))
+	for i, n := range nodes {
+		prices[i] = formatIndicatorValue(n.Price)
+	}
+	return strings.Join(prices, ", ")
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PremiumIndex is the /fapi/v1/premiumIndex response: mark/index price and funding
+type PremiumIndex struct {
+	Symbol          string  `json:"symbol"`
+	MarkPrice       float64 `json:"markPrice,string"`
+	IndexPrice      float64 `json:"indexPrice,string"`
+	LastFundingRate float64 `json:"lastFundingRate,string"`
+	InterestRate    float64 `json:"interestRate,string"`
+	NextFundingTime int64   `json:"nextFundingTime"`
+	Time            int64   `json:"time"`
+}
+
+// OpenInterestPoint is one entry of /futures/data/openInterestHist
+type OpenInterestPoint struct {
+	Symbol               string  `json:"symbol"`
+	SumOpenInterest      float64 `json:"sumOpenInterest,string"`
+	SumOpenInterestValue float64 `json:"sumOpenInterestValue,string"`
+	Timestamp            int64   `json:"timestamp"`
+}
+
+// LongShortRatioPoint is one entry of /futures/data/topLongShortPositionRatio
+type LongShortRatioPoint struct {
+	Symbol         string  `json:"symbol"`
+	LongShortRatio float64 `json:"longShortRatio,string"`
+	LongAccount    float64 `json:"longAccount,string"`
+	ShortAccount   float64 `json:"shortAccount,string"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+// TakerVolumePoint is one entry of /futures/data/takerlongshortRatio
+type TakerVolumePoint struct {
+	BuySellRatio float64 `json:"buySellRatio,string"`
+	BuyVol       float64 `json:"buyVol,string"`
+	SellVol      float64 `json:"sellVol,string"`
+	Timestamp    int64   `json:"timestamp"`
+}
+
+// DerivativesContext bundles the perpetual-specific data shown to the agents
+type DerivativesContext struct {
+	Symbol         string                `json:"symbol"`
+	Period         string                `json:"period"`
+	Premium        *PremiumIndex         `json:"premium,omitempty"`
+	OpenInterest   []OpenInterestPoint   `json:"openInterest"`
+	TopTraderRatio []LongShortRatioPoint `json:"topTraderRatio"`
+	TakerVolume    []TakerVolumePoint    `json:"takerVolume"`
+	Missing        []string              `json:"missing,omitempty"` // sections that failed to load
+}
+
+// derivativesPeriods are the periods accepted by the /futures/data endpoints
+var derivativesPeriods = map[string]bool{
+	"5m": true, "15m": true, "30m": true, "1h": true, "2h": true,
+	"4h": true, "6h": true, "12h": true, "1d": true,
+}
+
+// GetPremiumIndex returns mark price, index price and funding for a symbol,
+// served from the markPrice stream when warm
+func (s *BinanceService) GetPremiumIndex(symbol string) (*PremiumIndex, error) {
+	if mark, ok := s.GetMarkPrice(symbol); ok {
+		return &PremiumIndex{
+			Symbol:          mark.Symbol,
+			MarkPrice:       mark.MarkPrice,
+			IndexPrice:      mark.IndexPrice,
+			LastFundingRate: mark.FundingRate,
+			NextFundingTime: mark.NextFundingTime,
+			Time:            mark.UpdatedAt.UnixMilli(),
+		}, nil
+	}
+
+	var premium PremiumIndex
+	url := fmt.Sprintf("%s/premiumIndex?symbol=%s", s.baseURL, symbol)
+	if err := s.getJSON(url, &premium); err != nil {
+		return nil, fmt.Errorf("failed to fetch premium index: %w", err)
+	}
+
+	return &premium, nil
+}
+
+// GetAllPremiumIndexes returns mark price and funding for every futures symbol
+func (s *BinanceService) GetAllPremiumIndexes() ([]PremiumIndex, error) {
+	var premiums []PremiumIndex
+	if err := s.getJSON(s.baseURL+"/premiumIndex", &premiums); err != nil {
+		return nil, fmt.Errorf("failed to fetch premium indexes: %w", err)
+	}
+
+	return premiums, nil
+}
+
+// GetOpenInterestHistory returns open interest statistics for a period (5m..1d)
+func (s *BinanceService) GetOpenInterestHistory(symbol, period string, limit int) ([]OpenInterestPoint, error) {
+	var points []OpenInterestPoint
+	url := fmt.Sprintf("%s/openInterestHist?symbol=%s&period=%s&limit=%d", s.dataURL, symbol, period, limit)
+	if err := s.getJSON(url, &points); err != nil {
+		return nil, fmt.Errorf("failed to fetch open interest history: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetTopTraderLongShortRatio returns the top-trader long/short position ratio for a period
+func (s *BinanceService) GetTopTraderLongShortRatio(symbol, period string, limit int) ([]LongShortRatioPoint, error) {
+	var points []LongShortRatioPoint
+	url := fmt.Sprintf("%s/topLongShortPositionRatio?symbol=%s&period=%s&limit=%d", s.dataURL, symbol, period, limit)
+	if err := s.getJSON(url, &points); err != nil {
+		return nil, fmt.Errorf("failed to fetch top trader long/short ratio: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetTakerBuySellVolume returns the taker buy/sell volume ratio for a period
+func (s *BinanceService) GetTakerBuySellVolume(symbol, period string, limit int) ([]TakerVolumePoint, error) {
+	var points []TakerVolumePoint
+	url := fmt.Sprintf("%s/takerlongshortRatio?symbol=%s&period=%s&limit=%d", s.dataURL, symbol, period, limit)
+	if err := s.getJSON(url, &points); err != nil {
+		return nil, fmt.Errorf("failed to fetch taker buy/sell volume: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetDerivativesContext gathers funding, open interest, top-trader positioning and
+// taker flow for a symbol. Individual sections that fail are logged, left empty and
+// listed in Missing.
+func (s *BinanceService) GetDerivativesContext(symbol, period string, limit int) (*DerivativesContext, error) {
+	if !derivativesPeriods[period] {
+		return nil, fmt.Errorf("unsupported derivatives period: %s", period)
+	}
+
+	ctx := &DerivativesContext{
+		Symbol: symbol,
+		Period: period,
+	}
+
+	missing := func(section string, err error) {
+		log.Printf("BinanceService: %s of %s unavailable: %v", section, symbol, err)
+		ctx.Missing = append(ctx.Missing, section)
+	}
+
+	if premium, err := s.GetPremiumIndex(symbol); err == nil {
+		ctx.Premium = premium
+	} else {
+		missing("premium", err)
+	}
+	if points, err := s.GetOpenInterestHistory(symbol, period, limit); err == nil {
+		ctx.OpenInterest = points
+	} else {
+		missing("openInterest", err)
+	}
+	if points, err := s.GetTopTraderLongShortRatio(symbol, period, limit); err == nil {
+		ctx.TopTraderRatio = points
+	} else {
+		missing("topTraderRatio", err)
+	}
+	if points, err := s.GetTakerBuySellVolume(symbol, period, limit); err == nil {
+		ctx.TakerVolume = points
+	} else {
+		missing("takerVolume", err)
+	}
+
+	if ctx.Premium == nil && len(ctx.OpenInterest) == 0 && len(ctx.TopTraderRatio) == 0 && len(ctx.TakerVolume) == 0 {
+		return nil, fmt.Errorf("no derivatives data available for %s", symbol)
+	}
+
+	return ctx, nil
+}
+
+// DerivativesPeriodFor picks the derivatives statistics period closest to the
+// shortest analyzed timeframe, defaulting to 1h
+func DerivativesPeriodFor(timeframes []string) string {
+	best := ""
+	var bestDuration time.Duration
+	for _, tf := range timeframes {
+		// Derived series such as ha:4h follow the interval they are built at
+		if spec, err := ParseTimeframe(tf); err == nil {
+			tf = spec.Interval
+		}
+		if !derivativesPeriods[tf] {
+			continue
+		}
+		d, err := IntervalDuration(tf)
+		if err != nil {
+			continue
+		}
+		if best == "" || d < bestDuration {
+			best, bestDuration = tf, d
+		}
+	}
+	if best == "" {
+		return "1h"
+	}
+	return best
+}
+
+// getJSON performs a GET request and decodes the JSON body into v
+func (s *BinanceService) getJSON(url string, v interface{}) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("binance API error: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// formatDerivativesSection renders the derivatives context as a compact prompt block
+func formatDerivativesSection(ctx *DerivativesContext) string {
+	if ctx == nil {
+		return "Derivatives Context: not available\n\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Derivatives Context (%s, period %s, oldest to newest):\n", ctx.Symbol, ctx.Period))
+
+	if p := ctx.Premium; p != nil {
+		basis := 0.0
+		if p.IndexPrice > 0 {
+			basis = (p.MarkPrice - p.IndexPrice) / p.IndexPrice * 100
+		}
+		sb.WriteString(fmt.Sprintf("funding_rate: %.4f%% (next funding %s), mark: %.6f, index: %.6f, basis: %+.4f%%\n",
+			p.LastFundingRate*100, time.UnixMilli(p.NextFundingTime).UTC().Format(time.RFC3339), p.MarkPrice, p.IndexPrice, basis))
+	} else {
+		sb.WriteString("funding_rate: not available\n")
+	}
+
+	if n := len(ctx.OpenInterest); n > 0 {
+		values := make([]string, n)
+		for i, p := range ctx.OpenInterest {
+			values[i] = fmt.Sprintf("%.0f", p.SumOpenInterestValue)
+		}
+		change := 0.0
+		if first := ctx.OpenInterest[0].SumOpenInterestValue; first > 0 {
+			change = (ctx.OpenInterest[n-1].SumOpenInterestValue - first) / first * 100
+		}
+		sb.WriteString(fmt.Sprintf("open_interest_usd: %s (change %+.2f%%)\n", strings.Join(values, ", "), change))
+	} else {
+		sb.WriteString("open_interest_usd: not available\n")
+	}
+
+	if n := len(ctx.TopTraderRatio); n > 0 {
+		values := make([]string, n)
+		for i, p := range ctx.TopTraderRatio {
+			values[i] = fmt.Sprintf("%.2f", p.LongShortRatio)
+		}
+		latest := ctx.TopTraderRatio[n-1]
+		sb.WriteString(fmt.Sprintf("top_trader_long_short_ratio: %s (latest long %.1f%% / short %.1f%%)\n",
+			strings.Join(values, ", "), latest.LongAccount*100, latest.ShortAccount*100))
+	} else {
+		sb.WriteString("top_trader_long_short_ratio: not available\n")
+	}
+
+	if n := len(ctx.TakerVolume); n > 0 {
+		values := make([]string, n)
+		for i, p := range ctx.TakerVolume {
+			values[i] = fmt.Sprintf("%.2f", p.BuySellRatio)
+		}
+		sb.WriteString(fmt.Sprintf("taker_buy_sell_ratio: %s\n", strings.Join(values, ", ")))
+	} else {
+		sb.WriteString("taker_buy_sell_ratio: not available\n")
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// BinanceExchange adapts the Binance USDⓈ-M futures services to the Exchange interface
+type BinanceExchange struct {
+	futures   *BinanceFuturesService
+	market    *BinanceService
+	isTestnet bool
+}
+
+func NewBinanceExchange(isTestnet bool) *BinanceExchange {
+	return &BinanceExchange{
+		futures:   NewBinanceFuturesService(isTestnet),
+		market:    NewBinanceService(),
+		isTestnet: isTestnet,
+	}
+}
+
+func (e *BinanceExchange) Name() string {
+	return VenueBinance
+}
+
+func (e *BinanceExchange) IsTestnet() bool {
+	return e.isTestnet
+}
+
+func (e *BinanceExchange) IsConfigured() bool {
+	return e.futures.IsConfigured()
+}
+
+// GetPrice returns the last traded price (from mainnet market data)
+func (e *BinanceExchange) GetPrice(symbol string) (float64, error) {
+	price, err := e.market.GetPrice(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return price.Price, nil
+}
+
+func (e *BinanceExchange) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	return e.market.GetKlines(symbol, interval, limit)
+}
+
+func (e *BinanceExchange) GetSymbolInfo(symbol string) (*SymbolInfo, error) {
+	return e.futures.GetSymbolInfo(symbol)
+}
+
+func (e *BinanceExchange) GetBalance(asset string) (*ExchangeBalance, error) {
+	accountInfo, err := e.futures.GetAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range accountInfo.Assets {
+		if a.Asset == asset {
+			wallet, _ := strconv.ParseFloat(a.WalletBalance, 64)
+			available, _ := strconv.ParseFloat(a.AvailableBalance, 64)
+			unrealized, _ := strconv.ParseFloat(a.UnrealizedProfit, 64)
+			return &ExchangeBalance{
+				Asset:            asset,
+				WalletBalance:    wallet,
+				AvailableBalance: available,
+				UnrealizedProfit: unrealized,
+			}, nil
+		}
+	}
+
+	return &ExchangeBalance{Asset: asset}, nil
+}
+
+func (e *BinanceExchange) GetPositions() ([]ExchangePosition, error) {
+	accountInfo, err := e.futures.GetAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := []ExchangePosition{}
+	for _, p := range accountInfo.Positions {
+		amt, err := strconv.ParseFloat(p.PositionAmt, 64)
+		if err != nil || amt == 0 {
+			continue
+		}
+		direction := "LONG"
+		if amt < 0 {
+			direction = "SHORT"
+		}
+		entry, _ := strconv.ParseFloat(p.EntryPrice, 64)
+		unrealized, _ := strconv.ParseFloat(p.UnrealizedProfit, 64)
+		leverage, _ := strconv.Atoi(p.Leverage)
+		positions = append(positions, ExchangePosition{
+			Symbol:        p.Symbol,
+			Direction:     direction,
+			Size:          math.Abs(amt),
+			EntryPrice:    entry,
+			UnrealizedPnL: unrealized,
+			Leverage:      leverage,
+		})
+	}
+
+	return positions, nil
+}
+
+func (e *BinanceExchange) SetLeverage(symbol string, leverage int) error {
+	_, err := e.futures.SetLeverage(symbol, leverage)
+	return err
+}
+
+func (e *BinanceExchange) SetMarginMode(symbol, mode string) error {
+	return e.futures.SetMarginType(symbol, mode)
+}
+
+func (e *BinanceExchange) GetMarginMode(symbol string) (string, error) {
+	accountInfo, err := e.futures.GetAccountInfo()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range accountInfo.Positions {
+		if p.Symbol != symbol {
+			continue
+		}
+		if p.Isolated {
+			return MarginModeIsolated, nil
+		}
+		return MarginModeCross, nil
+	}
+	return "", fmt.Errorf("no margin settings reported for %s", symbol)
+}
+
+func (e *BinanceExchange) GetLeverageBrackets(symbol string) ([]LeverageBracket, error) {
+	return e.futures.GetLeverageBrackets(symbol)
+}
+
+func (e *BinanceExchange) PlaceOrder(req *OrderRequest) (*ExchangeOrder, error) {
+	if req.PositionSide == "" {
+		req.PositionSide = "BOTH"
+	}
+	order, err := e.futures.PlaceOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	return binanceOrderToExchange(order), nil
+}
+
+func (e *BinanceExchange) CancelOrder(symbol, orderID string) (*ExchangeOrder, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Binance order ID %q: %w", orderID, err)
+	}
+	order, err := e.futures.CancelOrder(symbol, id)
+	if err != nil {
+		return nil, err
+	}
+	return binanceOrderToExchange(order), nil
+}
+
+func (e *BinanceExchange) GetOrder(symbol, orderID string) (*ExchangeOrder, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Binance order ID %q: %w", orderID, err)
+	}
+	order, err := e.futures.GetOrder(symbol, id)
+	if err != nil {
+		return nil, err
+	}
+	return binanceOrderToExchange(order), nil
+}
+
+func binanceOrderToExchange(order *BinanceOrder) *ExchangeOrder {
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+	qty, _ := strconv.ParseFloat(order.OrigQty, 64)
+	executed, _ := strconv.ParseFloat(order.ExecutedQty, 64)
+
+	return &ExchangeOrder{
+		Venue:         VenueBinance,
+		Symbol:        order.Symbol,
+		OrderID:       strconv.FormatInt(order.OrderID, 10),
+		ClientOrderID: order.ClientOrderID,
+		Side:          order.Side,
+		Type:          order.Type,
+		Status:        order.Status,
+		Price:         price,
+		AvgPrice:      avgPrice,
+		StopPrice:     stopPrice,
+		Quantity:      qty,
+		ExecutedQty:   executed,
+		UpdateTime:    order.UpdateTime,
+	}
+}
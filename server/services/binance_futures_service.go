@@ -14,11 +14,33 @@ import (
 	"time"
 )
 
+// defaultRecvWindow is how long (ms) after its timestamp a signed request stays valid
+const defaultRecvWindow = 5000
+
+const (
+	// binanceTimestampErrorCode is returned when a request's timestamp is outside recvWindow
+	binanceTimestampErrorCode = -1021
+	// binanceMarginTypeUnchangedCode is returned when a symbol already has the requested margin type
+	binanceMarginTypeUnchangedCode = -4046
+)
+
+// binanceAPIError is a non-200 response of a signed request; Code is 0 when the body has none
+type binanceAPIError struct {
+	Code int
+	Body string
+}
+
+func (e *binanceAPIError) Error() string {
+	return fmt.Sprintf("binance API error: %s", e.Body)
+}
+
 type BinanceFuturesService struct {
-	apiKey    string
-	secretKey string
-	baseURL   string
-	client    *http.Client
+	apiKey       string
+	secretKey    string
+	baseURL      string
+	recvWindow   int
+	client       *BinanceHTTPClient
+	exchangeInfo *ExchangeInfoService
 }
 
 // Order represents a Binance futures order
@@ -29,6 +51,7 @@ type BinanceOrder struct {
 	Price         string `json:"price"`
 	OrigQty       string `json:"origQty"`
 	ExecutedQty   string `json:"executedQty"`
+	AvgPrice      string `json:"avgPrice"`
 	CumQuote      string `json:"cumQuote"`
 	Status        string `json:"status"`
 	TimeInForce   string `json:"timeInForce"`
@@ -113,13 +136,36 @@ func NewBinanceFuturesService(isTestnet bool) *BinanceFuturesService {
 	apiKey := os.Getenv("BINANCE_API_KEY")
 	secretKey := os.Getenv("BINANCE_SECRET_KEY")
 
-	baseURL := os.Getenv("BINANCE_MAINNET_URL")
 	if isTestnet {
-		baseURL = os.Getenv("BINANCE_TESTNET_URL")
 		apiKey = os.Getenv("BINANCE_TESTNET_API_KEY")
 		secretKey = os.Getenv("BINANCE_TESTNET_SECRET_KEY")
 	}
 
+	baseURL := futuresBaseURL(isTestnet)
+
+	// Binance accepts at most 60000ms
+	recvWindow := defaultRecvWindow
+	if v, err := strconv.Atoi(os.Getenv("BINANCE_RECV_WINDOW")); err == nil && v > 0 && v <= 60000 {
+		recvWindow = v
+	}
+
+	return &BinanceFuturesService{
+		apiKey:       apiKey,
+		secretKey:    secretKey,
+		baseURL:      baseURL,
+		recvWindow:   recvWindow,
+		client:       SharedBinanceHTTPClient(baseURL),
+		exchangeInfo: SharedExchangeInfo(isTestnet),
+	}
+}
+
+// futuresBaseURL returns the configured futures host for mainnet or testnet
+func futuresBaseURL(isTestnet bool) string {
+	baseURL := os.Getenv("BINANCE_MAINNET_URL")
+	if isTestnet {
+		baseURL = os.Getenv("BINANCE_TESTNET_URL")
+	}
+
 	if baseURL == "" {
 		if isTestnet {
 			baseURL = "https://testnet.binancefuture.com"
@@ -128,14 +174,7 @@ func NewBinanceFuturesService(isTestnet bool) *BinanceFuturesService {
 		}
 	}
 
-	return &BinanceFuturesService{
-		apiKey:    apiKey,
-		secretKey: secretKey,
-		baseURL:   baseURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}
+	return baseURL
 }
 
 // IsConfigured checks if Binance API credentials are properly configured
@@ -150,14 +189,42 @@ func (s *BinanceFuturesService) generateSignature(queryString string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// makeSignedRequest makes a signed request to Binance API
+// makeSignedRequest makes a signed request to Binance API. Requests are stamped
+// with the synced server time; a -1021 timestamp error triggers one resync and retry.
 func (s *BinanceFuturesService) makeSignedRequest(method, endpoint string, params map[string]string) ([]byte, error) {
 	if !s.IsConfigured() {
 		return nil, fmt.Errorf("binance API credentials not configured")
 	}
 
-	// Add timestamp
-	params["timestamp"] = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	for attempt := 0; ; attempt++ {
+		body, status, err := s.doSignedRequest(method, endpoint, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if status == http.StatusOK {
+			return body, nil
+		}
+
+		var apiErr struct {
+			Code int `json:"code"`
+		}
+		json.Unmarshal(body, &apiErr)
+		if attempt == 0 && apiErr.Code == binanceTimestampErrorCode {
+			if err := s.client.SyncTime(); err == nil {
+				continue
+			}
+		}
+
+		return nil, &binanceAPIError{Code: apiErr.Code, Body: string(body)}
+	}
+}
+
+// doSignedRequest signs params with a fresh timestamp and sends them once
+func (s *BinanceFuturesService) doSignedRequest(method, endpoint string, params map[string]string) ([]byte, int, error) {
+	// Add timestamp and recvWindow
+	params["timestamp"] = strconv.FormatInt(s.client.ServerTime().UnixMilli(), 10)
+	params["recvWindow"] = strconv.Itoa(s.recvWindow)
 
 	// Build query string
 	values := url.Values{}
@@ -176,7 +243,7 @@ func (s *BinanceFuturesService) makeSignedRequest(method, endpoint string, param
 	// Create request
 	req, err := http.NewRequest(method, requestURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add headers
@@ -186,20 +253,16 @@ func (s *BinanceFuturesService) makeSignedRequest(method, endpoint string, param
 	// Make request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("binance API error: %s", string(body))
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return body, nil
+	return body, resp.StatusCode, nil
 }
 
 // SetLeverage sets the leverage for a symbol
@@ -231,6 +294,29 @@ func (s *BinanceFuturesService) SetLeverage(symbol string, leverage int) (*Lever
 	return &response, nil
 }
 
+// SetMarginType switches a symbol to ISOLATED or CROSSED margin; a symbol that
+// already uses the requested type is left as is
+func (s *BinanceFuturesService) SetMarginType(symbol, marginType string) error {
+	if !s.IsConfigured() {
+		// Mock response for testing
+		return nil
+	}
+
+	params := map[string]string{
+		"symbol":     symbol,
+		"marginType": marginType,
+	}
+
+	_, err := s.makeSignedRequest("POST", "/fapi/v1/marginType", params)
+	if apiErr, ok := err.(*binanceAPIError); ok && apiErr.Code == binanceMarginTypeUnchangedCode {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set margin type: %w", err)
+	}
+	return nil
+}
+
 // PlaceOrder places a new order on Binance Futures
 func (s *BinanceFuturesService) PlaceOrder(orderReq *OrderRequest) (*BinanceOrder, error) {
 	if !s.IsConfigured() {
@@ -251,20 +337,26 @@ func (s *BinanceFuturesService) PlaceOrder(orderReq *OrderRequest) (*BinanceOrde
 		return mockOrder, nil
 	}
 
+	symbolInfo, err := s.exchangeInfo.GetSymbol(orderReq.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbol rules: %w", err)
+	}
+
+	isMarket := orderReq.Type == "MARKET" || orderReq.Type == "STOP_MARKET" || orderReq.Type == "TAKE_PROFIT_MARKET"
 	params := map[string]string{
 		"symbol":       orderReq.Symbol,
 		"side":         orderReq.Side,
 		"type":         orderReq.Type,
-		"quantity":     fmt.Sprintf("%.6f", orderReq.Quantity),
+		"quantity":     symbolInfo.FormatQuantity(orderReq.Quantity, isMarket),
 		"positionSide": orderReq.PositionSide,
 	}
 
 	if orderReq.Price > 0 {
-		params["price"] = fmt.Sprintf("%.6f", orderReq.Price)
+		params["price"] = symbolInfo.FormatPrice(orderReq.Price)
 	}
 
 	if orderReq.StopPrice > 0 {
-		params["stopPrice"] = fmt.Sprintf("%.6f", orderReq.StopPrice)
+		params["stopPrice"] = symbolInfo.FormatPrice(orderReq.StopPrice)
 	}
 
 	if orderReq.TimeInForce != "" {
@@ -389,71 +481,42 @@ func (s *BinanceFuturesService) GetOrder(symbol string, orderID int64) (*Binance
 	return &order, nil
 }
 
-// ValidateMarginAndBalance checks if user has sufficient margin for the trade
-func (s *BinanceFuturesService) ValidateMarginAndBalance(symbol string, quantity float64, price float64, leverage int) error {
-	accountInfo, err := s.GetAccountInfo()
+// GetLeverageBrackets retrieves the notional tiers and maintenance margin rates of a symbol
+func (s *BinanceFuturesService) GetLeverageBrackets(symbol string) ([]LeverageBracket, error) {
+	params := map[string]string{
+		"symbol": symbol,
+	}
+
+	body, err := s.makeSignedRequest("GET", "/fapi/v1/leverageBracket", params)
 	if err != nil {
-		return fmt.Errorf("failed to get account info for margin validation: %w", err)
+		return nil, fmt.Errorf("failed to get leverage brackets: %w", err)
 	}
 
-	// Find USDT balance
-	var usdtBalance float64
-	for _, asset := range accountInfo.Assets {
-		if asset.Asset == "USDT" {
-			balance, err := strconv.ParseFloat(asset.AvailableBalance, 64)
-			if err != nil {
-				continue
-			}
-			usdtBalance = balance
-			break
-		}
+	type symbolBrackets struct {
+		Symbol   string            `json:"symbol"`
+		Brackets []LeverageBracket `json:"brackets"`
 	}
 
-	// Calculate required margin
-	notionalValue := quantity * price
-	requiredMargin := notionalValue / float64(leverage)
+	// The endpoint returns an array, or a single object on some API versions
+	var list []symbolBrackets
+	if err := json.Unmarshal(body, &list); err != nil {
+		var single symbolBrackets
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil, fmt.Errorf("failed to parse leverage brackets: %w", err)
+		}
+		list = []symbolBrackets{single}
+	}
 
-	if usdtBalance < requiredMargin {
-		return fmt.Errorf("insufficient margin: required %.2f USDT, available %.2f USDT", requiredMargin, usdtBalance)
+	for _, entry := range list {
+		if entry.Symbol == symbol && len(entry.Brackets) > 0 {
+			return entry.Brackets, nil
+		}
 	}
 
-	return nil
+	return nil, fmt.Errorf("no leverage brackets for %s", symbol)
 }
 
-func (s *BinanceFuturesService) GetSymbolStepSizeAndMinQty(symbol string) (float64, float64, error) {
-	url := s.baseURL + "/fapi/v1/exchangeInfo"
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get exchange info: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to read exchange info: %w", err)
-	}
-	var info struct {
-		Symbols []struct {
-			Symbol  string `json:"symbol"`
-			Filters []struct {
-				FilterType string `json:"filterType"`
-				MinQty     string `json:"minQty,omitempty"`
-				StepSize   string `json:"stepSize,omitempty"`
-			} `json:"filters"`
-		} `json:"symbols"`
-	}
-	if err := json.Unmarshal(body, &info); err != nil {
-		return 0, 0, fmt.Errorf("failed to parse exchange info: %w", err)
-	}
-	for _, s := range info.Symbols {
-		if s.Symbol == symbol {
-			for _, f := range s.Filters {
-				if f.FilterType == "LOT_SIZE" {
-					stepSize, _ := strconv.ParseFloat(f.StepSize, 64)
-					minQty, _ := strconv.ParseFloat(f.MinQty, 64)
-					return stepSize, minQty, nil
-				}
-			}
-		}
-	}
-	return 0, 0, fmt.Errorf("symbol %s not found", symbol)
+// GetSymbolInfo returns the cached trading rules for a symbol
+func (s *BinanceFuturesService) GetSymbolInfo(symbol string) (*SymbolInfo, error) {
+	return s.exchangeInfo.GetSymbol(symbol)
 }
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Binance USDⓈ-M futures IP and account limits
+const (
+	binanceWeightLimit1m  = 2400
+	binanceOrderLimit10s  = 300
+	binanceOrderLimit1m   = 1200
+	binanceBudgetHeadroom = 0.9 // never plan to use more than 90% of a limit
+	binanceMaxQueueWait   = time.Minute
+	binanceTimeSyncEvery  = 10 * time.Minute
+)
+
+// RequestBudget is a snapshot of the request weight and order counters for one Binance host
+type RequestBudget struct {
+	Host            string     `json:"host"`
+	UsedWeight      int        `json:"usedWeight"`
+	WeightLimit     int        `json:"weightLimit"`
+	RemainingWeight int        `json:"remainingWeight"`
+	WindowResetsAt  time.Time  `json:"windowResetsAt"`
+	OrderCount10s   int        `json:"orderCount10s"`
+	OrderLimit10s   int        `json:"orderLimit10s"`
+	OrderCount1m    int        `json:"orderCount1m"`
+	OrderLimit1m    int        `json:"orderLimit1m"`
+	BackoffUntil    *time.Time `json:"backoffUntil,omitempty"`
+	Banned          bool       `json:"banned"`
+}
+
+// BinanceHTTPClient is the HTTP layer shared by every Binance call to one host.
+// It tracks request weight and order counts from the X-MBX-* headers, delays calls
+// that would exceed the budget and honors Retry-After on 429/418 responses.
+type BinanceHTTPClient struct {
+	host   string
+	client *http.Client
+
+	mu            sync.Mutex
+	weightWindow  time.Time
+	usedWeight    int
+	orderWindow   time.Time
+	orderCount10s int
+	orderCount1m  int
+	orderMinute   time.Time
+	backoffUntil  time.Time
+	banned        bool
+
+	// Server clock offset from /fapi/v1/time, used to stamp signed requests
+	clockMu     sync.Mutex
+	clockOffset time.Duration
+	clockSynced time.Time
+}
+
+var (
+	binanceHTTPClientsMu sync.Mutex
+	binanceHTTPClients   = map[string]*BinanceHTTPClient{}
+)
+
+// SharedBinanceHTTPClient returns the process-wide client for a Binance host,
+// so that weight used by market data, account and order calls is counted together
+func SharedBinanceHTTPClient(host string) *BinanceHTTPClient {
+	host = strings.TrimSuffix(host, "/")
+
+	binanceHTTPClientsMu.Lock()
+	defer binanceHTTPClientsMu.Unlock()
+
+	if c, ok := binanceHTTPClients[host]; ok {
+		return c
+	}
+
+	c := &BinanceHTTPClient{
+		host: host,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+	binanceHTTPClients[host] = c
+	return c
+}
+
+// BinanceRequestBudgets returns the budget of every Binance host used so far
+func BinanceRequestBudgets() []RequestBudget {
+	binanceHTTPClientsMu.Lock()
+	clients := make([]*BinanceHTTPClient, 0, len(binanceHTTPClients))
+	for _, c := range binanceHTTPClients {
+		clients = append(clients, c)
+	}
+	binanceHTTPClientsMu.Unlock()
+
+	budgets := make([]RequestBudget, 0, len(clients))
+	for _, c := range clients {
+		budgets = append(budgets, c.Budget())
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].Host < budgets[j].Host
+	})
+	return budgets
+}
+
+// Get issues a GET request through the budgeter
+func (c *BinanceHTTPClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.Do(req)
+}
+
+// Do sends a request once there is budget for it. A 429 is retried once after
+// Retry-After if the wait is short; a 418 (IP ban) is returned immediately.
+func (c *BinanceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	weight := requestWeight(req)
+	isOrder := isOrderRequest(req)
+
+	for attempt := 0; ; attempt++ {
+		if err := c.reserve(weight, isOrder); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		c.observe(resp)
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+			return resp, nil
+		}
+
+		wait := c.backoff(resp)
+		if resp.StatusCode == http.StatusTeapot || attempt > 0 || wait > binanceMaxQueueWait || req.Body != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		log.Printf("BinanceHTTP: Rate limited by %s, retrying in %s", c.host, wait)
+	}
+}
+
+// ServerTime returns the local clock corrected by the last measured offset to
+// Binance's clock, resyncing when the offset is older than binanceTimeSyncEvery.
+// If a sync fails the last known offset (initially zero) is kept.
+func (c *BinanceHTTPClient) ServerTime() time.Time {
+	c.clockMu.Lock()
+	stale := time.Since(c.clockSynced) > binanceTimeSyncEvery
+	c.clockMu.Unlock()
+
+	if stale {
+		if err := c.SyncTime(); err != nil {
+			log.Printf("BinanceHTTP: Time sync with %s failed: %v", c.host, err)
+		}
+	}
+
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	return time.Now().Add(c.clockOffset)
+}
+
+// SyncTime measures the offset to the server clock, assuming the server stamped
+// its time halfway through the round trip
+func (c *BinanceHTTPClient) SyncTime() error {
+	sent := time.Now()
+	resp, err := c.Get(c.host + "/fapi/v1/time")
+	if err != nil {
+		return fmt.Errorf("failed to get server time: %w", err)
+	}
+	defer resp.Body.Close()
+	received := time.Now()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("binance time API error: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to parse server time: %w", err)
+	}
+
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	offset := time.UnixMilli(body.ServerTime).Sub(midpoint)
+
+	c.clockMu.Lock()
+	c.clockOffset = offset
+	c.clockSynced = received
+	c.clockMu.Unlock()
+
+	return nil
+}
+
+// Budget reports the current weight and order usage
+func (c *BinanceHTTPClient) Budget() RequestBudget {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.rollWindows(now)
+
+	budget := RequestBudget{
+		Host:            c.host,
+		UsedWeight:      c.usedWeight,
+		WeightLimit:     binanceWeightLimit1m,
+		RemainingWeight: binanceWeightLimit1m - c.usedWeight,
+		WindowResetsAt:  c.weightWindow.Add(time.Minute),
+		OrderCount10s:   c.orderCount10s,
+		OrderLimit10s:   binanceOrderLimit10s,
+		OrderCount1m:    c.orderCount1m,
+		OrderLimit1m:    binanceOrderLimit1m,
+		Banned:          c.banned && now.Before(c.backoffUntil),
+	}
+	if now.Before(c.backoffUntil) {
+		until := c.backoffUntil
+		budget.BackoffUntil = &until
+	}
+
+	return budget
+}
+
+// reserve blocks until the request fits the budget and counts it, or fails if
+// the wait would be longer than binanceMaxQueueWait
+func (c *BinanceHTTPClient) reserve(weight int, isOrder bool) error {
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.rollWindows(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(c.backoffUntil):
+			wait = c.backoffUntil.Sub(now)
+		case float64(c.usedWeight+weight) > binanceWeightLimit1m*binanceBudgetHeadroom:
+			wait = c.weightWindow.Add(time.Minute).Sub(now)
+		case isOrder && float64(c.orderCount10s+1) > binanceOrderLimit10s*binanceBudgetHeadroom:
+			wait = c.orderWindow.Add(10 * time.Second).Sub(now)
+		case isOrder && float64(c.orderCount1m+1) > binanceOrderLimit1m*binanceBudgetHeadroom:
+			wait = c.orderMinute.Add(time.Minute).Sub(now)
+		}
+
+		if wait <= 0 {
+			c.usedWeight += weight
+			if isOrder {
+				c.orderCount10s++
+				c.orderCount1m++
+			}
+			c.mu.Unlock()
+			return nil
+		}
+
+		banned := c.banned && now.Before(c.backoffUntil)
+		c.mu.Unlock()
+
+		if wait > binanceMaxQueueWait {
+			if banned {
+				return fmt.Errorf("binance IP ban in effect for %s", wait.Round(time.Second))
+			}
+			return fmt.Errorf("binance request budget exhausted, retry in %s", wait.Round(time.Second))
+		}
+		time.Sleep(wait)
+	}
+}
+
+// rollWindows resets counters whose window has elapsed. Caller must hold mu.
+func (c *BinanceHTTPClient) rollWindows(now time.Time) {
+	if minute := now.Truncate(time.Minute); !minute.Equal(c.weightWindow) {
+		c.weightWindow = minute
+		c.usedWeight = 0
+	}
+	if window := now.Truncate(10 * time.Second); !window.Equal(c.orderWindow) {
+		c.orderWindow = window
+		c.orderCount10s = 0
+	}
+	if minute := now.Truncate(time.Minute); !minute.Equal(c.orderMinute) {
+		c.orderMinute = minute
+		c.orderCount1m = 0
+	}
+}
+
+// observe updates the counters from the authoritative X-MBX-* response headers
+func (c *BinanceHTTPClient) observe(resp *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		c.usedWeight = v
+	}
+	if v, err := strconv.Atoi(resp.Header.Get("X-MBX-ORDER-COUNT-10S")); err == nil {
+		c.orderCount10s = v
+	}
+	if v, err := strconv.Atoi(resp.Header.Get("X-MBX-ORDER-COUNT-1M")); err == nil {
+		c.orderCount1m = v
+	}
+}
+
+// backoff records the Retry-After of a 429/418 response and returns the wait
+func (c *BinanceHTTPClient) backoff(resp *http.Response) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	wait := c.weightWindow.Add(time.Minute).Sub(now)
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	if until := now.Add(wait); until.After(c.backoffUntil) {
+		c.backoffUntil = until
+	}
+	c.banned = resp.StatusCode == http.StatusTeapot
+	log.Printf("BinanceHTTP: %s returned %d, backing off until %s", c.host, resp.StatusCode, c.backoffUntil.Format(time.RFC3339))
+
+	return wait
+}
+
+// requestWeight returns the documented request weight of a futures endpoint
+func requestWeight(req *http.Request) int {
+	path := req.URL.Path
+	limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+
+	switch {
+	case strings.HasSuffix(path, "/klines"):
+		switch {
+		case limit >= 1000:
+			return 10
+		case limit >= 500:
+			return 5
+		case limit >= 100:
+			return 2
+		}
+		return 1
+	case strings.HasSuffix(path, "/depth"):
+		switch {
+		case limit >= 1000:
+			return 20
+		case limit >= 500:
+			return 10
+		case limit >= 100:
+			return 5
+		}
+		return 2
+	case strings.HasSuffix(path, "/ticker/24hr"):
+		if req.URL.Query().Get("symbol") == "" {
+			return 40
+		}
+		return 1
+	case strings.HasSuffix(path, "/premiumIndex"):
+		if req.URL.Query().Get("symbol") == "" {
+			return 10
+		}
+		return 1
+	case strings.HasSuffix(path, "/account"):
+		return 5
+	case strings.HasSuffix(path, "/order") && req.Method != "GET":
+		return 0
+	}
+	return 1
+}
+
+func isOrderRequest(req *http.Request) bool {
+	return req.Method == "POST" && (strings.HasSuffix(req.URL.Path, "/order") || strings.HasSuffix(req.URL.Path, "/batchOrders"))
+}
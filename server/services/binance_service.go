@@ -7,12 +7,13 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"time"
 )
 
 type BinanceService struct {
 	baseURL string
-	client  *http.Client
+	dataURL string
+	client  *BinanceHTTPClient
+	stream  *MarketStreamService
 }
 
 type BinancePriceData struct {
@@ -49,6 +50,8 @@ type Kline struct {
 	MACDSignal               float64 `json:"macdSignal"`
 	MACDHist                 float64 `json:"macdHist"`
 	OBV                      float64 `json:"obv"`
+	Delta                    float64 `json:"delta"` // taker buy volume minus taker sell volume
+	CVD                      float64 `json:"cvd"`   // cumulative volume delta
 }
 
 // TimeframeData contains analysis data for a specific timeframe
@@ -66,21 +69,27 @@ type MultiTimeframeData struct {
 }
 
 func NewBinanceService() *BinanceService {
-	baseURL := os.Getenv("BINANCE_MAINNET_URL") + "/fapi/v1"
+	host := os.Getenv("BINANCE_MAINNET_URL")
 
-	if baseURL == "" {
-		baseURL = "https://fapi.binance.com/fapi/v1"
+	if host == "" {
+		host = "https://fapi.binance.com"
 	}
 
 	return &BinanceService{
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL: host + "/fapi/v1",
+		dataURL: host + "/futures/data",
+		client:  SharedBinanceHTTPClient(host),
+		stream:  SharedMarketStream(),
 	}
 }
 
+// GetPrice returns the 24h ticker for a symbol, served from the WebSocket cache when warm
 func (s *BinanceService) GetPrice(symbol string) (*BinancePriceResponse, error) {
+	s.stream.WatchSymbol(symbol)
+	if cached, ok := s.stream.Price(symbol); ok {
+		return cached, nil
+	}
+
 	url := fmt.Sprintf("%s/ticker/24hr?symbol=%s", s.baseURL, symbol)
 
 	resp, err := s.client.Get(url)
@@ -119,10 +128,126 @@ func (s *BinanceService) GetPrice(symbol string) (*BinancePriceResponse, error)
 	return response, nil
 }
 
-// GetKlines fetches candlestick data for a specific timeframe
+// GetAllTickers returns the 24h ticker of every futures symbol
+func (s *BinanceService) GetAllTickers() ([]BinancePriceData, error) {
+	var tickers []BinancePriceData
+	if err := s.getJSON(s.baseURL+"/ticker/24hr", &tickers); err != nil {
+		return nil, fmt.Errorf("failed to fetch tickers from Binance: %w", err)
+	}
+
+	for i := range tickers {
+		if price, err := strconv.ParseFloat(tickers[i].PriceStr, 64); err == nil {
+			tickers[i].Price = price
+		}
+	}
+
+	return tickers, nil
+}
+
+// GetKlines returns candlestick data for a specific timeframe, served from the
+// WebSocket candle cache when warm and fetched over REST (seeding the cache) otherwise
 func (s *BinanceService) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	if cached, ok := s.stream.Klines(symbol, interval, limit); ok {
+		return cached, nil
+	}
+
+	klines, err := s.fetchKlines(symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.stream.Seed(symbol, interval, klines)
+
+	return klines, nil
+}
+
+// GetMarkPrice returns the latest mark price and funding data from the stream cache
+func (s *BinanceService) GetMarkPrice(symbol string) (*MarkPriceData, bool) {
+	s.stream.WatchSymbol(symbol)
+	return s.stream.MarkPrice(symbol)
+}
+
+// GetDepthSnapshot fetches an order book snapshot over REST
+func (s *BinanceService) GetDepthSnapshot(symbol string, limit int) (*DepthSnapshot, error) {
+	url := fmt.Sprintf("%s/depth?symbol=%s&limit=%d", s.baseURL, symbol, limit)
+
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch depth from Binance: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("binance depth API error: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read depth response: %w", err)
+	}
+
+	var snapshot DepthSnapshot
+	if err := json.Unmarshal(body, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse depth data: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// GetOrderBookFeatures returns spread, imbalance and wall features for a symbol.
+// The local book maintained from the diff-depth stream is used when it is in sync;
+// otherwise a fresh REST snapshot is taken (and used to resync the local book).
+func (s *BinanceService) GetOrderBookFeatures(symbol string) (*OrderBookFeatures, error) {
+	s.stream.WatchDepth(symbol)
+
+	bids, asks, ok := s.stream.OrderBookLevels(symbol)
+	if !ok {
+		snapshot, err := s.GetDepthSnapshot(symbol, 1000)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.stream.SyncOrderBook(symbol, snapshot) {
+			bids, asks, ok = s.stream.OrderBookLevels(symbol)
+		}
+		if !ok {
+			// Stream unavailable, use the snapshot on its own
+			bidMap := make(map[float64]float64, len(snapshot.Bids))
+			askMap := make(map[float64]float64, len(snapshot.Asks))
+			applyLevels(bidMap, snapshot.Bids)
+			applyLevels(askMap, snapshot.Asks)
+			return ComputeOrderBookFeatures(symbol, sortedLevels(bidMap, true), sortedLevels(askMap, false))
+		}
+	}
+
+	features, err := ComputeOrderBookFeatures(symbol, bids, asks)
+	if err != nil {
+		return nil, err
+	}
+	features.FromStream = true
+
+	return features, nil
+}
+
+// StreamStatus reports the state of the shared market data stream
+func (s *BinanceService) StreamStatus() MarketStreamStatus {
+	return s.stream.Status()
+}
+
+// GetKlinesRange fetches up to limit candles whose open time falls within [startTime, endTime] (Unix ms).
+// It always goes to REST and is used for paging through history.
+func (s *BinanceService) GetKlinesRange(symbol, interval string, startTime, endTime int64, limit int) ([]Kline, error) {
+	url := fmt.Sprintf("%s/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d", s.baseURL, symbol, interval, startTime, endTime, limit)
+	return s.requestKlines(url)
+}
+
+// fetchKlines fetches the latest candlestick data over REST
+func (s *BinanceService) fetchKlines(symbol, interval string, limit int) ([]Kline, error) {
 	url := fmt.Sprintf("%s/klines?symbol=%s&interval=%s&limit=%d", s.baseURL, symbol, interval, limit)
+	return s.requestKlines(url)
+}
 
+// requestKlines performs a klines request and parses the raw array payload
+func (s *BinanceService) requestKlines(url string) ([]Kline, error) {
 	resp, err := s.client.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch klines from Binance: %w", err)
@@ -191,6 +316,16 @@ func (s *BinanceService) GetKlines(symbol, interval string, limit int) ([]Kline,
 		if trades, ok := raw[8].(float64); ok {
 			kline.NumberOfTrades = int(trades)
 		}
+		if takerBuyBase, ok := raw[9].(string); ok {
+			if f, err := strconv.ParseFloat(takerBuyBase, 64); err == nil {
+				kline.TakerBuyBaseAssetVolume = f
+			}
+		}
+		if takerBuyQuote, ok := raw[10].(string); ok {
+			if f, err := strconv.ParseFloat(takerBuyQuote, 64); err == nil {
+				kline.TakerBuyQuoteAssetVolume = f
+			}
+		}
 
 		klines[i] = kline
 	}
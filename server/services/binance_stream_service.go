@@ -0,0 +1,527 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// MarketStreamService keeps a live, in-memory view of Binance futures market
+// data (klines, mark price and 24h ticker) fed by the combined WebSocket stream.
+// The cache is only considered warm while the socket is connected; every
+// reconnect invalidates it so callers fall back to REST until it is reseeded.
+type MarketStreamService struct {
+	wsURL string
+
+	mu         sync.RWMutex
+	conn       *websocket.Conn
+	connected  bool
+	dialed     bool
+	reconnects int
+	streams    map[string]bool
+	candles    map[string]*candleBuffer
+	tickers    map[string]*BinancePriceResponse
+	markPrices map[string]*MarkPriceData
+	books      map[string]*localOrderBook
+
+	startOnce sync.Once
+	requestID int64
+}
+
+// MarkPriceData is the latest markPrice stream update for a symbol
+type MarkPriceData struct {
+	Symbol          string    `json:"symbol"`
+	MarkPrice       float64   `json:"markPrice"`
+	IndexPrice      float64   `json:"indexPrice"`
+	FundingRate     float64   `json:"fundingRate"`
+	NextFundingTime int64     `json:"nextFundingTime"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// MarketStreamStatus describes the current state of the stream connection
+type MarketStreamStatus struct {
+	Connected  bool `json:"connected"`
+	Reconnects int  `json:"reconnects"`
+	Streams    int  `json:"streams"`
+}
+
+// candleBuffer is a rolling window of candles for one symbol/interval
+type candleBuffer struct {
+	klines []Kline
+	maxLen int
+	warm   bool
+}
+
+type streamEnvelope struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type streamKlineEvent struct {
+	Symbol string `json:"s"`
+	Kline  struct {
+		OpenTime                 int64  `json:"t"`
+		CloseTime                int64  `json:"T"`
+		Interval                 string `json:"i"`
+		Open                     string `json:"o"`
+		Close                    string `json:"c"`
+		High                     string `json:"h"`
+		Low                      string `json:"l"`
+		Volume                   string `json:"v"`
+		NumberOfTrades           int    `json:"n"`
+		QuoteAssetVolume         string `json:"q"`
+		TakerBuyBaseAssetVolume  string `json:"V"`
+		TakerBuyQuoteAssetVolume string `json:"Q"`
+	} `json:"k"`
+}
+
+type streamTickerEvent struct {
+	Symbol             string `json:"s"`
+	LastPrice          string `json:"c"`
+	PriceChangePercent string `json:"P"`
+	Volume             string `json:"v"`
+}
+
+type streamMarkPriceEvent struct {
+	Symbol          string `json:"s"`
+	MarkPrice       string `json:"p"`
+	IndexPrice      string `json:"i"`
+	FundingRate     string `json:"r"`
+	NextFundingTime int64  `json:"T"`
+}
+
+// maxCachedCandles is the minimum rolling window kept per symbol/interval
+const maxCachedCandles = 500
+
+var (
+	marketStreamOnce sync.Once
+	marketStream     *MarketStreamService
+)
+
+// SharedMarketStream returns the process-wide stream manager used by every BinanceService
+func SharedMarketStream() *MarketStreamService {
+	marketStreamOnce.Do(func() {
+		wsURL := os.Getenv("BINANCE_WS_URL")
+		if wsURL == "" {
+			wsURL = "wss://fstream.binance.com"
+		}
+		marketStream = NewMarketStreamService(wsURL)
+	})
+	return marketStream
+}
+
+// NewMarketStreamService creates a stream manager for the given WebSocket base URL.
+// The connection is opened lazily on the first subscription.
+func NewMarketStreamService(wsURL string) *MarketStreamService {
+	return &MarketStreamService{
+		wsURL:      strings.TrimSuffix(wsURL, "/"),
+		streams:    make(map[string]bool),
+		candles:    make(map[string]*candleBuffer),
+		tickers:    make(map[string]*BinancePriceResponse),
+		markPrices: make(map[string]*MarkPriceData),
+		books:      make(map[string]*localOrderBook),
+	}
+}
+
+// WatchSymbol subscribes to the ticker and markPrice streams for a symbol
+func (m *MarketStreamService) WatchSymbol(symbol string) {
+	lower := strings.ToLower(symbol)
+	m.subscribe(lower+"@ticker", lower+"@markPrice@1s")
+}
+
+// WatchKlines subscribes to the kline stream for a symbol/interval
+func (m *MarketStreamService) WatchKlines(symbol, interval string) {
+	m.subscribe(fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval))
+}
+
+// WatchDepth subscribes to the diff-depth stream for a symbol
+func (m *MarketStreamService) WatchDepth(symbol string) {
+	upper := strings.ToUpper(symbol)
+
+	m.mu.Lock()
+	if _, ok := m.books[upper]; !ok {
+		m.books[upper] = newLocalOrderBook()
+	}
+	m.mu.Unlock()
+
+	m.subscribe(strings.ToLower(symbol) + "@depth@100ms")
+}
+
+// SyncOrderBook applies a REST depth snapshot to the local book and replays
+// buffered diff events. It reports whether the book is usable afterwards.
+func (m *MarketStreamService) SyncOrderBook(symbol string, snapshot *DepthSnapshot) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[strings.ToUpper(symbol)]
+	if !ok || !m.connected {
+		return false
+	}
+	book.applySnapshot(snapshot)
+	return book.initialized
+}
+
+// OrderBookLevels returns the sorted bids and asks of the local book, or false if it is not initialized
+func (m *MarketStreamService) OrderBookLevels(symbol string) ([]PriceLevel, []PriceLevel, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.connected {
+		return nil, nil, false
+	}
+	book, ok := m.books[strings.ToUpper(symbol)]
+	if !ok || !book.initialized {
+		return nil, nil, false
+	}
+	return sortedLevels(book.bids, true), sortedLevels(book.asks, false), true
+}
+
+// Seed merges REST candles into the buffer for a symbol/interval and marks it warm.
+// Cached candles older than the seed are kept when the seed overlaps the newest
+// cached candle, so a short seed does not shrink the window; otherwise the seed
+// replaces the buffer.
+func (m *MarketStreamService) Seed(symbol, interval string, klines []Kline) {
+	m.WatchKlines(symbol, interval)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := candleKey(symbol, interval)
+	buf, ok := m.candles[key]
+	if !ok {
+		buf = &candleBuffer{maxLen: maxCachedCandles}
+		m.candles[key] = buf
+	}
+	if len(klines) == 0 {
+		return
+	}
+	if len(klines) > buf.maxLen {
+		buf.maxLen = len(klines)
+	}
+
+	var kept []Kline
+	if n := len(buf.klines); n > 0 && klines[0].OpenTime >= buf.klines[0].OpenTime && klines[0].OpenTime <= buf.klines[n-1].OpenTime {
+		for _, k := range buf.klines {
+			if k.OpenTime >= klines[0].OpenTime {
+				break
+			}
+			kept = append(kept, k)
+		}
+	}
+	buf.klines = append(kept, klines...)
+	if len(buf.klines) > buf.maxLen {
+		buf.klines = buf.klines[len(buf.klines)-buf.maxLen:]
+	}
+	buf.warm = true
+}
+
+// Klines returns a copy of the last limit cached candles, or false if the cache is cold
+func (m *MarketStreamService) Klines(symbol, interval string, limit int) ([]Kline, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.connected {
+		return nil, false
+	}
+	buf, ok := m.candles[candleKey(symbol, interval)]
+	if !ok || !buf.warm || len(buf.klines) < limit {
+		return nil, false
+	}
+	return append([]Kline(nil), buf.klines[len(buf.klines)-limit:]...), true
+}
+
+// Price returns the latest 24h ticker for a symbol, or false if none has arrived since the last connect
+func (m *MarketStreamService) Price(symbol string) (*BinancePriceResponse, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.connected {
+		return nil, false
+	}
+	ticker, ok := m.tickers[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, false
+	}
+	price := *ticker
+	return &price, true
+}
+
+// MarkPrice returns the latest markPrice update for a symbol
+func (m *MarketStreamService) MarkPrice(symbol string) (*MarkPriceData, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.connected {
+		return nil, false
+	}
+	mark, ok := m.markPrices[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, false
+	}
+	data := *mark
+	return &data, true
+}
+
+// Status reports the connection state of the stream
+func (m *MarketStreamService) Status() MarketStreamStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return MarketStreamStatus{
+		Connected:  m.connected,
+		Reconnects: m.reconnects,
+		Streams:    len(m.streams),
+	}
+}
+
+func (m *MarketStreamService) subscribe(streams ...string) {
+	m.mu.Lock()
+	var added []string
+	for _, stream := range streams {
+		if !m.streams[stream] {
+			m.streams[stream] = true
+			added = append(added, stream)
+		}
+	}
+	conn := m.conn
+	m.mu.Unlock()
+
+	m.startOnce.Do(func() {
+		go m.run()
+	})
+
+	// Streams added while disconnected are picked up by the next dial
+	if conn != nil && len(added) > 0 {
+		if err := m.sendSubscribe(conn, added); err != nil {
+			log.Printf("MarketStream: Failed to subscribe to %v: %v", added, err)
+		}
+	}
+}
+
+func (m *MarketStreamService) sendSubscribe(conn *websocket.Conn, streams []string) error {
+	m.mu.Lock()
+	m.requestID++
+	id := m.requestID
+	m.mu.Unlock()
+
+	return websocket.JSON.Send(conn, map[string]interface{}{
+		"method": "SUBSCRIBE",
+		"params": streams,
+		"id":     id,
+	})
+}
+
+// run keeps the connection alive, reconnecting with exponential backoff
+func (m *MarketStreamService) run() {
+	backoff := time.Second
+	for {
+		wasConnected, err := m.connectAndRead()
+		if wasConnected {
+			backoff = time.Second
+		}
+		log.Printf("MarketStream: Connection lost: %v (reconnecting in %s)", err, backoff)
+		time.Sleep(backoff)
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// connectAndRead dials the combined stream and reads until the connection fails.
+// It reports whether the dial succeeded so run can reset its backoff.
+func (m *MarketStreamService) connectAndRead() (bool, error) {
+	m.mu.RLock()
+	streams := make([]string, 0, len(m.streams))
+	for stream := range m.streams {
+		streams = append(streams, stream)
+	}
+	m.mu.RUnlock()
+
+	url := m.wsURL + "/stream"
+	if len(streams) > 0 {
+		url += "?streams=" + strings.Join(streams, "/")
+	}
+
+	conn, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		return false, fmt.Errorf("failed to dial %s: %w", m.wsURL, err)
+	}
+	defer conn.Close()
+
+	m.mu.Lock()
+	if m.dialed {
+		m.reconnects++
+	}
+	m.dialed = true
+	m.conn = conn
+	m.connected = true
+	// Anything cached before this connection may have missed updates
+	for _, buf := range m.candles {
+		buf.warm = false
+	}
+	m.tickers = make(map[string]*BinancePriceResponse)
+	m.markPrices = make(map[string]*MarkPriceData)
+	for _, book := range m.books {
+		book.reset()
+	}
+	var missed []string
+	for stream := range m.streams {
+		if !contains(streams, stream) {
+			missed = append(missed, stream)
+		}
+	}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.conn = nil
+		m.connected = false
+		m.mu.Unlock()
+	}()
+
+	// Streams subscribed between building the URL and the dial completing
+	if len(missed) > 0 {
+		if err := m.sendSubscribe(conn, missed); err != nil {
+			return true, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Minute))
+
+		var msg []byte
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			return true, err
+		}
+		m.handleMessage(msg)
+	}
+}
+
+func (m *MarketStreamService) handleMessage(msg []byte) {
+	var envelope streamEnvelope
+	if err := json.Unmarshal(msg, &envelope); err != nil || envelope.Stream == "" {
+		// Subscription acknowledgements have no stream name
+		return
+	}
+
+	switch {
+	case strings.Contains(envelope.Stream, "@depth"):
+		var event depthEvent
+		if err := json.Unmarshal(envelope.Data, &event); err == nil {
+			m.applyDepth(&event)
+		}
+	case strings.Contains(envelope.Stream, "@kline_"):
+		var event streamKlineEvent
+		if err := json.Unmarshal(envelope.Data, &event); err == nil {
+			m.applyKline(&event)
+		}
+	case strings.HasSuffix(envelope.Stream, "@ticker"):
+		var event streamTickerEvent
+		if err := json.Unmarshal(envelope.Data, &event); err == nil {
+			m.applyTicker(&event)
+		}
+	case strings.Contains(envelope.Stream, "@markPrice"):
+		var event streamMarkPriceEvent
+		if err := json.Unmarshal(envelope.Data, &event); err == nil {
+			m.applyMarkPrice(&event)
+		}
+	}
+}
+
+func (m *MarketStreamService) applyKline(event *streamKlineEvent) {
+	k := event.Kline
+	kline := Kline{
+		OpenTime:                 k.OpenTime,
+		Open:                     parseStreamFloat(k.Open),
+		High:                     parseStreamFloat(k.High),
+		Low:                      parseStreamFloat(k.Low),
+		Close:                    parseStreamFloat(k.Close),
+		Volume:                   parseStreamFloat(k.Volume),
+		CloseTime:                k.CloseTime,
+		QuoteAssetVolume:         parseStreamFloat(k.QuoteAssetVolume),
+		NumberOfTrades:           k.NumberOfTrades,
+		TakerBuyBaseAssetVolume:  parseStreamFloat(k.TakerBuyBaseAssetVolume),
+		TakerBuyQuoteAssetVolume: parseStreamFloat(k.TakerBuyQuoteAssetVolume),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf, ok := m.candles[candleKey(event.Symbol, k.Interval)]
+	if !ok || !buf.warm || len(buf.klines) == 0 {
+		return
+	}
+
+	last := &buf.klines[len(buf.klines)-1]
+	switch {
+	case kline.OpenTime == last.OpenTime:
+		*last = kline
+	case kline.OpenTime == last.CloseTime+1:
+		buf.klines = append(buf.klines, kline)
+		if len(buf.klines) > buf.maxLen {
+			buf.klines = buf.klines[len(buf.klines)-buf.maxLen:]
+		}
+	case kline.OpenTime > last.CloseTime+1:
+		// A candle was skipped; the buffer needs a fresh REST seed
+		buf.warm = false
+	}
+}
+
+func (m *MarketStreamService) applyDepth(event *depthEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if book, ok := m.books[event.Symbol]; ok {
+		book.apply(*event)
+	}
+}
+
+func (m *MarketStreamService) applyTicker(event *streamTickerEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tickers[event.Symbol] = &BinancePriceResponse{
+		Price:     parseStreamFloat(event.LastPrice),
+		Change24h: parseStreamFloat(event.PriceChangePercent),
+		Volume:    parseStreamFloat(event.Volume),
+	}
+}
+
+func (m *MarketStreamService) applyMarkPrice(event *streamMarkPriceEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.markPrices[event.Symbol] = &MarkPriceData{
+		Symbol:          event.Symbol,
+		MarkPrice:       parseStreamFloat(event.MarkPrice),
+		IndexPrice:      parseStreamFloat(event.IndexPrice),
+		FundingRate:     parseStreamFloat(event.FundingRate),
+		NextFundingTime: event.NextFundingTime,
+		UpdatedAt:       time.Now(),
+	}
+}
+
+func candleKey(symbol, interval string) string {
+	return strings.ToUpper(symbol) + "|" + interval
+}
+
+func parseStreamFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// streamStandIn is a local WebSocket server that hands each accepted connection
+// to the test and keeps it open until the test closes it
+type streamStandIn struct {
+	server *httptest.Server
+	conns  chan *websocket.Conn
+}
+
+func newStreamStandIn(t *testing.T) *streamStandIn {
+	standIn := &streamStandIn{conns: make(chan *websocket.Conn, 4)}
+	standIn.server = httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		standIn.conns <- conn
+		// Drain subscribe requests until the client or the test closes the socket
+		var msg []byte
+		for websocket.Message.Receive(conn, &msg) == nil {
+		}
+	}))
+	t.Cleanup(standIn.server.Close)
+	return standIn
+}
+
+func (s *streamStandIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *streamStandIn) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not connect")
+		return nil
+	}
+}
+
+func sendStream(t *testing.T, conn *websocket.Conn, stream, data string) {
+	t.Helper()
+	msg := fmt.Sprintf(`{"stream":%q,"data":%s}`, stream, data)
+	if err := websocket.Message.Send(conn, msg); err != nil {
+		t.Fatalf("failed to send %s: %v", stream, err)
+	}
+}
+
+func klineEvent(openTime int64, close string) string {
+	return fmt.Sprintf(`{"s":"BTCUSDT","k":{"t":%d,"T":%d,"i":"1m","o":"100","c":%q,"h":"110","l":"90","v":"5"}}`,
+		openTime, openTime+59999, close)
+}
+
+func testCandles(from, count int) []Kline {
+	klines := make([]Kline, count)
+	for i := range klines {
+		openTime := int64(from+i) * 60000
+		klines[i] = Kline{OpenTime: openTime, CloseTime: openTime + 59999, Open: 100, Close: 100}
+	}
+	return klines
+}
+
+// eventually polls cond until it holds or a few seconds have passed
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMarketStreamSeedUpdateAndReconnect(t *testing.T) {
+	standIn := newStreamStandIn(t)
+	m := NewMarketStreamService(standIn.url())
+
+	// The first subscription dials; the connect invalidates anything seeded before it
+	m.Seed("BTCUSDT", "1m", testCandles(0, 3))
+	conn := standIn.accept(t)
+	eventually(t, "connect", func() bool { return m.Status().Connected })
+	if _, ok := m.Klines("BTCUSDT", "1m", 3); ok {
+		t.Fatal("cache seeded before the connect should be cold")
+	}
+
+	m.Seed("BTCUSDT", "1m", testCandles(0, 3))
+	if klines, ok := m.Klines("BTCUSDT", "1m", 3); !ok || len(klines) != 3 {
+		t.Fatalf("expected 3 warm candles, got %d (ok=%v)", len(klines), ok)
+	}
+
+	// An update of the forming candle replaces it, the next candle is appended
+	sendStream(t, conn, "btcusdt@kline_1m", klineEvent(2*60000, "105"))
+	eventually(t, "candle update", func() bool {
+		klines, ok := m.Klines("BTCUSDT", "1m", 3)
+		return ok && klines[2].Close == 105
+	})
+	sendStream(t, conn, "btcusdt@kline_1m", klineEvent(3*60000, "106"))
+	eventually(t, "candle append", func() bool {
+		klines, ok := m.Klines("BTCUSDT", "1m", 4)
+		return ok && klines[3].OpenTime == 3*60000 && klines[3].Close == 106
+	})
+
+	sendStream(t, conn, "btcusdt@ticker", `{"s":"BTCUSDT","c":"106","P":"1.5","v":"1000"}`)
+	eventually(t, "ticker", func() bool {
+		price, ok := m.Price("BTCUSDT")
+		return ok && price.Price == 106
+	})
+
+	// A skipped candle makes the buffer cold until it is reseeded
+	sendStream(t, conn, "btcusdt@kline_1m", klineEvent(5*60000, "108"))
+	eventually(t, "gap invalidation", func() bool {
+		_, ok := m.Klines("BTCUSDT", "1m", 1)
+		return !ok
+	})
+	m.Seed("BTCUSDT", "1m", testCandles(0, 6))
+
+	// Dropping the connection invalidates every cache until the reconnect is reseeded
+	conn.Close()
+	eventually(t, "disconnect", func() bool { return !m.Status().Connected })
+	if _, ok := m.Klines("BTCUSDT", "1m", 1); ok {
+		t.Fatal("candles should not be served while disconnected")
+	}
+
+	standIn.accept(t)
+	eventually(t, "reconnect", func() bool { return m.Status().Connected })
+	if status := m.Status(); status.Reconnects != 1 {
+		t.Fatalf("expected 1 reconnect, got %d", status.Reconnects)
+	}
+	if _, ok := m.Klines("BTCUSDT", "1m", 1); ok {
+		t.Fatal("candles cached before the reconnect should be cold")
+	}
+	if _, ok := m.Price("BTCUSDT"); ok {
+		t.Fatal("ticker cached before the reconnect should be dropped")
+	}
+
+	// A short seed overlapping the newest candle refreshes it and keeps the older window
+	m.Seed("BTCUSDT", "1m", testCandles(4, 2))
+	klines, ok := m.Klines("BTCUSDT", "1m", 6)
+	if !ok {
+		t.Fatal("short seed should keep the cached window warm")
+	}
+	for i, k := range klines {
+		if k.OpenTime != int64(i)*60000 {
+			t.Fatalf("candle %d has open time %d", i, k.OpenTime)
+		}
+	}
+}
+
+func TestMarketStreamSeedReplacesDisjointBuffer(t *testing.T) {
+	m := NewMarketStreamService("ws://127.0.0.1:0")
+
+	m.Seed("BTCUSDT", "1m", testCandles(0, 5))
+	m.Seed("BTCUSDT", "1m", testCandles(10, 2))
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	buf := m.candles[candleKey("BTCUSDT", "1m")]
+	if len(buf.klines) != 2 || buf.klines[0].OpenTime != 10*60000 {
+		t.Fatalf("a seed after a gap should replace the buffer, got %d candles", len(buf.klines))
+	}
+}
@@ -0,0 +1,749 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const bybitRecvWindow = "5000"
+
+// bybitIntervals maps Binance interval names to Bybit v5 kline intervals
+var bybitIntervals = map[string]string{
+	"1m":  "1",
+	"3m":  "3",
+	"5m":  "5",
+	"15m": "15",
+	"30m": "30",
+	"1h":  "60",
+	"2h":  "120",
+	"4h":  "240",
+	"6h":  "360",
+	"12h": "720",
+	"1d":  "D",
+	"1w":  "W",
+	"1M":  "M",
+}
+
+// bybitOrderStatuses maps Bybit v5 order statuses to the Binance names used across the app
+var bybitOrderStatuses = map[string]string{
+	"New":                     "NEW",
+	"PartiallyFilled":         "PARTIALLY_FILLED",
+	"Filled":                  "FILLED",
+	"Cancelled":               "CANCELED",
+	"PartiallyFilledCanceled": "CANCELED",
+	"Rejected":                "REJECTED",
+	"Untriggered":             "NEW",
+	"Triggered":               "NEW",
+	"Deactivated":             "CANCELED",
+}
+
+// BybitExchange implements Exchange for Bybit USDT linear perpetuals (v5 API)
+type BybitExchange struct {
+	apiKey    string
+	secretKey string
+	baseURL   string
+	isTestnet bool
+	client    *http.Client
+}
+
+// bybitResponse is the v5 response envelope
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type bybitOrder struct {
+	OrderID      string `json:"orderId"`
+	OrderLinkID  string `json:"orderLinkId"`
+	Symbol       string `json:"symbol"`
+	Side         string `json:"side"`
+	OrderType    string `json:"orderType"`
+	OrderStatus  string `json:"orderStatus"`
+	Price        string `json:"price"`
+	AvgPrice     string `json:"avgPrice"`
+	TriggerPrice string `json:"triggerPrice"`
+	Qty          string `json:"qty"`
+	CumExecQty   string `json:"cumExecQty"`
+	UpdatedTime  string `json:"updatedTime"`
+}
+
+var (
+	bybitInstrumentsMu sync.Mutex
+	bybitInstruments   = map[string]*bybitInstrumentCache{}
+)
+
+// bybitInstrumentCache holds instruments-info for one Bybit host
+type bybitInstrumentCache struct {
+	mu      sync.RWMutex
+	symbols map[string]cachedInstrument
+}
+
+// cachedInstrument is one symbol's rules and when they were fetched
+type cachedInstrument struct {
+	info      *SymbolInfo
+	fetchedAt time.Time
+}
+
+func NewBybitExchange(isTestnet bool) *BybitExchange {
+	apiKey := os.Getenv("BYBIT_API_KEY")
+	secretKey := os.Getenv("BYBIT_SECRET_KEY")
+	baseURL := os.Getenv("BYBIT_MAINNET_URL")
+
+	if isTestnet {
+		apiKey = os.Getenv("BYBIT_TESTNET_API_KEY")
+		secretKey = os.Getenv("BYBIT_TESTNET_SECRET_KEY")
+		baseURL = os.Getenv("BYBIT_TESTNET_URL")
+	}
+
+	if baseURL == "" {
+		if isTestnet {
+			baseURL = "https://api-testnet.bybit.com"
+		} else {
+			baseURL = "https://api.bybit.com"
+		}
+	}
+
+	return &BybitExchange{
+		apiKey:    apiKey,
+		secretKey: secretKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		isTestnet: isTestnet,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (e *BybitExchange) Name() string {
+	return VenueBybit
+}
+
+func (e *BybitExchange) IsTestnet() bool {
+	return e.isTestnet
+}
+
+// IsConfigured checks if Bybit API credentials are properly configured
+func (e *BybitExchange) IsConfigured() bool {
+	return e.apiKey != "" && e.secretKey != ""
+}
+
+// generateSignature signs timestamp + apiKey + recvWindow + payload as Bybit v5 expects
+func (e *BybitExchange) generateSignature(timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(e.secretKey))
+	mac.Write([]byte(timestamp + e.apiKey + bybitRecvWindow + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicGet calls an unauthenticated market endpoint and decodes its result
+func (e *BybitExchange) publicGet(endpoint string, params url.Values, v interface{}) error {
+	resp, err := e.client.Get(fmt.Sprintf("%s%s?%s", e.baseURL, endpoint, params.Encode()))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return decodeBybitResponse(resp, v)
+}
+
+// signedRequest calls a private endpoint. GET parameters go in the query string,
+// POST parameters in a JSON body; both are part of the signature.
+func (e *BybitExchange) signedRequest(method, endpoint string, params map[string]interface{}, v interface{}) error {
+	if !e.IsConfigured() {
+		return fmt.Errorf("bybit API credentials not configured")
+	}
+
+	var (
+		req     *http.Request
+		payload string
+		err     error
+	)
+
+	if method == "GET" {
+		values := url.Values{}
+		for key, value := range params {
+			values.Add(key, fmt.Sprint(value))
+		}
+		payload = values.Encode()
+		req, err = http.NewRequest(method, fmt.Sprintf("%s%s?%s", e.baseURL, endpoint, payload), nil)
+	} else {
+		body, marshalErr := json.Marshal(params)
+		if marshalErr != nil {
+			return fmt.Errorf("failed to encode request: %w", marshalErr)
+		}
+		payload = string(body)
+		req, err = http.NewRequest(method, e.baseURL+endpoint, bytes.NewReader(body))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req.Header.Set("X-BAPI-API-KEY", e.apiKey)
+	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+	req.Header.Set("X-BAPI-RECV-WINDOW", bybitRecvWindow)
+	req.Header.Set("X-BAPI-SIGN", e.generateSignature(timestamp, payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return decodeBybitResponse(resp, v)
+}
+
+func decodeBybitResponse(resp *http.Response, v interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bybit API error: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope bybitResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("failed to parse bybit response: %w", err)
+	}
+	if envelope.RetCode != 0 {
+		return &bybitAPIError{Code: envelope.RetCode, Message: envelope.RetMsg}
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, v); err != nil {
+		return fmt.Errorf("failed to parse bybit result: %w", err)
+	}
+	return nil
+}
+
+// bybitAPIError is a non-zero retCode returned by the v5 API
+type bybitAPIError struct {
+	Code    int
+	Message string
+}
+
+func (e *bybitAPIError) Error() string {
+	return fmt.Sprintf("bybit API error %d: %s", e.Code, e.Message)
+}
+
+func (e *BybitExchange) GetPrice(symbol string) (float64, error) {
+	var result struct {
+		List []struct {
+			LastPrice string `json:"lastPrice"`
+		} `json:"list"`
+	}
+	params := url.Values{"category": {"linear"}, "symbol": {symbol}}
+	if err := e.publicGet("/v5/market/tickers", params, &result); err != nil {
+		return 0, fmt.Errorf("failed to get price: %w", err)
+	}
+	if len(result.List) == 0 {
+		return 0, fmt.Errorf("symbol %s not found on bybit", symbol)
+	}
+
+	price, err := strconv.ParseFloat(result.List[0].LastPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse price: %w", err)
+	}
+	return price, nil
+}
+
+// GetKlines returns candles oldest first. Bybit does not report taker buy volume,
+// so Delta and CVD stay zero for this venue.
+func (e *BybitExchange) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
+	bybitInterval, ok := bybitIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("interval %s is not supported by bybit", interval)
+	}
+	duration, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		List [][]string `json:"list"`
+	}
+	params := url.Values{
+		"category": {"linear"},
+		"symbol":   {symbol},
+		"interval": {bybitInterval},
+		"limit":    {strconv.Itoa(limit)},
+	}
+	if err := e.publicGet("/v5/market/kline", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+
+	klines := make([]Kline, 0, len(result.List))
+	for _, raw := range result.List {
+		if len(raw) < 7 {
+			continue
+		}
+		openTime, _ := strconv.ParseInt(raw[0], 10, 64)
+		open, _ := strconv.ParseFloat(raw[1], 64)
+		high, _ := strconv.ParseFloat(raw[2], 64)
+		low, _ := strconv.ParseFloat(raw[3], 64)
+		closePrice, _ := strconv.ParseFloat(raw[4], 64)
+		volume, _ := strconv.ParseFloat(raw[5], 64)
+		turnover, _ := strconv.ParseFloat(raw[6], 64)
+		klines = append(klines, Kline{
+			OpenTime:         openTime,
+			Open:             open,
+			High:             high,
+			Low:              low,
+			Close:            closePrice,
+			Volume:           volume,
+			CloseTime:        openTime + duration.Milliseconds() - 1,
+			QuoteAssetVolume: turnover,
+		})
+	}
+
+	// Bybit lists the newest candle first
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].OpenTime < klines[j].OpenTime
+	})
+
+	return klines, nil
+}
+
+// GetSymbolInfo returns the instrument rules mapped onto SymbolInfo, cached per host
+func (e *BybitExchange) GetSymbolInfo(symbol string) (*SymbolInfo, error) {
+	bybitInstrumentsMu.Lock()
+	cache, ok := bybitInstruments[e.baseURL]
+	if !ok {
+		cache = &bybitInstrumentCache{symbols: map[string]cachedInstrument{}}
+		bybitInstruments[e.baseURL] = cache
+	}
+	bybitInstrumentsMu.Unlock()
+
+	cache.mu.RLock()
+	cached, found := cache.symbols[strings.ToUpper(symbol)]
+	cache.mu.RUnlock()
+	if found && time.Since(cached.fetchedAt) < defaultExchangeInfoTTL {
+		return cached.info, nil
+	}
+
+	info, err := e.fetchInstrument(strings.ToUpper(symbol))
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	cache.symbols[info.Symbol] = cachedInstrument{info: info, fetchedAt: time.Now()}
+	cache.mu.Unlock()
+
+	return info, nil
+}
+
+func (e *BybitExchange) fetchInstrument(symbol string) (*SymbolInfo, error) {
+	var result struct {
+		List []struct {
+			Symbol       string `json:"symbol"`
+			ContractType string `json:"contractType"`
+			Status       string `json:"status"`
+			BaseCoin     string `json:"baseCoin"`
+			QuoteCoin    string `json:"quoteCoin"`
+			SettleCoin   string `json:"settleCoin"`
+			LaunchTime   string `json:"launchTime"`
+			PriceScale   string `json:"priceScale"`
+			PriceFilter  struct {
+				MinPrice string `json:"minPrice"`
+				MaxPrice string `json:"maxPrice"`
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+			LotSizeFilter struct {
+				MaxOrderQty      string `json:"maxOrderQty"`
+				MaxMktOrderQty   string `json:"maxMktOrderQty"`
+				MinOrderQty      string `json:"minOrderQty"`
+				QtyStep          string `json:"qtyStep"`
+				MinNotionalValue string `json:"minNotionalValue"`
+			} `json:"lotSizeFilter"`
+		} `json:"list"`
+	}
+	params := url.Values{"category": {"linear"}, "symbol": {symbol}}
+	if err := e.publicGet("/v5/market/instruments-info", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get instrument info: %w", err)
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("symbol %s not found", symbol)
+	}
+
+	raw := result.List[0]
+	parse := func(s string) float64 {
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	}
+	priceScale, _ := strconv.Atoi(raw.PriceScale)
+	launchTime, _ := strconv.ParseInt(raw.LaunchTime, 10, 64)
+
+	info := &SymbolInfo{
+		Symbol:            raw.Symbol,
+		Pair:              raw.BaseCoin + raw.QuoteCoin,
+		ContractType:      raw.ContractType,
+		Status:            strings.ToUpper(raw.Status),
+		BaseAsset:         raw.BaseCoin,
+		QuoteAsset:        raw.QuoteCoin,
+		MarginAsset:       raw.SettleCoin,
+		PricePrecision:    priceScale,
+		QuantityPrecision: decimalPlaces(raw.LotSizeFilter.QtyStep),
+		OnboardDate:       launchTime,
+		TickSize:          parse(raw.PriceFilter.TickSize),
+		MinPrice:          parse(raw.PriceFilter.MinPrice),
+		MaxPrice:          parse(raw.PriceFilter.MaxPrice),
+		TickDecimals:      decimalPlaces(raw.PriceFilter.TickSize),
+		StepSize:          parse(raw.LotSizeFilter.QtyStep),
+		MinQty:            parse(raw.LotSizeFilter.MinOrderQty),
+		MaxQty:            parse(raw.LotSizeFilter.MaxOrderQty),
+		StepDecimals:      decimalPlaces(raw.LotSizeFilter.QtyStep),
+		MarketStepSize:    parse(raw.LotSizeFilter.QtyStep),
+		MarketMinQty:      parse(raw.LotSizeFilter.MinOrderQty),
+		MarketMaxQty:      parse(raw.LotSizeFilter.MaxMktOrderQty),
+		MinNotional:       parse(raw.LotSizeFilter.MinNotionalValue),
+	}
+	if raw.ContractType == "LinearPerpetual" {
+		info.ContractType = "PERPETUAL"
+	}
+
+	return info, nil
+}
+
+// GetLeverageBrackets converts the symbol's risk limit tiers into leverage
+// brackets. Tiers without an mmDeduction get the cumulative deduction that keeps
+// the maintenance margin continuous, as Binance computes cum.
+func (e *BybitExchange) GetLeverageBrackets(symbol string) ([]LeverageBracket, error) {
+	var result struct {
+		List []struct {
+			RiskLimitValue    string `json:"riskLimitValue"`
+			MaintenanceMargin string `json:"maintenanceMargin"`
+			MaxLeverage       string `json:"maxLeverage"`
+			MMDeduction       string `json:"mmDeduction"`
+		} `json:"list"`
+	}
+	params := url.Values{"category": {"linear"}, "symbol": {symbol}}
+	if err := e.publicGet("/v5/market/risk-limit", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("no risk limits for %s", symbol)
+	}
+
+	brackets := make([]LeverageBracket, 0, len(result.List))
+	for _, tier := range result.List {
+		limit, _ := strconv.ParseFloat(tier.RiskLimitValue, 64)
+		mmr, _ := strconv.ParseFloat(tier.MaintenanceMargin, 64)
+		maxLeverage, _ := strconv.ParseFloat(tier.MaxLeverage, 64)
+		cum, err := strconv.ParseFloat(tier.MMDeduction, 64)
+		if err != nil {
+			cum = -1
+		}
+		brackets = append(brackets, LeverageBracket{
+			NotionalCap:      limit,
+			MaintMarginRatio: mmr,
+			InitialLeverage:  int(maxLeverage),
+			Cum:              cum,
+		})
+	}
+	sort.Slice(brackets, func(i, j int) bool {
+		return brackets[i].NotionalCap < brackets[j].NotionalCap
+	})
+	for i := range brackets {
+		brackets[i].Bracket = i + 1
+		if i == 0 {
+			brackets[i].Cum = math.Max(brackets[i].Cum, 0)
+			continue
+		}
+		prev := brackets[i-1]
+		brackets[i].NotionalFloor = prev.NotionalCap
+		if brackets[i].Cum < 0 {
+			brackets[i].Cum = prev.Cum + prev.NotionalCap*(brackets[i].MaintMarginRatio-prev.MaintMarginRatio)
+		}
+	}
+	return brackets, nil
+}
+
+func (e *BybitExchange) GetBalance(asset string) (*ExchangeBalance, error) {
+	var result struct {
+		List []struct {
+			TotalAvailableBalance string `json:"totalAvailableBalance"`
+			Coin                  []struct {
+				Coin          string `json:"coin"`
+				WalletBalance string `json:"walletBalance"`
+				UnrealisedPnl string `json:"unrealisedPnl"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	params := map[string]interface{}{"accountType": "UNIFIED", "coin": asset}
+	if err := e.signedRequest("GET", "/v5/account/wallet-balance", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	balance := &ExchangeBalance{Asset: asset}
+	if len(result.List) == 0 {
+		return balance, nil
+	}
+
+	// Unified accounts share margin across coins, so availability is reported in USD at account level
+	balance.AvailableBalance, _ = strconv.ParseFloat(result.List[0].TotalAvailableBalance, 64)
+	for _, c := range result.List[0].Coin {
+		if c.Coin == asset {
+			balance.WalletBalance, _ = strconv.ParseFloat(c.WalletBalance, 64)
+			balance.UnrealizedProfit, _ = strconv.ParseFloat(c.UnrealisedPnl, 64)
+		}
+	}
+
+	return balance, nil
+}
+
+func (e *BybitExchange) GetPositions() ([]ExchangePosition, error) {
+	var result struct {
+		List []struct {
+			Symbol        string `json:"symbol"`
+			Side          string `json:"side"`
+			Size          string `json:"size"`
+			AvgPrice      string `json:"avgPrice"`
+			UnrealisedPnl string `json:"unrealisedPnl"`
+			Leverage      string `json:"leverage"`
+		} `json:"list"`
+	}
+	params := map[string]interface{}{"category": "linear", "settleCoin": "USDT"}
+	if err := e.signedRequest("GET", "/v5/position/list", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	positions := []ExchangePosition{}
+	for _, p := range result.List {
+		size, _ := strconv.ParseFloat(p.Size, 64)
+		if size == 0 {
+			continue
+		}
+		direction := "LONG"
+		if p.Side == "Sell" {
+			direction = "SHORT"
+		}
+		entry, _ := strconv.ParseFloat(p.AvgPrice, 64)
+		unrealized, _ := strconv.ParseFloat(p.UnrealisedPnl, 64)
+		leverage, _ := strconv.ParseFloat(p.Leverage, 64)
+		positions = append(positions, ExchangePosition{
+			Symbol:        p.Symbol,
+			Direction:     direction,
+			Size:          size,
+			EntryPrice:    entry,
+			UnrealizedPnL: unrealized,
+			Leverage:      int(leverage),
+		})
+	}
+
+	return positions, nil
+}
+
+func (e *BybitExchange) SetLeverage(symbol string, leverage int) error {
+	params := map[string]interface{}{
+		"category":     "linear",
+		"symbol":       symbol,
+		"buyLeverage":  strconv.Itoa(leverage),
+		"sellLeverage": strconv.Itoa(leverage),
+	}
+	err := e.signedRequest("POST", "/v5/position/set-leverage", params, nil)
+	// 110043: leverage not modified
+	if apiErr, ok := err.(*bybitAPIError); ok && apiErr.Code == 110043 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set leverage: %w", err)
+	}
+	return nil
+}
+
+// SetMarginMode refuses to switch: Bybit's unified account sets the margin mode
+// for every position at once
+func (e *BybitExchange) SetMarginMode(symbol, mode string) error {
+	return ErrMarginModePerAccount
+}
+
+// GetMarginMode reports the unified account's margin mode, which every symbol shares.
+// Portfolio margin is cross margin across the account.
+func (e *BybitExchange) GetMarginMode(symbol string) (string, error) {
+	var result struct {
+		MarginMode string `json:"marginMode"`
+	}
+	if err := e.signedRequest("GET", "/v5/account/info", map[string]interface{}{}, &result); err != nil {
+		return "", fmt.Errorf("failed to get account info: %w", err)
+	}
+	if result.MarginMode == "ISOLATED_MARGIN" {
+		return MarginModeIsolated, nil
+	}
+	return MarginModeCross, nil
+}
+
+// PlaceOrder maps a Binance-style order request onto /v5/order/create.
+// STOP_MARKET and TAKE_PROFIT_MARKET become conditional market orders whose
+// trigger direction follows from the side.
+func (e *BybitExchange) PlaceOrder(req *OrderRequest) (*ExchangeOrder, error) {
+	info, err := e.GetSymbolInfo(req.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbol rules: %w", err)
+	}
+
+	side := "Buy"
+	if req.Side == "SELL" {
+		side = "Sell"
+	}
+
+	params := map[string]interface{}{
+		"category":    "linear",
+		"symbol":      req.Symbol,
+		"side":        side,
+		"positionIdx": 0,
+	}
+
+	switch req.Type {
+	case "MARKET":
+		params["orderType"] = "Market"
+		params["qty"] = info.FormatQuantity(req.Quantity, true)
+	case "LIMIT":
+		params["orderType"] = "Limit"
+		params["qty"] = info.FormatQuantity(req.Quantity, false)
+		params["price"] = info.FormatPrice(req.Price)
+		if req.TimeInForce != "" {
+			params["timeInForce"] = req.TimeInForce
+		}
+	case "STOP_MARKET", "TAKE_PROFIT_MARKET":
+		params["orderType"] = "Market"
+		params["qty"] = info.FormatQuantity(req.Quantity, true)
+		params["triggerPrice"] = info.FormatPrice(req.StopPrice)
+		params["triggerBy"] = "MarkPrice"
+		// 1: triggered when price rises to triggerPrice, 2: when it falls to it
+		rises := (req.Type == "STOP_MARKET") == (req.Side == "BUY")
+		if rises {
+			params["triggerDirection"] = 1
+		} else {
+			params["triggerDirection"] = 2
+		}
+	default:
+		return nil, fmt.Errorf("order type %s is not supported on bybit", req.Type)
+	}
+
+	if req.ReduceOnly {
+		params["reduceOnly"] = true
+	}
+	if req.NewClientOrderID != "" {
+		params["orderLinkId"] = req.NewClientOrderID
+	}
+
+	var result struct {
+		OrderID     string `json:"orderId"`
+		OrderLinkID string `json:"orderLinkId"`
+	}
+	if err := e.signedRequest("POST", "/v5/order/create", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	// The create endpoint only acknowledges; fetch the order for status and fill price
+	order, err := e.GetOrder(req.Symbol, result.OrderID)
+	if err != nil {
+		return &ExchangeOrder{
+			Venue:         VenueBybit,
+			Symbol:        req.Symbol,
+			OrderID:       result.OrderID,
+			ClientOrderID: result.OrderLinkID,
+			Side:          req.Side,
+			Type:          req.Type,
+			Status:        "NEW",
+			Price:         req.Price,
+			StopPrice:     req.StopPrice,
+			Quantity:      req.Quantity,
+			UpdateTime:    time.Now().UnixMilli(),
+		}, nil
+	}
+	order.Type = req.Type
+	return order, nil
+}
+
+func (e *BybitExchange) CancelOrder(symbol, orderID string) (*ExchangeOrder, error) {
+	params := map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+		"orderId":  orderID,
+	}
+	if err := e.signedRequest("POST", "/v5/order/cancel", params, nil); err != nil {
+		return nil, fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	return &ExchangeOrder{
+		Venue:      VenueBybit,
+		Symbol:     symbol,
+		OrderID:    orderID,
+		Status:     "CANCELED",
+		UpdateTime: time.Now().UnixMilli(),
+	}, nil
+}
+
+// GetOrder looks the order up among open orders first, then in the order history
+func (e *BybitExchange) GetOrder(symbol, orderID string) (*ExchangeOrder, error) {
+	params := map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+		"orderId":  orderID,
+	}
+
+	for _, endpoint := range []string{"/v5/order/realtime", "/v5/order/history"} {
+		var result struct {
+			List []bybitOrder `json:"list"`
+		}
+		if err := e.signedRequest("GET", endpoint, params, &result); err != nil {
+			return nil, fmt.Errorf("failed to get order: %w", err)
+		}
+		if len(result.List) > 0 {
+			return bybitOrderToExchange(&result.List[0]), nil
+		}
+	}
+
+	return nil, fmt.Errorf("order %s not found on bybit", orderID)
+}
+
+func bybitOrderToExchange(order *bybitOrder) *ExchangeOrder {
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	triggerPrice, _ := strconv.ParseFloat(order.TriggerPrice, 64)
+	qty, _ := strconv.ParseFloat(order.Qty, 64)
+	executed, _ := strconv.ParseFloat(order.CumExecQty, 64)
+	updated, _ := strconv.ParseInt(order.UpdatedTime, 10, 64)
+
+	status, ok := bybitOrderStatuses[order.OrderStatus]
+	if !ok {
+		status = strings.ToUpper(order.OrderStatus)
+	}
+
+	return &ExchangeOrder{
+		Venue:         VenueBybit,
+		Symbol:        order.Symbol,
+		OrderID:       order.OrderID,
+		ClientOrderID: order.OrderLinkID,
+		Side:          strings.ToUpper(order.Side),
+		Type:          strings.ToUpper(order.OrderType),
+		Status:        status,
+		Price:         price,
+		AvgPrice:      avgPrice,
+		StopPrice:     triggerPrice,
+		Quantity:      qty,
+		ExecutedQty:   executed,
+		UpdateTime:    updated,
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bybitStandIn serves canned v5 market responses and counts the requests per
+// endpoint and symbol
+type bybitStandIn struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newBybitStandIn(t *testing.T, results map[string]string) *bybitStandIn {
+	standIn := &bybitStandIn{requests: map[string]int{}}
+	standIn.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path + "?" + r.URL.Query().Get("symbol")
+		standIn.mu.Lock()
+		standIn.requests[key]++
+		standIn.mu.Unlock()
+
+		result, ok := results[key]
+		if !ok {
+			fmt.Fprint(w, `{"retCode":10001,"retMsg":"params error: symbol invalid","result":{}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"retCode":0,"retMsg":"OK","result":%s}`, result)
+	}))
+	t.Cleanup(standIn.server.Close)
+	t.Setenv("BYBIT_MAINNET_URL", standIn.server.URL)
+	return standIn
+}
+
+func (s *bybitStandIn) count(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[key]
+}
+
+func bybitInstrument(symbol, base string) string {
+	return fmt.Sprintf(`{"list":[{"symbol":%q,"contractType":"LinearPerpetual","status":"Trading","baseCoin":%q,
+		"quoteCoin":"USDT","settleCoin":"USDT","launchTime":"1585526400000","priceScale":"2",
+		"priceFilter":{"minPrice":"0.10","maxPrice":"1999999.80","tickSize":"0.10"},
+		"lotSizeFilter":{"maxOrderQty":"1190.000","maxMktOrderQty":"119.000","minOrderQty":"0.001","qtyStep":"0.001","minNotionalValue":"5"}}]}`,
+		symbol, base)
+}
+
+func TestBybitSymbolInfo(t *testing.T) {
+	newBybitStandIn(t, map[string]string{
+		"/v5/market/instruments-info?BTCUSDT": bybitInstrument("BTCUSDT", "BTC"),
+	})
+	exchange := NewBybitExchange(false)
+
+	info, err := exchange.GetSymbolInfo("btcusdt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := SymbolInfo{
+		Symbol:            "BTCUSDT",
+		Pair:              "BTCUSDT",
+		ContractType:      "PERPETUAL",
+		Status:            "TRADING",
+		BaseAsset:         "BTC",
+		QuoteAsset:        "USDT",
+		MarginAsset:       "USDT",
+		PricePrecision:    2,
+		QuantityPrecision: 3,
+		OnboardDate:       1585526400000,
+		TickSize:          0.1,
+		MinPrice:          0.1,
+		MaxPrice:          1999999.8,
+		TickDecimals:      1,
+		StepSize:          0.001,
+		MinQty:            0.001,
+		MaxQty:            1190,
+		StepDecimals:      3,
+		MarketStepSize:    0.001,
+		MarketMinQty:      0.001,
+		MarketMaxQty:      119,
+		MinNotional:       5,
+	}
+	if *info != want {
+		t.Fatalf("parsed rules\n%+v\nwant\n%+v", *info, want)
+	}
+
+	// Bybit rules go through the same validation as Binance's
+	tests := []struct {
+		name     string
+		quantity float64
+		isMarket bool
+		wantErr  bool
+	}{
+		{"within the limits", 0.01, false, false},
+		{"notional below minNotionalValue", 0.001, false, true},
+		{"limit order above maxMktOrderQty", 500, false, false},
+		{"market order above maxMktOrderQty", 500, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := info.ValidateOrder(tt.quantity, 3000, 0, tt.isMarket)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateOrder error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var apiErr *bybitAPIError
+	if _, err := exchange.GetSymbolInfo("NOPEUSDT"); !errors.As(err, &apiErr) || apiErr.Code != 10001 {
+		t.Fatalf("an unknown symbol should surface the API error, got %v", err)
+	}
+}
+
+func TestBybitSymbolInfoCachedPerSymbol(t *testing.T) {
+	standIn := newBybitStandIn(t, map[string]string{
+		"/v5/market/instruments-info?BTCUSDT": bybitInstrument("BTCUSDT", "BTC"),
+		"/v5/market/instruments-info?ETHUSDT": bybitInstrument("ETHUSDT", "ETH"),
+	})
+	exchange := NewBybitExchange(false)
+
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "BTCUSDT", "ETHUSDT"} {
+		if _, err := exchange.GetSymbolInfo(symbol); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		if n := standIn.count("/v5/market/instruments-info?" + symbol); n != 1 {
+			t.Fatalf("%s fetched %d times, want 1", symbol, n)
+		}
+	}
+
+	// An expired entry is refetched without touching the other symbols
+	bybitInstrumentsMu.Lock()
+	cache := bybitInstruments[exchange.baseURL]
+	bybitInstrumentsMu.Unlock()
+	cache.mu.Lock()
+	stale := cache.symbols["BTCUSDT"]
+	stale.fetchedAt = time.Now().Add(-2 * defaultExchangeInfoTTL)
+	cache.symbols["BTCUSDT"] = stale
+	cache.mu.Unlock()
+
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		if _, err := exchange.GetSymbolInfo(symbol); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := standIn.count("/v5/market/instruments-info?BTCUSDT"); n != 2 {
+		t.Errorf("expired BTCUSDT fetched %d times, want 2", n)
+	}
+	if n := standIn.count("/v5/market/instruments-info?ETHUSDT"); n != 1 {
+		t.Errorf("fresh ETHUSDT fetched %d times, want 1", n)
+	}
+}
+
+func TestBybitOrderStatus(t *testing.T) {
+	tests := map[string]string{
+		"New":                     "NEW",
+		"PartiallyFilled":         "PARTIALLY_FILLED",
+		"Filled":                  "FILLED",
+		"PartiallyFilledCanceled": "CANCELED",
+		"Untriggered":             "NEW",
+		"SomethingNew":            "SOMETHINGNEW",
+	}
+	for bybitStatus, want := range tests {
+		order := bybitOrderToExchange(&bybitOrder{OrderStatus: bybitStatus, Qty: "1.5", CumExecQty: "0.5"})
+		if order.Status != want {
+			t.Errorf("status %s mapped to %s, want %s", bybitStatus, order.Status, want)
+		}
+		if order.Quantity != 1.5 || order.ExecutedQty != 0.5 {
+			t.Errorf("quantities parsed as %g/%g, want 1.5/0.5", order.Quantity, order.ExecutedQty)
+		}
+	}
+}
+
+func TestBybitLeverageBrackets(t *testing.T) {
+	newBybitStandIn(t, map[string]string{
+		"/v5/market/risk-limit?BTCUSDT": `{"list":[
+			{"riskLimitValue":"4000000","maintenanceMargin":"0.01","maxLeverage":"50.00","mmDeduction":"20000"},
+			{"riskLimitValue":"2000000","maintenanceMargin":"0.005","maxLeverage":"100.00","mmDeduction":""},
+			{"riskLimitValue":"6000000","maintenanceMargin":"0.015","maxLeverage":"33.33","mmDeduction":""}
+		]}`,
+	})
+	exchange := NewBybitExchange(false)
+
+	brackets, err := exchange.GetLeverageBrackets("BTCUSDT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Tiers are sorted by their cap, and a missing deduction keeps the
+	// maintenance margin continuous at the previous cap: 20000 + 4M * 0.005
+	want := []LeverageBracket{
+		{Bracket: 1, InitialLeverage: 100, NotionalFloor: 0, NotionalCap: 2000000, MaintMarginRatio: 0.005, Cum: 0},
+		{Bracket: 2, InitialLeverage: 50, NotionalFloor: 2000000, NotionalCap: 4000000, MaintMarginRatio: 0.01, Cum: 20000},
+		{Bracket: 3, InitialLeverage: 33, NotionalFloor: 4000000, NotionalCap: 6000000, MaintMarginRatio: 0.015, Cum: 40000},
+	}
+	if len(brackets) != len(want) {
+		t.Fatalf("got %d brackets, want %d", len(brackets), len(want))
+	}
+	for i := range want {
+		if brackets[i] != want[i] {
+			t.Errorf("bracket %d = %+v, want %+v", i+1, brackets[i], want[i])
+		}
+	}
+
+	if _, err := exchange.GetLeverageBrackets("NOPEUSDT"); err == nil {
+		t.Error("an unknown symbol should be an error")
+	}
+}
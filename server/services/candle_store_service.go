@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"saturday-autotrade/config"
+	"saturday-autotrade/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxKlinesPerRequest is the largest page /fapi/v1/klines will return
+const maxKlinesPerRequest = 1500
+
+const (
+	// maxDerivedSourceCandles caps the stored candles read to build one derived series
+	maxDerivedSourceCandles = 5000
+	// renkoSourceFactor is how many source candles are read per requested brick,
+	// since a candle rarely completes a brick on its own
+	renkoSourceFactor = 4
+)
+
+// ErrInvalidCandleRange is returned for a backfill whose start is not before its end
+var ErrInvalidCandleRange = errors.New("startTime must be before endTime")
+
+// CandleStoreService persists candles in MongoDB and backfills missing history from Binance
+type CandleStoreService struct {
+	binanceService *BinanceService
+	collection     *mongo.Collection
+}
+
+var intervalDurations = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+	"1M":  31 * 24 * time.Hour, // upper bound; months are not a fixed length
+}
+
+// IntervalDuration returns the length of one candle for a Binance interval
+func IntervalDuration(interval string) (time.Duration, error) {
+	d, ok := intervalDurations[interval]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s is not a Binance interval", ErrUnsupportedTimeframe, interval)
+	}
+	return d, nil
+}
+
+func NewCandleStoreService(binanceService *BinanceService) *CandleStoreService {
+	store := &CandleStoreService{
+		binanceService: binanceService,
+		collection:     config.DB.Collection("candles"),
+	}
+
+	if err := store.ensureIndexes(); err != nil {
+		log.Printf("CandleStore: Failed to create indexes: %v", err)
+	}
+
+	return store
+}
+
+func (s *CandleStoreService) ensureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "interval", Value: 1}, {Key: "openTime", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// SaveKlines upserts candles keyed by symbol/interval/openTime
+func (s *CandleStoreService) SaveKlines(symbol, interval string, klines []Kline) (int, error) {
+	if len(klines) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(klines))
+	for _, k := range klines {
+		if k.OpenTime == 0 {
+			continue
+		}
+		candle := klineToCandle(symbol, interval, k)
+		candle.UpdatedAt = now
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"symbol": symbol, "interval": interval, "openTime": k.OpenTime}).
+			SetUpdate(bson.M{"$set": candle}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := s.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to save candles: %w", err)
+	}
+
+	return int(result.UpsertedCount + result.ModifiedCount), nil
+}
+
+// GetCandles returns stored candles whose open time falls within [start, end], oldest first
+func (s *CandleStoreService) GetCandles(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"symbol":   symbol,
+		"interval": interval,
+		"openTime": bson.M{"$gte": start.UnixMilli(), "$lte": end.UnixMilli()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "openTime", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve candles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var candles []models.Candle
+	if err = cursor.All(ctx, &candles); err != nil {
+		return nil, fmt.Errorf("failed to decode candles: %w", err)
+	}
+
+	klines := make([]Kline, len(candles))
+	for i, c := range candles {
+		klines[i] = candleToKline(c)
+	}
+
+	return klines, nil
+}
+
+// GetRecentCandles returns the latest limit candles from the store, refreshing the
+// still-forming candles and backfilling any gaps in the window first
+func (s *CandleStoreService) GetRecentCandles(symbol, interval string, limit int) ([]Kline, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.binanceService.GetKlines(symbol, interval, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest candles: %w", err)
+	}
+	if _, err := s.SaveKlines(symbol, interval, latest); err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	start := end.Add(-step * time.Duration(limit))
+	if _, err := s.Backfill(symbol, interval, start, end); err != nil {
+		return nil, err
+	}
+
+	klines, err := s.GetCandles(symbol, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+
+	return klines, nil
+}
+
+// GetRecentSeries returns the latest limit candles of a timeframe, which may be a
+// native Binance interval or a series derived locally from stored candles (see
+// ParseTimeframe)
+func (s *CandleStoreService) GetRecentSeries(symbol, timeframe string, limit int) ([]Kline, error) {
+	spec, err := ParseTimeframe(timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceLimit := limit
+	if spec.Resample > 0 {
+		// One extra bucket, since the oldest one is usually partial and dropped
+		sourceLimit = (limit + 1) * int(spec.Resample/intervalDurations[spec.Base])
+	}
+	if spec.Transform == TransformRenko {
+		sourceLimit *= renkoSourceFactor
+	}
+	if sourceLimit > maxDerivedSourceCandles {
+		return nil, fmt.Errorf("%w: %s needs %d %s candles, more than the %d allowed", ErrUnsupportedTimeframe, timeframe, sourceLimit, spec.Base, maxDerivedSourceCandles)
+	}
+
+	klines, err := s.GetRecentCandles(symbol, spec.Base, sourceLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Resample > 0 {
+		klines = Resample(klines, spec.Resample)
+	}
+	switch spec.Transform {
+	case TransformHeikinAshi:
+		klines = HeikinAshi(klines)
+	case TransformRenko:
+		box := spec.BoxSize
+		if box <= 0 {
+			if box, err = renkoBoxSize(klines); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkRenkoBox(box, klines); err != nil {
+			return nil, err
+		}
+		klines = Renko(klines, box, limit)
+	}
+
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// Backfill pages through /fapi/v1/klines to fill every gap in the stored candles between start and end.
+// It returns the number of candles written.
+func (s *CandleStoreService) Backfill(symbol, interval string, start, end time.Time) (int, error) {
+	gaps, err := s.findGaps(symbol, interval, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for _, gap := range gaps {
+		cursor := gap[0]
+		for cursor <= gap[1] {
+			klines, err := s.binanceService.GetKlinesRange(symbol, interval, cursor, gap[1], maxKlinesPerRequest)
+			if err != nil {
+				return stored, fmt.Errorf("failed to backfill %s %s: %w", symbol, interval, err)
+			}
+			if len(klines) == 0 {
+				break
+			}
+
+			n, err := s.SaveKlines(symbol, interval, klines)
+			if err != nil {
+				return stored, err
+			}
+			stored += n
+
+			next := klines[len(klines)-1].CloseTime + 1
+			if next <= cursor {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	return stored, nil
+}
+
+// findGaps returns the [from, to] open-time ranges (Unix ms) in the window that have
+// no final stored candle. A candle saved before its close time was still forming
+// and holds partial OHLCV, so it counts as missing until it is saved again.
+func (s *CandleStoreService) findGaps(symbol, interval string, start, end time.Time) ([][2]int64, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	stepMs := step.Milliseconds()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"symbol":   symbol,
+		"interval": interval,
+		"openTime": bson.M{"$gte": start.UnixMilli(), "$lte": end.UnixMilli()},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "openTime", Value: 1}}).
+		SetProjection(bson.M{"openTime": 1, "closeTime": 1, "updatedAt": 1})
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan candles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stored []models.Candle
+	if err = cursor.All(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode candles: %w", err)
+	}
+
+	var gaps [][2]int64
+	expected := start.UnixMilli()
+	for _, c := range stored {
+		if c.UpdatedAt.UnixMilli() <= c.CloseTime {
+			continue
+		}
+		if c.OpenTime-expected >= stepMs {
+			gaps = append(gaps, [2]int64{expected, c.OpenTime - 1})
+		}
+		expected = c.CloseTime + 1
+	}
+	if end.UnixMilli()-expected >= stepMs {
+		gaps = append(gaps, [2]int64{expected, end.UnixMilli()})
+	}
+
+	return gaps, nil
+}
+
+func klineToCandle(symbol, interval string, k Kline) models.Candle {
+	return models.Candle{
+		Symbol:                   symbol,
+		Interval:                 interval,
+		OpenTime:                 k.OpenTime,
+		Open:                     k.Open,
+		High:                     k.High,
+		Low:                      k.Low,
+		Close:                    k.Close,
+		Volume:                   k.Volume,
+		CloseTime:                k.CloseTime,
+		QuoteAssetVolume:         k.QuoteAssetVolume,
+		NumberOfTrades:           k.NumberOfTrades,
+		TakerBuyBaseAssetVolume:  k.TakerBuyBaseAssetVolume,
+		TakerBuyQuoteAssetVolume: k.TakerBuyQuoteAssetVolume,
+	}
+}
+
+func candleToKline(c models.Candle) Kline {
+	return Kline{
+		OpenTime:                 c.OpenTime,
+		Open:                     c.Open,
+		High:                     c.High,
+		Low:                      c.Low,
+		Close:                    c.Close,
+		Volume:                   c.Volume,
+		CloseTime:                c.CloseTime,
+		QuoteAssetVolume:         c.QuoteAssetVolume,
+		NumberOfTrades:           c.NumberOfTrades,
+		TakerBuyBaseAssetVolume:  c.TakerBuyBaseAssetVolume,
+		TakerBuyQuoteAssetVolume: c.TakerBuyQuoteAssetVolume,
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"saturday-autotrade/indicators"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Candle transforms applied after fetching or resampling
+const (
+	TransformHeikinAshi = "ha"
+	TransformRenko      = "renko"
+)
+
+// ErrUnsupportedTimeframe is wrapped by every ParseTimeframe error
+var ErrUnsupportedTimeframe = errors.New("unsupported timeframe")
+
+// minRenkoBoxFraction is the smallest Renko box accepted, relative to the last
+// close; smaller boxes would turn every tick of movement into thousands of bricks
+const minRenkoBoxFraction = 1e-4
+
+// resampleBases are the native intervals a custom interval can be built from,
+// longest first so the fewest source candles are fetched
+var resampleBases = []string{"1d", "12h", "8h", "6h", "4h", "2h", "1h", "30m", "15m", "5m", "3m", "1m"}
+
+// TimeframeSpec describes how the candles of a requested timeframe are produced.
+// Accepted forms:
+//
+//	1h              a native Binance interval
+//	45m, 3h, 2d     a custom interval, resampled from the longest native interval dividing it
+//	4h@15m          an interval resampled from an explicit native base
+//	ha:<tf>         Heikin-Ashi candles of any of the above
+//	renko:<tf>      Renko bricks sized by ATR(14) of the source candles
+//	renko:<tf>:<n>  Renko bricks of n price units
+type TimeframeSpec struct {
+	Name      string        // as requested; used to label the series
+	Interval  string        // candle length before any transform, e.g. "4h"
+	Base      string        // native interval read from the candle store
+	Resample  time.Duration // bucket length when resampling, 0 when Base is used as is
+	Transform string        // "", TransformHeikinAshi or TransformRenko
+	BoxSize   float64       // Renko brick size, 0 to derive it from ATR
+}
+
+// ParseTimeframe validates a timeframe and works out how to build it
+func ParseTimeframe(timeframe string) (TimeframeSpec, error) {
+	spec := TimeframeSpec{Name: timeframe}
+	rest := strings.TrimSpace(timeframe)
+
+	if prefix, tail, ok := strings.Cut(rest, ":"); ok {
+		switch strings.ToLower(prefix) {
+		case TransformHeikinAshi:
+			spec.Transform = TransformHeikinAshi
+			rest = tail
+		case TransformRenko:
+			spec.Transform = TransformRenko
+			rest = tail
+			if tf, box, ok := strings.Cut(tail, ":"); ok {
+				size, err := strconv.ParseFloat(box, 64)
+				if err != nil || size <= 0 {
+					return spec, fmt.Errorf("%w: invalid renko box size in %s", ErrUnsupportedTimeframe, timeframe)
+				}
+				spec.BoxSize = size
+				rest = tf
+			}
+		default:
+			return spec, fmt.Errorf("%w: unknown candle transform %s in %s", ErrUnsupportedTimeframe, prefix, timeframe)
+		}
+	}
+
+	target, base, explicitBase := strings.Cut(rest, "@")
+	spec.Interval = target
+
+	if !explicitBase {
+		if _, ok := intervalDurations[target]; ok {
+			spec.Base = target
+			return spec, nil
+		}
+	}
+
+	length, err := parseCustomInterval(target)
+	if err != nil {
+		return spec, fmt.Errorf("%w %s: %v", ErrUnsupportedTimeframe, timeframe, err)
+	}
+	spec.Resample = length
+
+	if explicitBase {
+		step, ok := intervalDurations[base]
+		if !ok || !isResampleBase(base) {
+			return spec, fmt.Errorf("%w: unsupported base interval %s in %s", ErrUnsupportedTimeframe, base, timeframe)
+		}
+		if length <= step || length%step != 0 {
+			return spec, fmt.Errorf("%w: %s is not a multiple of %s", ErrUnsupportedTimeframe, target, base)
+		}
+		spec.Base = base
+		return spec, nil
+	}
+
+	for _, candidate := range resampleBases {
+		if step := intervalDurations[candidate]; step < length && length%step == 0 {
+			spec.Base = candidate
+			return spec, nil
+		}
+	}
+	return spec, fmt.Errorf("%w %s: not a whole number of minutes", ErrUnsupportedTimeframe, timeframe)
+}
+
+// Describe explains a derived series for prompts; native intervals return ""
+func (t TimeframeSpec) Describe() string {
+	desc := ""
+	if t.Resample > 0 {
+		desc = fmt.Sprintf("%s candles resampled from %s", t.Interval, t.Base)
+	}
+	switch t.Transform {
+	case TransformHeikinAshi:
+		desc = "Heikin-Ashi candles of " + t.Interval
+	case TransformRenko:
+		desc = "Renko bricks built from " + t.Interval + " closes"
+		if t.BoxSize > 0 {
+			desc += fmt.Sprintf(", box size %g", t.BoxSize)
+		} else {
+			desc += ", box size ATR(14)"
+		}
+		desc += "; bricks are not evenly spaced in time"
+	}
+	return desc
+}
+
+// parseCustomInterval reads intervals such as 45m, 3h or 2d
+func parseCustomInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute, nil
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid interval unit in %q, use m, h or d", interval)
+}
+
+func isResampleBase(interval string) bool {
+	for _, b := range resampleBases {
+		if b == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// Resample aggregates candles into buckets of the given length aligned to the UTC
+// epoch, so 4h buckets open at 00:00, 04:00 and so on. A leading bucket missing
+// its first candles is dropped; the last bucket may still be forming, like the
+// last candle Binance returns.
+func Resample(klines []Kline, length time.Duration) []Kline {
+	lengthMs := length.Milliseconds()
+	if lengthMs <= 0 || len(klines) == 0 {
+		return nil
+	}
+
+	var out []Kline
+	for _, k := range klines {
+		bucket := k.OpenTime - k.OpenTime%lengthMs
+		if n := len(out); n > 0 && out[n-1].OpenTime == bucket {
+			c := &out[n-1]
+			c.High = math.Max(c.High, k.High)
+			c.Low = math.Min(c.Low, k.Low)
+			c.Close = k.Close
+			c.Volume += k.Volume
+			c.QuoteAssetVolume += k.QuoteAssetVolume
+			c.NumberOfTrades += k.NumberOfTrades
+			c.TakerBuyBaseAssetVolume += k.TakerBuyBaseAssetVolume
+			c.TakerBuyQuoteAssetVolume += k.TakerBuyQuoteAssetVolume
+			continue
+		}
+		if len(out) == 0 && k.OpenTime != bucket {
+			continue
+		}
+		out = append(out, Kline{
+			OpenTime:                 bucket,
+			Open:                     k.Open,
+			High:                     k.High,
+			Low:                      k.Low,
+			Close:                    k.Close,
+			Volume:                   k.Volume,
+			CloseTime:                bucket + lengthMs - 1,
+			QuoteAssetVolume:         k.QuoteAssetVolume,
+			NumberOfTrades:           k.NumberOfTrades,
+			TakerBuyBaseAssetVolume:  k.TakerBuyBaseAssetVolume,
+			TakerBuyQuoteAssetVolume: k.TakerBuyQuoteAssetVolume,
+		})
+	}
+	return out
+}
+
+// HeikinAshi returns Heikin-Ashi candles; volume and trade fields are kept as is
+func HeikinAshi(klines []Kline) []Kline {
+	out := make([]Kline, len(klines))
+	for i, k := range klines {
+		ha := k
+		ha.Close = (k.Open + k.High + k.Low + k.Close) / 4
+		if i == 0 {
+			ha.Open = (k.Open + k.Close) / 2
+		} else {
+			ha.Open = (out[i-1].Open + out[i-1].Close) / 2
+		}
+		ha.High = math.Max(k.High, math.Max(ha.Open, ha.Close))
+		ha.Low = math.Min(k.Low, math.Min(ha.Open, ha.Close))
+		out[i] = ha
+	}
+	return out
+}
+
+// Renko returns the newest limit close-based Renko bricks of the given size. A
+// brick in the same direction needs one box of movement and a reversal needs two.
+// Each brick takes the times of the candle that completed it, and the volume
+// traded since the previous brick is credited to the first brick a candle
+// completes. Older bricks are dropped while building, so memory stays bounded by
+// limit however small the box is.
+func Renko(klines []Kline, box float64, limit int) []Kline {
+	if box <= 0 || limit <= 0 || len(klines) == 0 {
+		return nil
+	}
+
+	var bricks []Kline
+	var pending Kline
+	anchor := klines[0].Close // top of the last up brick or bottom of the last down brick
+	direction := 0
+
+	for _, k := range klines {
+		pending.Volume += k.Volume
+		pending.QuoteAssetVolume += k.QuoteAssetVolume
+		pending.NumberOfTrades += k.NumberOfTrades
+		pending.TakerBuyBaseAssetVolume += k.TakerBuyBaseAssetVolume
+		pending.TakerBuyQuoteAssetVolume += k.TakerBuyQuoteAssetVolume
+
+		for {
+			open, close, ok := nextRenkoBrick(anchor, box, direction, k.Close)
+			if !ok {
+				break
+			}
+
+			brick := pending
+			pending = Kline{}
+			brick.OpenTime, brick.CloseTime = k.OpenTime, k.CloseTime
+			brick.Open, brick.Close = open, close
+			brick.High, brick.Low = math.Max(open, close), math.Min(open, close)
+			bricks = append(bricks, brick)
+			if len(bricks) >= 2*limit {
+				bricks = append(bricks[:0], bricks[len(bricks)-limit:]...)
+			}
+
+			anchor = close
+			if close > open {
+				direction = 1
+			} else {
+				direction = -1
+			}
+		}
+	}
+	if len(bricks) > limit {
+		bricks = bricks[len(bricks)-limit:]
+	}
+	return bricks
+}
+
+// checkRenkoBox rejects boxes too small for the price of the series
+func checkRenkoBox(box float64, klines []Kline) error {
+	if len(klines) == 0 {
+		return nil
+	}
+	if minBox := klines[len(klines)-1].Close * minRenkoBoxFraction; box < minBox {
+		return fmt.Errorf("%w: renko box size %g is below the minimum of %g for the last close", ErrUnsupportedTimeframe, box, minBox)
+	}
+	return nil
+}
+
+// nextRenkoBrick returns the brick a close completes from the anchor, if any
+func nextRenkoBrick(anchor, box float64, direction int, price float64) (float64, float64, bool) {
+	switch {
+	case direction >= 0 && price >= anchor+box:
+		return anchor, anchor + box, true
+	case direction <= 0 && price <= anchor-box:
+		return anchor, anchor - box, true
+	case direction > 0 && price <= anchor-2*box:
+		return anchor - box, anchor - 2*box, true
+	case direction < 0 && price >= anchor+2*box:
+		return anchor + box, anchor + 2*box, true
+	}
+	return 0, 0, false
+}
+
+// renkoBoxSize is ATR(14) of the source candles
+func renkoBoxSize(klines []Kline) (float64, error) {
+	atr := indicators.Last(indicators.ATR(klinesToBars(klines), 14))
+	if math.IsNaN(atr) || atr <= 0 {
+		return 0, fmt.Errorf("not enough candles to size renko bricks")
+	}
+	return atr, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimeframe(t *testing.T) {
+	tests := []struct {
+		timeframe string
+		want      TimeframeSpec
+		wantErr   bool
+	}{
+		{timeframe: "1h", want: TimeframeSpec{Interval: "1h", Base: "1h"}},
+		{timeframe: "45m", want: TimeframeSpec{Interval: "45m", Base: "15m", Resample: 45 * time.Minute}},
+		{timeframe: "3h", want: TimeframeSpec{Interval: "3h", Base: "1h", Resample: 3 * time.Hour}},
+		{timeframe: "2d", want: TimeframeSpec{Interval: "2d", Base: "1d", Resample: 48 * time.Hour}},
+		{timeframe: "4h@15m", want: TimeframeSpec{Interval: "4h", Base: "15m", Resample: 4 * time.Hour}},
+		{timeframe: "ha:1h", want: TimeframeSpec{Interval: "1h", Base: "1h", Transform: TransformHeikinAshi}},
+		{timeframe: "renko:4h", want: TimeframeSpec{Interval: "4h", Base: "4h", Transform: TransformRenko}},
+		{timeframe: "renko:4h:50", want: TimeframeSpec{Interval: "4h", Base: "4h", Transform: TransformRenko, BoxSize: 50}},
+		{timeframe: "renko:1h:-1", wantErr: true},
+		{timeframe: "kagi:1h", wantErr: true},
+		{timeframe: "4h@1w", wantErr: true},   // not a resample base
+		{timeframe: "4h@4h", wantErr: true},   // nothing to resample
+		{timeframe: "45m@30m", wantErr: true}, // not a multiple
+		{timeframe: "90s", wantErr: true},
+		{timeframe: "0h", wantErr: true},
+		{timeframe: "h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			got, err := ParseTimeframe(tt.timeframe)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedTimeframe) {
+					t.Fatalf("error = %v, want %v", err, ErrUnsupportedTimeframe)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.want.Name = tt.timeframe
+			if got != tt.want {
+				t.Errorf("ParseTimeframe(%q) = %+v, want %+v", tt.timeframe, got, tt.want)
+			}
+		})
+	}
+}
+
+// candle is the price and volume part of a Kline, for comparisons
+type candle struct {
+	OpenTime, CloseTime    int64
+	Open, High, Low, Close float64
+	Volume                 float64
+}
+
+func candlesOf(klines []Kline) []candle {
+	out := make([]candle, len(klines))
+	for i, k := range klines {
+		out[i] = candle{k.OpenTime, k.CloseTime, k.Open, k.High, k.Low, k.Close, k.Volume}
+	}
+	return out
+}
+
+func checkCandles(t *testing.T, name string, got []Kline, want []candle) {
+	t.Helper()
+	candles := candlesOf(got)
+	if len(candles) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", name, candles, want)
+	}
+	for i := range want {
+		if candles[i] != want[i] {
+			t.Errorf("%s candle %d = %+v, want %+v", name, i, candles[i], want[i])
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	// One-minute candles from minute 3 to 11, each a unit either side of its open
+	var klines []Kline
+	for i := 3; i <= 11; i++ {
+		f := float64(i)
+		open := int64(i) * 60000
+		klines = append(klines, Kline{OpenTime: open, CloseTime: open + 59999, Open: f, High: f + 1, Low: f - 1, Close: f + 0.5, Volume: 1})
+	}
+
+	// The bucket at minute 0 is missing its first candles and is dropped; the one
+	// at minute 10 is still forming
+	checkCandles(t, "Resample", Resample(klines, 5*time.Minute), []candle{
+		{OpenTime: 300000, CloseTime: 599999, Open: 5, High: 10, Low: 4, Close: 9.5, Volume: 5},
+		{OpenTime: 600000, CloseTime: 899999, Open: 10, High: 12, Low: 9, Close: 11.5, Volume: 2},
+	})
+
+	if got := Resample(klines, 0); got != nil {
+		t.Errorf("a zero length should give nothing, got %+v", got)
+	}
+}
+
+func TestHeikinAshi(t *testing.T) {
+	klines := []Kline{
+		{Open: 10, High: 12, Low: 8, Close: 11, Volume: 3},
+		{Open: 11, High: 13, Low: 10, Close: 12, Volume: 4},
+	}
+
+	// The first open is the candle's own mid-body, later opens the previous
+	// Heikin-Ashi mid-body
+	checkCandles(t, "HeikinAshi", HeikinAshi(klines), []candle{
+		{Open: 10.5, High: 12, Low: 8, Close: 10.25, Volume: 3},
+		{Open: 10.375, High: 13, Low: 10, Close: 11.5, Volume: 4},
+	})
+}
+
+// closesAt builds one-minute candles with a unit of volume at the given closes
+func closesAt(closes ...float64) []Kline {
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		open := int64(i) * 60000
+		klines[i] = Kline{OpenTime: open, CloseTime: open + 59999, Open: c, High: c, Low: c, Close: c, Volume: 1}
+	}
+	return klines
+}
+
+func TestRenko(t *testing.T) {
+	rising := make([]float64, 21)
+	for i := range rising {
+		rising[i] = float64(i)
+	}
+
+	tests := []struct {
+		name   string
+		klines []Kline
+		box    float64
+		limit  int
+		want   []candle
+	}{
+		{
+			// 101 is short of a box and 103 short of a reversal, so their volume
+			// rides along with the next brick
+			name:   "a reversal needs two boxes",
+			klines: closesAt(100, 101, 102, 105, 103, 99),
+			box:    2,
+			limit:  10,
+			want: []candle{
+				{OpenTime: 120000, CloseTime: 179999, Open: 100, High: 102, Low: 100, Close: 102, Volume: 3},
+				{OpenTime: 180000, CloseTime: 239999, Open: 102, High: 104, Low: 102, Close: 104, Volume: 1},
+				{OpenTime: 300000, CloseTime: 359999, Open: 102, High: 102, Low: 100, Close: 100, Volume: 2},
+			},
+		},
+		{
+			name:   "a gap completes several bricks, the first taking the volume",
+			klines: closesAt(100, 107),
+			box:    2,
+			limit:  10,
+			want: []candle{
+				{OpenTime: 60000, CloseTime: 119999, Open: 100, High: 102, Low: 100, Close: 102, Volume: 2},
+				{OpenTime: 60000, CloseTime: 119999, Open: 102, High: 104, Low: 102, Close: 104},
+				{OpenTime: 60000, CloseTime: 119999, Open: 104, High: 106, Low: 104, Close: 106},
+			},
+		},
+		{
+			name:   "only the newest bricks are kept",
+			klines: closesAt(rising...),
+			box:    1,
+			limit:  3,
+			want: []candle{
+				{OpenTime: 1080000, CloseTime: 1139999, Open: 17, High: 18, Low: 17, Close: 18, Volume: 1},
+				{OpenTime: 1140000, CloseTime: 1199999, Open: 18, High: 19, Low: 18, Close: 19, Volume: 1},
+				{OpenTime: 1200000, CloseTime: 1259999, Open: 19, High: 20, Low: 19, Close: 20, Volume: 1},
+			},
+		},
+		{name: "no limit", klines: closesAt(100, 110), box: 2},
+		{name: "no box", klines: closesAt(100, 110), limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkCandles(t, "Renko", Renko(tt.klines, tt.box, tt.limit), tt.want)
+		})
+	}
+}
+
+func TestCheckRenkoBox(t *testing.T) {
+	klines := closesAt(49000, 50000)
+
+	if err := checkRenkoBox(4, klines); !errors.Is(err, ErrUnsupportedTimeframe) {
+		t.Errorf("a box below 1e-4 of the last close should be rejected, got %v", err)
+	}
+	if err := checkRenkoBox(5, klines); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkRenkoBox(0.01, nil); err != nil {
+		t.Errorf("no candles should not be an error, got %v", err)
+	}
+}
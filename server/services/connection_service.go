@@ -13,10 +13,15 @@ type ConnectionService struct {
 }
 
 type ConnectionStatus struct {
-	Binance     bool      `json:"binance"`
-	OpenAI      bool      `json:"openai"`
-	Database    bool      `json:"database"`
-	LastChecked time.Time `json:"lastChecked"`
+	Binance      bool               `json:"binance"`
+	OpenAI       bool               `json:"openai"`
+	Database     bool               `json:"database"`
+	MarketStream MarketStreamStatus `json:"marketStream"`
+	RateLimits   []RequestBudget    `json:"rateLimits"`
+	// UnrecordedLLMExchanges counts exchanges that failed to be stored, whose
+	// cost is missing from the spend report
+	UnrecordedLLMExchanges int64     `json:"unrecordedLlmExchanges"`
+	LastChecked            time.Time `json:"lastChecked"`
 }
 
 func NewConnectionService() *ConnectionService {
@@ -86,10 +91,14 @@ func (cs *ConnectionService) GetConnectionStatus() *ConnectionStatus {
 	log.Printf("ConnectionService: Getting connection status for all services...")
 
 	status := &ConnectionStatus{
-		Binance:     cs.CheckBinanceConnection(),
-		OpenAI:      cs.CheckOpenAIConnection(),
-		Database:    cs.CheckDatabaseConnection(),
-		LastChecked: time.Now(),
+		Binance:      cs.CheckBinanceConnection(),
+		OpenAI:       cs.CheckOpenAIConnection(),
+		Database:     cs.CheckDatabaseConnection(),
+		MarketStream: cs.binanceService.StreamStatus(),
+		RateLimits:   BinanceRequestBudgets(),
+		LastChecked:  time.Now(),
+
+		UnrecordedLLMExchanges: UnrecordedLLMExchanges(),
 	}
 
 	log.Printf("ConnectionService: Connection status - Binance: %t, OpenAI: %t, Database: %t",
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Supported trading venues
+const (
+	VenueBinance = "binance"
+	VenueBybit   = "bybit"
+)
+
+// ErrMarginModePerAccount is returned by SetMarginMode on venues where the margin
+// mode applies to the whole account, which a single trade must not change
+var ErrMarginModePerAccount = errors.New("margin mode is set per account on this venue")
+
+// Exchange is the venue-neutral interface TradingService uses for market data,
+// account state and order management
+type Exchange interface {
+	Name() string
+	IsTestnet() bool
+	IsConfigured() bool
+
+	// Market data
+	GetPrice(symbol string) (float64, error)
+	GetKlines(symbol, interval string, limit int) ([]Kline, error)
+	GetSymbolInfo(symbol string) (*SymbolInfo, error)
+	GetLeverageBrackets(symbol string) ([]LeverageBracket, error)
+
+	// Account
+	GetBalance(asset string) (*ExchangeBalance, error)
+	GetPositions() ([]ExchangePosition, error)
+	SetLeverage(symbol string, leverage int) error
+	SetMarginMode(symbol, mode string) error // MarginModeIsolated or MarginModeCross
+	GetMarginMode(symbol string) (string, error)
+
+	// Orders
+	PlaceOrder(req *OrderRequest) (*ExchangeOrder, error)
+	CancelOrder(symbol, orderID string) (*ExchangeOrder, error)
+	GetOrder(symbol, orderID string) (*ExchangeOrder, error)
+}
+
+// ExchangeOrder is an order as reported by any venue
+type ExchangeOrder struct {
+	Venue         string  `json:"venue"`
+	Symbol        string  `json:"symbol"`
+	OrderID       string  `json:"orderId"`
+	ClientOrderID string  `json:"clientOrderId"`
+	Side          string  `json:"side"` // BUY or SELL
+	Type          string  `json:"type"`
+	Status        string  `json:"status"`
+	Price         float64 `json:"price"`
+	AvgPrice      float64 `json:"avgPrice"`
+	StopPrice     float64 `json:"stopPrice"`
+	Quantity      float64 `json:"quantity"`
+	ExecutedQty   float64 `json:"executedQty"`
+	UpdateTime    int64   `json:"updateTime"`
+}
+
+// FillPrice returns the average fill price, or the order price if nothing is reported
+func (o *ExchangeOrder) FillPrice() float64 {
+	if o.AvgPrice > 0 {
+		return o.AvgPrice
+	}
+	return o.Price
+}
+
+// ExchangeBalance is the balance of one asset in the futures wallet
+type ExchangeBalance struct {
+	Asset            string  `json:"asset"`
+	WalletBalance    float64 `json:"walletBalance"`
+	AvailableBalance float64 `json:"availableBalance"`
+	UnrealizedProfit float64 `json:"unrealizedProfit"`
+}
+
+// ExchangePosition is an open position on a venue
+type ExchangePosition struct {
+	Symbol        string  `json:"symbol"`
+	Direction     string  `json:"direction"` // LONG or SHORT
+	Size          float64 `json:"size"`
+	EntryPrice    float64 `json:"entryPrice"`
+	UnrealizedPnL float64 `json:"unrealizedPnl"`
+	Leverage      int     `json:"leverage"`
+}
+
+// NormalizeVenue maps an empty or mixed-case venue name to its canonical form.
+// Records created before venues existed have no venue and belong to Binance.
+func NormalizeVenue(venue string) string {
+	venue = strings.ToLower(strings.TrimSpace(venue))
+	if venue == "" {
+		return VenueBinance
+	}
+	return venue
+}
+
+// NewExchange returns the implementation for a venue
+func NewExchange(venue string, isTestnet bool) (Exchange, error) {
+	switch NormalizeVenue(venue) {
+	case VenueBinance:
+		return NewBinanceExchange(isTestnet), nil
+	case VenueBybit:
+		return NewBybitExchange(isTestnet), nil
+	default:
+		return nil, fmt.Errorf("unsupported venue: %s", venue)
+	}
+}
+
+// ExchangeFactory builds the Exchange for a venue and network
+type ExchangeFactory func(venue string, isTestnet bool) (Exchange, error)
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultExchangeInfoTTL is how long a downloaded exchangeInfo payload is trusted
+const defaultExchangeInfoTTL = 30 * time.Minute
+
+// SymbolInfo holds the trading rules of a futures symbol from /fapi/v1/exchangeInfo
+type SymbolInfo struct {
+	Symbol            string `json:"symbol"`
+	Pair              string `json:"pair"`
+	ContractType      string `json:"contractType"`
+	Status            string `json:"status"`
+	BaseAsset         string `json:"baseAsset"`
+	QuoteAsset        string `json:"quoteAsset"`
+	MarginAsset       string `json:"marginAsset"`
+	PricePrecision    int    `json:"pricePrecision"`
+	QuantityPrecision int    `json:"quantityPrecision"`
+	OnboardDate       int64  `json:"onboardDate"`
+
+	// PRICE_FILTER
+	TickSize     float64 `json:"tickSize"`
+	MinPrice     float64 `json:"minPrice"`
+	MaxPrice     float64 `json:"maxPrice"`
+	TickDecimals int     `json:"tickDecimals"`
+
+	// LOT_SIZE
+	StepSize     float64 `json:"stepSize"`
+	MinQty       float64 `json:"minQty"`
+	MaxQty       float64 `json:"maxQty"`
+	StepDecimals int     `json:"stepDecimals"`
+
+	// MARKET_LOT_SIZE
+	MarketStepSize float64 `json:"marketStepSize"`
+	MarketMinQty   float64 `json:"marketMinQty"`
+	MarketMaxQty   float64 `json:"marketMaxQty"`
+
+	// MIN_NOTIONAL
+	MinNotional float64 `json:"minNotional"`
+
+	// PERCENT_PRICE
+	MultiplierUp      float64 `json:"multiplierUp"`
+	MultiplierDown    float64 `json:"multiplierDown"`
+	MultiplierDecimal int     `json:"multiplierDecimal"`
+}
+
+// ExchangeInfoService caches /fapi/v1/exchangeInfo for one Binance host
+type ExchangeInfoService struct {
+	baseURL string
+	client  *BinanceHTTPClient
+	ttl     time.Duration
+
+	mu        sync.RWMutex
+	symbols   map[string]*SymbolInfo
+	fetchedAt time.Time
+}
+
+var (
+	exchangeInfoMu       sync.Mutex
+	exchangeInfoServices = map[string]*ExchangeInfoService{}
+)
+
+// SharedExchangeInfo returns the process-wide exchange info cache for mainnet or testnet
+func SharedExchangeInfo(isTestnet bool) *ExchangeInfoService {
+	baseURL := futuresBaseURL(isTestnet)
+
+	exchangeInfoMu.Lock()
+	defer exchangeInfoMu.Unlock()
+
+	if svc, ok := exchangeInfoServices[baseURL]; ok {
+		return svc
+	}
+
+	ttl := defaultExchangeInfoTTL
+	if minutes, err := strconv.Atoi(os.Getenv("BINANCE_EXCHANGE_INFO_TTL_MINUTES")); err == nil && minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+
+	svc := NewExchangeInfoService(baseURL, ttl)
+	exchangeInfoServices[baseURL] = svc
+	return svc
+}
+
+func NewExchangeInfoService(baseURL string, ttl time.Duration) *ExchangeInfoService {
+	return &ExchangeInfoService{
+		baseURL: baseURL,
+		client:  SharedBinanceHTTPClient(baseURL),
+		ttl:     ttl,
+		symbols: make(map[string]*SymbolInfo),
+	}
+}
+
+// GetSymbol returns the trading rules for a symbol, refreshing the cache if it is stale
+func (s *ExchangeInfoService) GetSymbol(symbol string) (*SymbolInfo, error) {
+	info, ok, err := s.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("symbol %s not found", symbol)
+	}
+	return info, nil
+}
+
+// Lookup is GetSymbol without treating a missing symbol as an error
+func (s *ExchangeInfoService) Lookup(symbol string) (*SymbolInfo, bool, error) {
+	if err := s.ensureFresh(); err != nil {
+		return nil, false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, ok := s.symbols[strings.ToUpper(symbol)]
+	return info, ok, nil
+}
+
+// Symbols returns the trading rules of every listed symbol
+func (s *ExchangeInfoService) Symbols() ([]SymbolInfo, error) {
+	if err := s.ensureFresh(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	symbols := make([]SymbolInfo, 0, len(s.symbols))
+	for _, info := range s.symbols {
+		symbols = append(symbols, *info)
+	}
+	return symbols, nil
+}
+
+// FetchedAt reports when the cache was last refreshed
+func (s *ExchangeInfoService) FetchedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fetchedAt
+}
+
+func (s *ExchangeInfoService) ensureFresh() error {
+	s.mu.RLock()
+	fresh := len(s.symbols) > 0 && time.Since(s.fetchedAt) < s.ttl
+	s.mu.RUnlock()
+
+	if fresh {
+		return nil
+	}
+	return s.Refresh()
+}
+
+// Refresh downloads exchangeInfo and replaces the cache
+func (s *ExchangeInfoService) Refresh() error {
+	resp, err := s.client.Get(s.baseURL + "/fapi/v1/exchangeInfo")
+	if err != nil {
+		return fmt.Errorf("failed to get exchange info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read exchange info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("binance exchange info error: status %d", resp.StatusCode)
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol            string                   `json:"symbol"`
+			Pair              string                   `json:"pair"`
+			ContractType      string                   `json:"contractType"`
+			Status            string                   `json:"status"`
+			BaseAsset         string                   `json:"baseAsset"`
+			QuoteAsset        string                   `json:"quoteAsset"`
+			MarginAsset       string                   `json:"marginAsset"`
+			PricePrecision    int                      `json:"pricePrecision"`
+			QuantityPrecision int                      `json:"quantityPrecision"`
+			OnboardDate       int64                    `json:"onboardDate"`
+			Filters           []map[string]interface{} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return fmt.Errorf("failed to parse exchange info: %w", err)
+	}
+
+	symbols := make(map[string]*SymbolInfo, len(info.Symbols))
+	for _, raw := range info.Symbols {
+		symbol := &SymbolInfo{
+			Symbol:            raw.Symbol,
+			Pair:              raw.Pair,
+			ContractType:      raw.ContractType,
+			Status:            raw.Status,
+			BaseAsset:         raw.BaseAsset,
+			QuoteAsset:        raw.QuoteAsset,
+			MarginAsset:       raw.MarginAsset,
+			PricePrecision:    raw.PricePrecision,
+			QuantityPrecision: raw.QuantityPrecision,
+			OnboardDate:       raw.OnboardDate,
+		}
+
+		for _, f := range raw.Filters {
+			switch filterString(f, "filterType") {
+			case "PRICE_FILTER":
+				symbol.TickSize = filterFloat(f, "tickSize")
+				symbol.MinPrice = filterFloat(f, "minPrice")
+				symbol.MaxPrice = filterFloat(f, "maxPrice")
+				symbol.TickDecimals = decimalPlaces(filterString(f, "tickSize"))
+			case "LOT_SIZE":
+				symbol.StepSize = filterFloat(f, "stepSize")
+				symbol.MinQty = filterFloat(f, "minQty")
+				symbol.MaxQty = filterFloat(f, "maxQty")
+				symbol.StepDecimals = decimalPlaces(filterString(f, "stepSize"))
+			case "MARKET_LOT_SIZE":
+				symbol.MarketStepSize = filterFloat(f, "stepSize")
+				symbol.MarketMinQty = filterFloat(f, "minQty")
+				symbol.MarketMaxQty = filterFloat(f, "maxQty")
+			case "MIN_NOTIONAL":
+				symbol.MinNotional = filterFloat(f, "notional")
+			case "PERCENT_PRICE":
+				symbol.MultiplierUp = filterFloat(f, "multiplierUp")
+				symbol.MultiplierDown = filterFloat(f, "multiplierDown")
+				symbol.MultiplierDecimal = int(filterFloat(f, "multiplierDecimal"))
+			}
+		}
+
+		symbols[raw.Symbol] = symbol
+	}
+
+	s.mu.Lock()
+	s.symbols = symbols
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// IsTrading reports whether the contract currently accepts orders
+func (i *SymbolInfo) IsTrading() bool {
+	return i.Status == "TRADING"
+}
+
+// RoundPrice rounds a price to the nearest tick
+func (i *SymbolInfo) RoundPrice(price float64) float64 {
+	if i.TickSize <= 0 {
+		return price
+	}
+	rounded := math.Round(price/i.TickSize) * i.TickSize
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(rounded, 'f', i.TickDecimals, 64), 64)
+	return f
+}
+
+// FormatPrice renders a price rounded to the tick size, as Binance expects it
+func (i *SymbolInfo) FormatPrice(price float64) string {
+	if i.TickSize <= 0 {
+		return strconv.FormatFloat(price, 'f', i.PricePrecision, 64)
+	}
+	return strconv.FormatFloat(i.RoundPrice(price), 'f', i.TickDecimals, 64)
+}
+
+// TruncateQuantity rounds a quantity down to the LOT_SIZE (or MARKET_LOT_SIZE) step
+func (i *SymbolInfo) TruncateQuantity(quantity float64, isMarket bool) float64 {
+	step := i.StepSize
+	if isMarket && i.MarketStepSize > 0 {
+		step = i.MarketStepSize
+	}
+	if step <= 0 {
+		return quantity
+	}
+	// The epsilon absorbs float error so that e.g. 0.3/0.1 is not floored to 2
+	truncated := math.Floor(quantity/step+1e-9) * step
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(truncated, 'f', i.StepDecimals, 64), 64)
+	return f
+}
+
+// FormatQuantity renders a quantity truncated to the step size
+func (i *SymbolInfo) FormatQuantity(quantity float64, isMarket bool) string {
+	return strconv.FormatFloat(i.TruncateQuantity(quantity, isMarket), 'f', i.StepDecimals, 64)
+}
+
+// ValidateOrder checks an order against the symbol status and all quantity,
+// notional and price filters. markPrice is only used for PERCENT_PRICE and may be 0.
+func (i *SymbolInfo) ValidateOrder(quantity, price, markPrice float64, isMarket bool) error {
+	if !i.IsTrading() {
+		return fmt.Errorf("%s is not trading (status %s)", i.Symbol, i.Status)
+	}
+
+	minQty, maxQty := i.MinQty, i.MaxQty
+	if isMarket && i.MarketMinQty > 0 {
+		minQty, maxQty = i.MarketMinQty, i.MarketMaxQty
+	}
+	if quantity < minQty {
+		return fmt.Errorf("quantity %s is less than the minimum allowed %s for %s", i.FormatQuantity(quantity, isMarket), strconv.FormatFloat(minQty, 'f', -1, 64), i.Symbol)
+	}
+	if maxQty > 0 && quantity > maxQty {
+		return fmt.Errorf("quantity %s is more than the maximum allowed %s for %s", i.FormatQuantity(quantity, isMarket), strconv.FormatFloat(maxQty, 'f', -1, 64), i.Symbol)
+	}
+
+	if i.MinNotional > 0 && quantity*price < i.MinNotional {
+		return fmt.Errorf("order notional %.4f is less than the minimum %.4f for %s", quantity*price, i.MinNotional, i.Symbol)
+	}
+
+	if !isMarket {
+		if price < i.MinPrice || (i.MaxPrice > 0 && price > i.MaxPrice) {
+			return fmt.Errorf("price %s is outside the allowed range for %s", i.FormatPrice(price), i.Symbol)
+		}
+		if markPrice > 0 && i.MultiplierUp > 0 {
+			if price > markPrice*i.MultiplierUp || price < markPrice*i.MultiplierDown {
+				return fmt.Errorf("price %s is too far from mark price %s for %s", i.FormatPrice(price), i.FormatPrice(markPrice), i.Symbol)
+			}
+		}
+	}
+
+	return nil
+}
+
+func filterString(f map[string]interface{}, key string) string {
+	switch v := f[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func filterFloat(f map[string]interface{}, key string) float64 {
+	v, _ := strconv.ParseFloat(filterString(f, key), 64)
+	return v
+}
+
+// decimalPlaces counts significant decimals in a step string such as "0.00100"
+func decimalPlaces(step string) int {
+	dot := strings.IndexByte(step, '.')
+	if dot < 0 {
+		return 0
+	}
+	return len(strings.TrimRight(step[dot+1:], "0"))
+}
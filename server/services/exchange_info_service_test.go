@@ -0,0 +1,155 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// btcRules mirrors the BTCUSDT filters of the futures exchangeInfo
+func btcRules() *SymbolInfo {
+	return &SymbolInfo{
+		Symbol:         "BTCUSDT",
+		Status:         "TRADING",
+		TickSize:       0.1,
+		MinPrice:       556.8,
+		MaxPrice:       4529764,
+		TickDecimals:   1,
+		StepSize:       0.001,
+		MinQty:         0.001,
+		MaxQty:         1000,
+		StepDecimals:   3,
+		MarketStepSize: 0.01,
+		MarketMinQty:   0.01,
+		MarketMaxQty:   120,
+		MinNotional:    100,
+		MultiplierUp:   1.05,
+		MultiplierDown: 0.95,
+	}
+}
+
+func TestTruncateQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		isMarket bool
+		want     float64
+	}{
+		{"rounds down to the lot step", 0.12345, false, 0.123},
+		{"keeps an exact multiple despite float error", 0.3, false, 0.3},
+		{"uses the market lot step for market orders", 0.12345, true, 0.12},
+		{"below one step becomes zero", 0.0009, false, 0},
+		{"large quantity", 12.3456789, false, 12.345},
+	}
+
+	info := btcRules()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := info.TruncateQuantity(tt.quantity, tt.isMarket); got != tt.want {
+				t.Errorf("TruncateQuantity(%g) = %g, want %g", tt.quantity, got, tt.want)
+			}
+		})
+	}
+
+	unfiltered := &SymbolInfo{}
+	if got := unfiltered.TruncateQuantity(0.12345, false); got != 0.12345 {
+		t.Errorf("a symbol without LOT_SIZE should keep the quantity, got %g", got)
+	}
+}
+
+func TestFormatPriceAndQuantity(t *testing.T) {
+	info := btcRules()
+	if got := info.FormatPrice(65000.04); got != "65000.0" {
+		t.Errorf("FormatPrice = %s, want 65000.0", got)
+	}
+	if got := info.FormatPrice(65000.06); got != "65000.1" {
+		t.Errorf("FormatPrice = %s, want 65000.1", got)
+	}
+	if got := info.FormatQuantity(0.0129, false); got != "0.012" {
+		t.Errorf("FormatQuantity = %s, want 0.012", got)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*SymbolInfo)
+		quantity  float64
+		price     float64
+		markPrice float64
+		isMarket  bool
+		wantErr   string
+	}{
+		{name: "valid limit order", quantity: 0.01, price: 65000, markPrice: 65000},
+		{name: "valid market order", quantity: 0.01, price: 65000, isMarket: true},
+		{name: "symbol not trading", mutate: func(i *SymbolInfo) { i.Status = "SETTLING" }, quantity: 0.01, price: 65000, wantErr: "not trading"},
+		{name: "below the lot minimum", quantity: 0.0005, price: 65000, wantErr: "less than the minimum allowed"},
+		{name: "below the market lot minimum", quantity: 0.005, price: 65000, isMarket: true, wantErr: "less than the minimum allowed"},
+		{name: "above the market lot maximum", quantity: 150, price: 65000, isMarket: true, wantErr: "more than the maximum allowed"},
+		{name: "notional too small", quantity: 0.001, price: 65000, wantErr: "order notional"},
+		{name: "price below the filter", quantity: 1, price: 500, wantErr: "outside the allowed range"},
+		{name: "price too far above the mark", quantity: 0.01, price: 70000, markPrice: 65000, wantErr: "too far from mark price"},
+		{name: "mark price check skipped without a mark", quantity: 0.01, price: 70000},
+		{name: "price filters do not apply to market orders", quantity: 0.01, price: 70000, markPrice: 65000, isMarket: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := btcRules()
+			if tt.mutate != nil {
+				tt.mutate(info)
+			}
+			err := info.ValidateOrder(tt.quantity, tt.price, tt.markPrice, tt.isMarket)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("expected an error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	tests := map[string]int{
+		"0.00100": 3,
+		"0.1":     1,
+		"1":       0,
+		"10.000":  0,
+		"":        0,
+	}
+	for step, want := range tests {
+		if got := decimalPlaces(step); got != want {
+			t.Errorf("decimalPlaces(%q) = %d, want %d", step, got, want)
+		}
+	}
+}
+
+func TestExchangeInfoRefreshParsesFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT","filters":[
+			{"filterType":"PRICE_FILTER","tickSize":"0.10","minPrice":"556.80","maxPrice":"4529764"},
+			{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001","maxQty":"1000"},
+			{"filterType":"MARKET_LOT_SIZE","stepSize":"0.010","minQty":"0.010","maxQty":"120"},
+			{"filterType":"MIN_NOTIONAL","notional":"100"},
+			{"filterType":"PERCENT_PRICE","multiplierUp":"1.0500","multiplierDown":"0.9500","multiplierDecimal":4}
+		]}]}`))
+	}))
+	defer server.Close()
+
+	service := NewExchangeInfoService(server.URL, time.Minute)
+	info, err := service.GetSymbol("BTCUSDT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := btcRules()
+	want.BaseAsset, want.QuoteAsset, want.MultiplierDecimal = "BTC", "USDT", 4
+	if *info != *want {
+		t.Fatalf("parsed rules\n%+v\nwant\n%+v", *info, *want)
+	}
+}
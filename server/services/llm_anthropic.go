@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicDefaultURL = "https://api.anthropic.com"
+	anthropicVersion    = "2023-06-01"
+)
+
+// AnthropicProvider talks to the Anthropic Messages API
+type AnthropicProvider struct {
+	name    string
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAnthropicProvider creates a provider; an empty base URL uses api.anthropic.com
+func NewAnthropicProvider(name, apiKey, baseURL string) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = anthropicDefaultURL
+	}
+	return &AnthropicProvider{
+		name:    name,
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (p *AnthropicProvider) Name() string {
+	return p.name
+}
+
+func (p *AnthropicProvider) IsConfigured() bool {
+	return p.apiKey != ""
+}
+
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model      string             `json:"model"`
+	MaxTokens  int                `json:"max_tokens"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice map[string]string  `json:"tool_choice,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicBlock `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if !p.IsConfigured() {
+		return nil, fmt.Errorf("%s provider not configured", p.name)
+	}
+
+	body := anthropicRequest{Model: req.Model, MaxTokens: req.MaxTokens}
+	for _, m := range req.Messages {
+		body.Messages = appendAnthropicMessage(body.Messages, m)
+	}
+	if req.Output != nil {
+		body.Tools = []anthropicTool{{Name: req.Output.Name, Description: req.Output.Description, InputSchema: req.Output.Schema}}
+		body.ToolChoice = map[string]string{"type": "tool", "name": req.Output.Name}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", p.name, err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", p.name, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", p.name, err)
+	}
+	var parsed anthropicResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("%s API error: status %d: %s", p.name, resp.StatusCode, string(raw))
+	}
+	if resp.StatusCode != http.StatusOK || parsed.Error != nil {
+		if parsed.Error != nil {
+			return nil, fmt.Errorf("%s API error: status %d: %s: %s", p.name, resp.StatusCode, parsed.Error.Type, parsed.Error.Message)
+		}
+		return nil, fmt.Errorf("%s API error: status %d", p.name, resp.StatusCode)
+	}
+
+	out := &CompletionResponse{
+		Message: ChatMessage{Role: RoleAssistant},
+		Usage:   TokenUsage{PromptTokens: parsed.Usage.InputTokens, CompletionTokens: parsed.Usage.OutputTokens},
+	}
+	for _, block := range parsed.Content {
+		switch block.Type {
+		case "text":
+			out.Message.Content += block.Text
+		case "tool_use":
+			out.Message.ToolCalls = append(out.Message.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+	if out.Message.Content == "" && len(out.Message.ToolCalls) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+	return out, nil
+}
+
+// appendAnthropicMessage converts a message to content blocks. Tool results are
+// sent as user turns, and consecutive turns of one role are merged because the
+// Messages API requires the roles to alternate.
+func appendAnthropicMessage(messages []anthropicMessage, m ChatMessage) []anthropicMessage {
+	role := m.Role
+	var blocks []anthropicBlock
+	switch m.Role {
+	case RoleTool:
+		role = RoleUser
+		blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+	default:
+		if m.Content != "" {
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+		}
+		for _, call := range m.ToolCalls {
+			input := json.RawMessage(call.Arguments)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+		}
+	}
+
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, blocks...)
+		return messages
+	}
+	return append(messages, anthropicMessage{Role: role, Content: blocks})
+}
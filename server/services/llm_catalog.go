@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Built-in provider names; more OpenAI-compatible providers come from LLM_PROVIDERS
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+var (
+	// ErrUnknownModel is wrapped when a request names a model missing from the catalog
+	ErrUnknownModel = errors.New("unknown model")
+	// ErrModelUnavailable is wrapped when a request names a model whose provider is not configured
+	ErrModelUnavailable = errors.New("model unavailable")
+)
+
+// defaultModelCatalog is used when LLM_MODELS is not set; the first entry is the default model
+var defaultModelCatalog = []string{
+	"gpt-3.5-turbo=openai",
+	"gpt-4=openai",
+	"gpt-4-turbo=openai",
+	"gpt-4o=openai",
+	"gpt-4o-mini=openai",
+	"gpt-4.1=openai",
+	"claude-sonnet-4-0=anthropic",
+	"claude-3-5-haiku-latest=anthropic",
+}
+
+// defaultModelPrices are USD per million prompt and completion tokens; LLM_MODEL_PRICES overrides them
+var defaultModelPrices = map[string][2]float64{
+	"gpt-3.5-turbo":           {0.5, 1.5},
+	"gpt-4":                   {30, 60},
+	"gpt-4-turbo":             {10, 30},
+	"gpt-4o":                  {2.5, 10},
+	"gpt-4o-mini":             {0.15, 0.6},
+	"gpt-4.1":                 {2, 8},
+	"claude-sonnet-4-0":       {3, 15},
+	"claude-3-5-haiku-latest": {0.8, 4},
+}
+
+// ModelInfo is a model requests can name
+type ModelInfo struct {
+	Name        string  `json:"name"` // as requested
+	Provider    string  `json:"provider"`
+	Model       string  `json:"model"`       // id sent to the provider
+	Available   bool    `json:"available"`   // the provider is configured
+	InputPrice  float64 `json:"inputPrice"`  // USD per million prompt tokens
+	OutputPrice float64 `json:"outputPrice"` // USD per million completion tokens
+}
+
+// Cost prices a request's token usage; models without a price cost nothing
+func (m ModelInfo) Cost(usage TokenUsage) float64 {
+	return (float64(usage.PromptTokens)*m.InputPrice + float64(usage.CompletionTokens)*m.OutputPrice) / 1e6
+}
+
+// ModelCatalog maps the model names requests can use to providers
+type ModelCatalog struct {
+	models       []ModelInfo
+	defaultModel string
+}
+
+// providersFromEnv builds the OpenAI and Anthropic providers plus one
+// OpenAI-compatible provider per LLM_PROVIDERS entry, configured by
+// LLM_PROVIDER_<NAME>_BASE_URL, _API_KEY and _TOOLS=false for servers without
+// function calling
+func providersFromEnv() (map[string]Provider, error) {
+	providers := map[string]Provider{
+		ProviderOpenAI:    NewOpenAIProvider(ProviderOpenAI, os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_BASE_URL"), true),
+		ProviderAnthropic: NewAnthropicProvider(ProviderAnthropic, os.Getenv("ANTHROPIC_API_KEY"), os.Getenv("ANTHROPIC_BASE_URL")),
+	}
+
+	for _, name := range strings.Split(os.Getenv("LLM_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := providers[name]; ok {
+			return nil, fmt.Errorf("provider %s is defined twice", name)
+		}
+
+		prefix := "LLM_PROVIDER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		baseURL := os.Getenv(prefix + "BASE_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("provider %s needs %sBASE_URL", name, prefix)
+		}
+		tools := !strings.EqualFold(os.Getenv(prefix+"TOOLS"), "false")
+		providers[name] = NewOpenAIProvider(name, os.Getenv(prefix+"API_KEY"), baseURL, tools)
+	}
+	return providers, nil
+}
+
+// catalogFromEnv reads LLM_MODELS, a comma-separated list of name=provider[:model]
+// entries where model defaults to name, e.g. "gpt-4o=openai,llama=ollama:llama3.1:8b".
+// LLM_DEFAULT_MODEL picks the default, otherwise the first entry.
+func catalogFromEnv(providers map[string]Provider) (*ModelCatalog, error) {
+	entries := defaultModelCatalog
+	if value := os.Getenv("LLM_MODELS"); value != "" {
+		entries = strings.Split(value, ",")
+	}
+
+	catalog := &ModelCatalog{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || target == "" {
+			return nil, fmt.Errorf("invalid LLM_MODELS entry %q, use name=provider[:model]", entry)
+		}
+		providerName, model, _ := strings.Cut(target, ":")
+		if model == "" {
+			model = name
+		}
+		provider, ok := providers[providerName]
+		if !ok {
+			return nil, fmt.Errorf("model %s uses unknown provider %s", name, providerName)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("model %s is listed twice", name)
+		}
+		seen[name] = true
+		price := defaultModelPrices[name]
+		catalog.models = append(catalog.models, ModelInfo{
+			Name:        name,
+			Provider:    providerName,
+			Model:       model,
+			Available:   provider.IsConfigured(),
+			InputPrice:  price[0],
+			OutputPrice: price[1],
+		})
+	}
+	if len(catalog.models) == 0 {
+		return nil, fmt.Errorf("LLM_MODELS lists no models")
+	}
+	if err := catalog.applyPrices(os.Getenv("LLM_MODEL_PRICES")); err != nil {
+		return nil, err
+	}
+
+	catalog.defaultModel = catalog.models[0].Name
+	if value := os.Getenv("LLM_DEFAULT_MODEL"); value != "" {
+		if !seen[value] {
+			return nil, fmt.Errorf("LLM_DEFAULT_MODEL %s is not in the model catalog", value)
+		}
+		catalog.defaultModel = value
+	}
+	return catalog, nil
+}
+
+// applyPrices reads a comma-separated list of name=input/output entries, in USD
+// per million prompt and completion tokens, e.g. "gpt-4o=2.5/10,llama=0/0"
+func (c *ModelCatalog) applyPrices(value string) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, prices, ok := strings.Cut(entry, "=")
+		input, output, ok2 := strings.Cut(prices, "/")
+		if !ok || !ok2 {
+			return fmt.Errorf("invalid LLM_MODEL_PRICES entry %q, use name=input/output", entry)
+		}
+		inputPrice, err1 := strconv.ParseFloat(input, 64)
+		outputPrice, err2 := strconv.ParseFloat(output, 64)
+		if err1 != nil || err2 != nil || inputPrice < 0 || outputPrice < 0 {
+			return fmt.Errorf("invalid prices in LLM_MODEL_PRICES entry %q", entry)
+		}
+
+		found := false
+		for i := range c.models {
+			if c.models[i].Name == name {
+				c.models[i].InputPrice, c.models[i].OutputPrice = inputPrice, outputPrice
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("LLM_MODEL_PRICES names %s, which is not in the model catalog", name)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the catalog entry for a model name, or the default for ""
+func (c *ModelCatalog) Resolve(name string) (ModelInfo, error) {
+	if name == "" {
+		name = c.defaultModel
+	}
+	for _, m := range c.models {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	names := make([]string, len(c.models))
+	for i, m := range c.models {
+		names[i] = m.Name
+	}
+	return ModelInfo{}, fmt.Errorf("%w %s, available: %s", ErrUnknownModel, name, strings.Join(names, ", "))
+}
+
+// Default returns the model used when a request names none
+func (c *ModelCatalog) Default() string {
+	return c.defaultModel
+}
+
+// Models returns the catalog in configuration order
+func (c *ModelCatalog) Models() []ModelInfo {
+	return append([]ModelInfo(nil), c.models...)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubProvider only reports whether it is configured; the other Provider methods are unused
+type stubProvider struct {
+	Provider
+	configured bool
+}
+
+func (p stubProvider) IsConfigured() bool {
+	return p.configured
+}
+
+func TestCatalogFromEnv(t *testing.T) {
+	providers := map[string]Provider{
+		ProviderOpenAI:    stubProvider{configured: true},
+		ProviderAnthropic: stubProvider{configured: true},
+		"ollama":          stubProvider{},
+	}
+
+	tests := []struct {
+		name         string
+		models       string
+		defaultModel string
+		prices       string
+		wantModels   []ModelInfo
+		wantDefault  string
+		wantErrMsg   string
+	}{
+		{
+			name:        "custom entries",
+			models:      "gpt-4o=openai, llama=ollama:llama3.1:8b",
+			wantDefault: "gpt-4o",
+			wantModels: []ModelInfo{
+				{Name: "gpt-4o", Provider: ProviderOpenAI, Model: "gpt-4o", Available: true, InputPrice: 2.5, OutputPrice: 10},
+				{Name: "llama", Provider: "ollama", Model: "llama3.1:8b"},
+			},
+		},
+		{
+			name:         "default model and price overrides",
+			models:       "gpt-4o=openai,llama=ollama:llama3.1:8b",
+			defaultModel: "llama",
+			prices:       "llama=0.1/0.2, gpt-4o=2/8",
+			wantDefault:  "llama",
+			wantModels: []ModelInfo{
+				{Name: "gpt-4o", Provider: ProviderOpenAI, Model: "gpt-4o", Available: true, InputPrice: 2, OutputPrice: 8},
+				{Name: "llama", Provider: "ollama", Model: "llama3.1:8b", InputPrice: 0.1, OutputPrice: 0.2},
+			},
+		},
+		{name: "entry without a provider", models: "gpt-4o", wantErrMsg: "invalid LLM_MODELS entry"},
+		{name: "unknown provider", models: "mixtral=groq", wantErrMsg: "unknown provider groq"},
+		{name: "model listed twice", models: "gpt-4o=openai,gpt-4o=openai:gpt-4o-2024", wantErrMsg: "listed twice"},
+		{name: "no models", models: " , ", wantErrMsg: "lists no models"},
+		{name: "default outside the catalog", models: "gpt-4o=openai", defaultModel: "gpt-4", wantErrMsg: "LLM_DEFAULT_MODEL"},
+		{name: "invalid prices", models: "gpt-4o=openai", prices: "gpt-4o=cheap", wantErrMsg: "LLM_MODEL_PRICES"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_MODELS", tt.models)
+			t.Setenv("LLM_DEFAULT_MODEL", tt.defaultModel)
+			t.Setenv("LLM_MODEL_PRICES", tt.prices)
+
+			catalog, err := catalogFromEnv(providers)
+			if tt.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if catalog.Default() != tt.wantDefault {
+				t.Errorf("default = %s, want %s", catalog.Default(), tt.wantDefault)
+			}
+			models := catalog.Models()
+			if len(models) != len(tt.wantModels) {
+				t.Fatalf("models = %+v, want %+v", models, tt.wantModels)
+			}
+			for i := range tt.wantModels {
+				if models[i] != tt.wantModels[i] {
+					t.Errorf("model %d = %+v, want %+v", i, models[i], tt.wantModels[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCatalogFromEnvDefaults(t *testing.T) {
+	t.Setenv("LLM_MODELS", "")
+	t.Setenv("LLM_DEFAULT_MODEL", "")
+	t.Setenv("LLM_MODEL_PRICES", "")
+
+	catalog, err := catalogFromEnv(map[string]Provider{
+		ProviderOpenAI:    stubProvider{configured: true},
+		ProviderAnthropic: stubProvider{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog.Models()) != len(defaultModelCatalog) || catalog.Default() != "gpt-3.5-turbo" {
+		t.Fatalf("catalog = %+v, default %s", catalog.Models(), catalog.Default())
+	}
+
+	claude, err := catalog.Resolve("claude-sonnet-4-0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claude.Available || claude.InputPrice != 3 || claude.OutputPrice != 15 {
+		t.Errorf("claude = %+v, want unavailable at 3/15", claude)
+	}
+	if cost := claude.Cost(TokenUsage{PromptTokens: 1000, CompletionTokens: 100}); cost != 0.0045 {
+		t.Errorf("cost = %g, want 0.0045", cost)
+	}
+	if _, err := catalog.Resolve("gpt-5"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("error = %v, want %v", err, ErrUnknownModel)
+	}
+}
+
+func TestApplyPrices(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		wantInput  float64
+		wantOutput float64
+		wantErr    bool
+	}{
+		{name: "unset keeps the defaults", wantInput: 2.5, wantOutput: 10},
+		{name: "override with spaces and empty entries", value: " gpt-4o=1.5/6 , ", wantInput: 1.5, wantOutput: 6},
+		{name: "free model", value: "gpt-4o=0/0"},
+		{name: "missing output price", value: "gpt-4o=2.5", wantErr: true},
+		{name: "not a number", value: "gpt-4o=a/1", wantErr: true},
+		{name: "negative price", value: "gpt-4o=-1/1", wantErr: true},
+		{name: "model outside the catalog", value: "gpt-5=1/1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := &ModelCatalog{models: []ModelInfo{{Name: "gpt-4o", InputPrice: 2.5, OutputPrice: 10}}}
+			err := catalog.applyPrices(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m := catalog.models[0]; m.InputPrice != tt.wantInput || m.OutputPrice != tt.wantOutput {
+				t.Errorf("prices = %g/%g, want %g/%g", m.InputPrice, m.OutputPrice, tt.wantInput, tt.wantOutput)
+			}
+		})
+	}
+}
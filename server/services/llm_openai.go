@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAIProvider talks to the OpenAI chat completions API or to any server that
+// implements it, such as Ollama or vLLM
+type OpenAIProvider struct {
+	name   string
+	client *openai.Client
+	tools  bool // false for servers without function calling; the answer is then read from the text
+}
+
+// NewOpenAIProvider creates a provider for the given base URL; an empty URL uses
+// api.openai.com. Without an API key or base URL the provider is not configured.
+func NewOpenAIProvider(name, apiKey, baseURL string, tools bool) *OpenAIProvider {
+	provider := &OpenAIProvider{name: name, tools: tools}
+	if apiKey == "" && baseURL == "" {
+		return provider
+	}
+
+	cfg := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		cfg.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	provider.client = openai.NewClientWithConfig(cfg)
+	return provider
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) IsConfigured() bool {
+	return p.client != nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if !p.IsConfigured() {
+		return nil, fmt.Errorf("%s provider not configured", p.name)
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:     req.Model,
+		Messages:  make([]openai.ChatCompletionMessage, len(req.Messages)),
+		MaxTokens: req.MaxTokens,
+	}
+	for i, m := range req.Messages {
+		msg := openai.ChatCompletionMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Name, Arguments: call.Arguments},
+			})
+		}
+		chatReq.Messages[i] = msg
+	}
+	if req.Output != nil && p.tools {
+		chatReq.Tools = []openai.Tool{{
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionDefinition{
+				Name:        req.Output.Name,
+				Description: req.Output.Description,
+				Parameters:  req.Output.Schema,
+			},
+		}}
+		chatReq.ToolChoice = openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: req.Output.Name},
+		}
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", p.name, err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+
+	reply := resp.Choices[0].Message
+	out := &CompletionResponse{
+		Message: ChatMessage{Role: RoleAssistant, Content: reply.Content},
+		Usage:   TokenUsage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens},
+	}
+	for _, call := range reply.ToolCalls {
+		out.Message.ToolCalls = append(out.Message.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	return out, nil
+}
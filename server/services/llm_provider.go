@@ -0,0 +1,54 @@
+package services
+
+import "context"
+
+// Chat roles in provider-neutral messages
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool" // result of a tool call, answered with ToolCallID
+)
+
+// Provider is the vendor-neutral interface LLMService uses to reach a model API
+type Provider interface {
+	Name() string
+	IsConfigured() bool
+	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
+}
+
+// ChatMessage is one turn of a conversation
+type ChatMessage struct {
+	Role       string
+	Content    string
+	ToolCalls  []ToolCall // calls made by the assistant
+	ToolCallID string     // the call a tool message answers
+}
+
+// ToolCall is a function call made by the model; Arguments is raw JSON
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// CompletionRequest is a chat request for one provider model
+type CompletionRequest struct {
+	Model     string // model id as the provider knows it
+	Messages  []ChatMessage
+	MaxTokens int
+
+	// Output, when set, is offered as the only tool and the model is forced to call it
+	Output *StructuredOutput
+}
+
+// CompletionResponse is the model's reply
+type CompletionResponse struct {
+	Message ChatMessage
+	Usage   TokenUsage
+}
+
+// TokenUsage is the token count a provider reports for one request
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
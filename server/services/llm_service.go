@@ -2,81 +2,179 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"regexp"
+	"log"
+	"saturday-autotrade/models"
 	"time"
-
-	"github.com/sashabaranov/go-openai"
 )
 
+// LLMService routes requests to the provider of the requested model
 type LLMService struct {
-	client *openai.Client
+	providers map[string]Provider
+	catalog   *ModelCatalog
+	err       error // configuration error, returned by every request
 }
 
 func NewLLMService() *LLMService {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-
-	if apiKey == "" {
-		return &LLMService{client: nil}
+	service := &LLMService{}
+	service.providers, service.err = providersFromEnv()
+	if service.err == nil {
+		service.catalog, service.err = catalogFromEnv(service.providers)
 	}
-
-	client := openai.NewClient(apiKey)
-	return &LLMService{client: client}
+	if service.err != nil {
+		log.Printf("LLM: invalid model configuration: %v", service.err)
+	}
+	return service
 }
 
+// IsConfigured reports whether any provider of the catalog can be used
 func (s *LLMService) IsConfigured() bool {
-	configured := s.client != nil
-	return configured
+	if s.err != nil {
+		return false
+	}
+	for _, m := range s.catalog.Models() {
+		if m.Available {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *LLMService) SendRequest(model, message string) (string, error) {
-	// Only log the prompt and response for OpenAI
-	fmt.Printf("[OpenAI Prompt] Model: %s\nPrompt: %.200s...\n", model, message)
-
-	if !s.IsConfigured() {
-		return "", fmt.Errorf("OpenAI API key not configured")
+// ResolveModel returns the catalog entry for a model name, or the default model for "".
+// Models whose provider is not configured are rejected.
+func (s *LLMService) ResolveModel(name string) (ModelInfo, error) {
+	if s.err != nil {
+		return ModelInfo{}, fmt.Errorf("LLM configuration error: %w", s.err)
 	}
+	info, err := s.catalog.Resolve(name)
+	if err != nil {
+		return info, err
+	}
+	if !info.Available {
+		return info, fmt.Errorf("%w: %s provider for model %s not configured", ErrModelUnavailable, info.Provider, info.Name)
+	}
+	return info, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// Models returns the model catalog and the default model
+func (s *LLMService) Models() ([]ModelInfo, string, error) {
+	if s.err != nil {
+		return nil, "", fmt.Errorf("LLM configuration error: %w", s.err)
+	}
+	return s.catalog.Models(), s.catalog.Default(), nil
+}
 
-	req := openai.ChatCompletionRequest{
-		Model: model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: message,
-			},
-		},
-		MaxTokens: 1024,
+// provider returns the model's catalog entry and its configured provider
+func (s *LLMService) provider(model string) (ModelInfo, Provider, error) {
+	info, err := s.ResolveModel(model)
+	if err != nil {
+		return info, nil, err
 	}
+	return info, s.providers[info.Provider], nil
+}
+
+const (
+	// maxRepairAttempts bounds the follow-up requests sent when structured output fails validation
+	maxRepairAttempts = 2
+	llmMaxTokens      = 1024
+	llmRequestTimeout = 60 * time.Second // local models answer slower than hosted APIs
+)
 
-	resp, err := s.client.CreateChatCompletion(ctx, req)
+// StructuredOutput describes the JSON an answer must conform to
+type StructuredOutput struct {
+	Name        string // function the model is asked to call
+	Description string
+	Schema      json.RawMessage // JSON schema of the function arguments
+	Validate    func(raw string) error
+}
+
+// SendStructuredRequest asks for output matching a schema by forcing a function
+// call, validates the arguments, and sends validation errors back to the model
+// for up to maxRepairAttempts corrections. Given an exchange, it records the
+// provider and every attempt into it.
+func (s *LLMService) SendStructuredRequest(model, message string, output *StructuredOutput, exchange *models.LLMExchange) (string, error) {
+	info, provider, err := s.provider(model)
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API error: %w", err)
+		return "", err
 	}
+	fmt.Printf("[LLM Prompt] Provider: %s Model: %s\nPrompt: %.200s...\n", info.Provider, info.Model, message)
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+	record := func(attempt models.LLMAttempt) {
+		if exchange != nil {
+			exchange.Attempts = append(exchange.Attempts, attempt)
+			exchange.Usage.Add(attempt.Usage)
+		}
+	}
+	if exchange != nil {
+		exchange.Provider, exchange.ProviderModel = info.Provider, info.Model
 	}
 
-	responseContent := resp.Choices[0].Message.Content
-	fmt.Printf("[OpenAI Response] Length: %d\nResponse: %s\n", len(responseContent), responseContent)
+	req := &CompletionRequest{
+		Model:     info.Model,
+		Messages:  []ChatMessage{{Role: RoleUser, Content: message}},
+		MaxTokens: llmMaxTokens,
+		Output:    output,
+	}
 
-	// Strip markdown JSON formatting if present
-	responseContent = StripMarkdownJSON(responseContent)
+	var lastErr error
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		start := time.Now()
+		reply, tokens, err := s.complete(provider, req)
+		latency := time.Since(start).Milliseconds()
+		if err != nil {
+			record(models.LLMAttempt{Error: err.Error(), LatencyMs: latency})
+			return "", err
+		}
+		usage := models.LLMUsage{PromptTokens: tokens.PromptTokens, CompletionTokens: tokens.CompletionTokens, CostUSD: info.Cost(tokens)}
+
+		// Models answer through the forced function call; fall back to the text
+		// content for any that reply in plain text anyway
+		raw := reply.Content
+		callID := ""
+		if len(reply.ToolCalls) > 0 {
+			raw, callID = reply.ToolCalls[0].Arguments, reply.ToolCalls[0].ID
+		}
+
+		if lastErr = output.Validate(raw); lastErr == nil {
+			record(models.LLMAttempt{Response: raw, LatencyMs: latency, Usage: usage})
+			if object, ok := ExtractJSONObject(raw); ok {
+				return object, nil
+			}
+			return raw, nil
+		}
+		record(models.LLMAttempt{Response: raw, Error: lastErr.Error(), LatencyMs: latency, Usage: usage})
+		log.Printf("LLM: %s output failed validation (attempt %d of %d): %v", output.Name, attempt+1, maxRepairAttempts+1, lastErr)
+
+		repair := fmt.Sprintf("The output was invalid: %v. Call %s again with corrected arguments that satisfy the schema and the rules in the first message.", lastErr, output.Name)
+		req.Messages = append(req.Messages, reply)
+		if callID != "" {
+			req.Messages = append(req.Messages, ChatMessage{Role: RoleTool, Content: repair, ToolCallID: callID})
+		} else {
+			req.Messages = append(req.Messages, ChatMessage{Role: RoleUser, Content: repair})
+		}
+	}
 
-	return responseContent, nil
+	return "", fmt.Errorf("%s output still invalid after %d repair attempts: %w", output.Name, maxRepairAttempts, lastErr)
 }
 
-// Strips ```json ... ``` or ``` ... ``` from a string
-func StripMarkdownJSON(s string) string {
-	// Regex for ```json ... ``` and ``` ... ```
-	re := regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)\\s*```")
-	matches := re.FindStringSubmatch(s)
-	if len(matches) > 1 {
-		return matches[1]
+// complete sends one request to a provider and returns the reply message and its token usage
+func (s *LLMService) complete(provider Provider, req *CompletionRequest) (ChatMessage, TokenUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
+	defer cancel()
+
+	resp, err := provider.Complete(ctx, req)
+	if err != nil {
+		return ChatMessage{}, TokenUsage{}, err
 	}
-	return s
+
+	reply := resp.Message
+	responseContent := reply.Content
+	if len(reply.ToolCalls) > 0 {
+		responseContent = reply.ToolCalls[0].Arguments
+	}
+	fmt.Printf("[LLM Response] Provider: %s Length: %d Tokens: %d/%d\nResponse: %s\n",
+		provider.Name(), len(responseContent), resp.Usage.PromptTokens, resp.Usage.CompletionTokens, responseContent)
+
+	return reply, resp.Usage, nil
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"saturday-autotrade/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrBudgetExceeded is returned when today's LLM spend has reached LLM_DAILY_BUDGET_USD
+var ErrBudgetExceeded = errors.New("daily LLM budget exceeded")
+
+// unrecordedLLMSpend keeps the cost of exchanges that failed to be stored, which
+// the spend queries cannot see
+type unrecordedLLMSpend struct {
+	mu        sync.Mutex
+	day       time.Time // UTC day costUSD belongs to
+	costUSD   float64
+	exchanges int64 // since the process started
+}
+
+var unrecordedSpend unrecordedLLMSpend
+
+func (u *unrecordedLLMSpend) add(exchanges []models.LLMExchange) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if !u.day.Equal(today) {
+		u.day, u.costUSD = today, 0
+	}
+	for _, e := range exchanges {
+		u.costUSD += e.Usage.CostUSD
+	}
+	u.exchanges += int64(len(exchanges))
+}
+
+// today returns the cost of today's unrecorded exchanges
+func (u *unrecordedLLMSpend) today() float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.day.Equal(time.Now().UTC().Truncate(24 * time.Hour)) {
+		return 0
+	}
+	return u.costUSD
+}
+
+// UnrecordedLLMExchanges returns how many LLM exchanges could not be stored since
+// the process started; their cost is missing from GetLLMSpend
+func UnrecordedLLMExchanges() int64 {
+	unrecordedSpend.mu.Lock()
+	defer unrecordedSpend.mu.Unlock()
+	return unrecordedSpend.exchanges
+}
+
+// DailyLLMBudget reads LLM_DAILY_BUDGET_USD; 0 means no limit
+func DailyLLMBudget() float64 {
+	budget, err := strconv.ParseFloat(os.Getenv("LLM_DAILY_BUDGET_USD"), 64)
+	if err != nil || budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// checkLLMBudget refuses new signal runs once the spend of the current UTC day,
+// recorded or not, reaches the daily budget. A run in progress may still go over it.
+func (s *TradingService) checkLLMBudget() error {
+	budget := DailyLLMBudget()
+	if budget <= 0 {
+		return nil
+	}
+
+	spent, err := s.llmSpendSince(time.Now().UTC().Truncate(24 * time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to check the daily LLM budget: %w", err)
+	}
+	spent += unrecordedSpend.today()
+	if spent >= budget {
+		return fmt.Errorf("%w: spent $%.4f of $%.2f today (UTC)", ErrBudgetExceeded, spent, budget)
+	}
+	return nil
+}
+
+// llmSpendSince sums the cost of the LLM exchanges recorded since the given time
+func (s *TradingService) llmSpendSince(since time.Time) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "costUsd": bson.M{"$sum": "$usage.costUsd"}}}},
+	}
+	cursor, err := s.exchangeCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		CostUSD float64 `bson:"costUsd"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, err
+	}
+	if len(totals) == 0 {
+		return 0, nil
+	}
+	return totals[0].CostUSD, nil
+}
+
+// GetLLMSpend aggregates the recorded LLM usage of the last days by UTC day, model
+// and symbol, newest day first and the most expensive first within a day
+
+func (s *TradingService) GetLLMSpend(days int) ([]models.LLMSpend, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"day":    bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$createdAt"}},
+				"model":  "$model",
+				"symbol": "$symbol",
+			},
+			"requests":         bson.M{"$sum": 1},
+			"signals":          bson.M{"$addToSet": "$signalId"},
+			"promptTokens":     bson.M{"$sum": "$usage.promptTokens"},
+			"completionTokens": bson.M{"$sum": "$usage.completionTokens"},
+			"costUsd":          bson.M{"$sum": "$usage.costUsd"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":              0,
+			"day":              "$_id.day",
+			"model":            "$_id.model",
+			"symbol":           "$_id.symbol",
+			"requests":         1,
+			"signals":          bson.M{"$size": "$signals"},
+			"promptTokens":     1,
+			"completionTokens": 1,
+			"costUsd":          1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "day", Value: -1}, {Key: "costUsd", Value: -1}}}},
+	}
+
+	cursor, err := s.exchangeCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate LLM spend: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	spend := []models.LLMSpend{}
+	if err := cursor.All(ctx, &spend); err != nil {
+		return nil, fmt.Errorf("failed to decode LLM spend: %w", err)
+	}
+	return spend, nil
+}
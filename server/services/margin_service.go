@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Margin modes, named as Binance reports them
+const (
+	MarginModeIsolated = "ISOLATED"
+	MarginModeCross    = "CROSSED"
+)
+
+// LeverageBracket is one notional tier from /fapi/v1/leverageBracket
+type LeverageBracket struct {
+	Bracket          int     `json:"bracket"`
+	InitialLeverage  int     `json:"initialLeverage"`
+	NotionalCap      float64 `json:"notionalCap"`
+	NotionalFloor    float64 `json:"notionalFloor"`
+	MaintMarginRatio float64 `json:"maintMarginRatio"`
+	Cum              float64 `json:"cum"` // maintenance amount deducted at this tier
+}
+
+// MarginInput describes a proposed or open position for the margin engine
+type MarginInput struct {
+	Venue      string // whose brackets apply; empty means Binance
+	Symbol     string
+	Direction  string // LONG or SHORT
+	Size       float64
+	EntryPrice float64
+	MarkPrice  float64 // defaults to EntryPrice
+	Leverage   int
+	MarginMode string // ISOLATED (default) or CROSSED
+
+	// Cross margin only: the wallet balance shared by all positions, and the
+	// maintenance margin and unrealized PnL of the account's other positions
+	WalletBalance      float64
+	OtherMaintMargin   float64
+	OtherUnrealizedPnL float64
+}
+
+// MarginEstimate is the outcome of the margin engine for one position
+type MarginEstimate struct {
+	Symbol            string  `json:"symbol"`
+	Direction         string  `json:"direction"`
+	MarginMode        string  `json:"marginMode"`
+	Leverage          int     `json:"leverage"`
+	Notional          float64 `json:"notional"`
+	InitialMargin     float64 `json:"initialMargin"`
+	MaintMarginRate   float64 `json:"maintMarginRate"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	MarginBalance     float64 `json:"marginBalance"`
+	MarginRatio       float64 `json:"marginRatio"` // maintenance margin / margin balance; 1 means liquidation
+	LiquidationPrice  float64 `json:"liquidationPrice"`
+	Bracket           int     `json:"bracket"`
+	MaxLeverage       int     `json:"maxLeverage"` // highest leverage allowed at this notional
+	Approximate       bool    `json:"approximate"` // the venue's brackets were unavailable, so defaults were used
+}
+
+// MarginService computes liquidation prices and margin requirements from the
+// venue's leverage brackets, which are cached per venue and symbol
+type MarginService struct {
+	newExchange ExchangeFactory
+
+	mu       sync.RWMutex
+	brackets map[string]cachedBrackets
+}
+
+type cachedBrackets struct {
+	brackets  []LeverageBracket
+	fetchedAt time.Time
+}
+
+// defaultLeverageBrackets is used when a venue's brackets cannot be fetched, so that
+// previews still work against a typical altcoin tier table; such estimates are
+// marked approximate
+var defaultLeverageBrackets = []LeverageBracket{
+	{Bracket: 1, InitialLeverage: 50, NotionalFloor: 0, NotionalCap: 5000, MaintMarginRatio: 0.01, Cum: 0},
+	{Bracket: 2, InitialLeverage: 25, NotionalFloor: 5000, NotionalCap: 25000, MaintMarginRatio: 0.025, Cum: 75},
+	{Bracket: 3, InitialLeverage: 20, NotionalFloor: 25000, NotionalCap: 100000, MaintMarginRatio: 0.05, Cum: 700},
+	{Bracket: 4, InitialLeverage: 10, NotionalFloor: 100000, NotionalCap: 250000, MaintMarginRatio: 0.1, Cum: 5700},
+	{Bracket: 5, InitialLeverage: 5, NotionalFloor: 250000, NotionalCap: 1000000, MaintMarginRatio: 0.125, Cum: 11950},
+	{Bracket: 6, InitialLeverage: 4, NotionalFloor: 1000000, NotionalCap: 5000000, MaintMarginRatio: 0.15, Cum: 36950},
+}
+
+func NewMarginService(newExchange ExchangeFactory) *MarginService {
+	return &MarginService{
+		newExchange: newExchange,
+		brackets:    make(map[string]cachedBrackets),
+	}
+}
+
+// Brackets returns the leverage brackets of a symbol on a venue, refreshing them
+// once the exchange info TTL has passed. When the venue cannot provide them it
+// returns defaultLeverageBrackets and reports them as approximate.
+func (s *MarginService) Brackets(venue, symbol string, isTestnet bool) ([]LeverageBracket, bool) {
+	venue = NormalizeVenue(venue)
+	key := fmt.Sprintf("%s|%t|%s", venue, isTestnet, strings.ToUpper(symbol))
+
+	s.mu.RLock()
+	cached, ok := s.brackets[key]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.fetchedAt) < defaultExchangeInfoTTL {
+		return cached.brackets, false
+	}
+
+	exchange, err := s.newExchange(venue, isTestnet)
+	if err != nil {
+		log.Printf("MarginService: Using default brackets for %s: %v", symbol, err)
+		return defaultLeverageBrackets, true
+	}
+	brackets, err := exchange.GetLeverageBrackets(symbol)
+	if err != nil || len(brackets) == 0 {
+		log.Printf("MarginService: Using default brackets for %s on %s: %v", symbol, venue, err)
+		return defaultLeverageBrackets, true
+	}
+
+	s.mu.Lock()
+	s.brackets[key] = cachedBrackets{brackets: brackets, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	return brackets, false
+}
+
+// Estimate looks up the symbol's brackets on the input's venue and runs the margin engine
+func (s *MarginService) Estimate(in MarginInput, isTestnet bool) (*MarginEstimate, error) {
+	brackets, approximate := s.Brackets(in.Venue, in.Symbol, isTestnet)
+	estimate, err := ComputeMargin(brackets, in)
+	if err != nil {
+		return nil, err
+	}
+	estimate.Approximate = approximate
+	return estimate, nil
+}
+
+// ComputeMargin applies Binance's one-way mode formulas:
+//
+//	maintenance margin = notional * MMR - cum
+//	liquidation price  = (WB - TMM + UPNL + cum - side*size*entry) / (size*MMR - side*size)
+//
+// where WB is the isolated margin (isolated) or the wallet balance (cross), and
+// TMM/UPNL are the other positions' maintenance margin and PnL (cross only).
+// The bracket used for the liquidation price is the one containing the
+// notional at that price.
+func ComputeMargin(brackets []LeverageBracket, in MarginInput) (*MarginEstimate, error) {
+	if len(brackets) == 0 {
+		return nil, fmt.Errorf("no leverage brackets for %s", in.Symbol)
+	}
+	if in.Size <= 0 || in.EntryPrice <= 0 {
+		return nil, fmt.Errorf("size and entry price must be greater than 0")
+	}
+	if in.Leverage <= 0 {
+		return nil, fmt.Errorf("leverage must be greater than 0")
+	}
+
+	side := 1.0
+	if in.Direction == "SHORT" {
+		side = -1.0
+	}
+
+	mode := strings.ToUpper(in.MarginMode)
+	switch mode {
+	case "", MarginModeIsolated:
+		mode = MarginModeIsolated
+	case "CROSS", MarginModeCross:
+		mode = MarginModeCross
+	default:
+		return nil, fmt.Errorf("unknown margin mode %s", in.MarginMode)
+	}
+
+	markPrice := in.MarkPrice
+	if markPrice <= 0 {
+		markPrice = in.EntryPrice
+	}
+
+	notional := in.Size * markPrice
+	initialMargin := in.Size * in.EntryPrice / float64(in.Leverage)
+	unrealizedPnL := side * in.Size * (markPrice - in.EntryPrice)
+	current := bracketFor(brackets, notional)
+
+	walletBalance := initialMargin
+	otherMaint, otherPnL := 0.0, 0.0
+	if mode == MarginModeCross {
+		if in.WalletBalance <= 0 {
+			return nil, fmt.Errorf("cross margin requires the wallet balance")
+		}
+		walletBalance = in.WalletBalance
+		otherMaint, otherPnL = in.OtherMaintMargin, in.OtherUnrealizedPnL
+	}
+
+	liquidationPrice := 0.0
+	for _, b := range brackets {
+		lp := liquidationPriceFor(b, side, in.Size, in.EntryPrice, walletBalance, otherMaint, otherPnL)
+		if lp <= 0 {
+			continue
+		}
+		if n := lp * in.Size; n >= b.NotionalFloor && n < b.NotionalCap {
+			liquidationPrice = lp
+			break
+		}
+	}
+	if liquidationPrice == 0 {
+		liquidationPrice = math.Max(liquidationPriceFor(current, side, in.Size, in.EntryPrice, walletBalance, otherMaint, otherPnL), 0)
+	}
+
+	maintenanceMargin := math.Max(notional*current.MaintMarginRatio-current.Cum, 0)
+	marginBalance := walletBalance + unrealizedPnL
+	if mode == MarginModeCross {
+		marginBalance += otherPnL
+		maintenanceMargin += otherMaint
+	}
+
+	marginRatio := 1.0
+	if marginBalance > 0 {
+		marginRatio = maintenanceMargin / marginBalance
+	}
+
+	return &MarginEstimate{
+		Symbol:            in.Symbol,
+		Direction:         in.Direction,
+		MarginMode:        mode,
+		Leverage:          in.Leverage,
+		Notional:          notional,
+		InitialMargin:     initialMargin,
+		MaintMarginRate:   current.MaintMarginRatio,
+		MaintenanceMargin: maintenanceMargin,
+		MarginBalance:     marginBalance,
+		MarginRatio:       marginRatio,
+		LiquidationPrice:  liquidationPrice,
+		Bracket:           current.Bracket,
+		MaxLeverage:       current.InitialLeverage,
+	}, nil
+}
+
+func liquidationPriceFor(b LeverageBracket, side, size, entry, walletBalance, otherMaint, otherPnL float64) float64 {
+	denominator := size*b.MaintMarginRatio - side*size
+	if denominator == 0 {
+		return 0
+	}
+	return (walletBalance - otherMaint + otherPnL + b.Cum - side*size*entry) / denominator
+}
+
+// bracketFor returns the tier whose notional range contains the given notional
+func bracketFor(brackets []LeverageBracket, notional float64) LeverageBracket {
+	for _, b := range brackets {
+		if notional >= b.NotionalFloor && notional < b.NotionalCap {
+			return b
+		}
+	}
+	return brackets[len(brackets)-1]
+}
+
+// StopBeyondLiquidation reports whether a stop-loss would only trigger after the
+// position has already been liquidated
+func StopBeyondLiquidation(direction string, stopLoss, liquidationPrice float64) bool {
+	if stopLoss <= 0 || liquidationPrice <= 0 {
+		return false
+	}
+	if direction == "SHORT" {
+		return stopLoss >= liquidationPrice
+	}
+	return stopLoss <= liquidationPrice
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestComputeMargin(t *testing.T) {
+	tests := []struct {
+		name            string
+		in              MarginInput
+		wantLiquidation float64
+		wantMaintenance float64
+		wantRatio       float64
+		wantBracket     int
+		wantErr         bool
+	}{
+		{
+			// (1000 + 75 - 10000) / (0.025 - 1), inside the 5k-25k tier
+			name:            "isolated long",
+			in:              MarginInput{Symbol: "BTCUSDT", Direction: "LONG", Size: 1, EntryPrice: 10000, Leverage: 10},
+			wantLiquidation: 8925 / 0.975,
+			wantMaintenance: 175,
+			wantRatio:       0.175,
+			wantBracket:     2,
+		},
+		{
+			// (1000 + 75 + 10000) / (0.025 + 1)
+			name:            "isolated short",
+			in:              MarginInput{Symbol: "BTCUSDT", Direction: "SHORT", Size: 1, EntryPrice: 10000, Leverage: 10},
+			wantLiquidation: 11075 / 1.025,
+			wantMaintenance: 175,
+			wantRatio:       0.175,
+			wantBracket:     2,
+		},
+		{
+			name:            "mark price moves the ratio, not the liquidation price",
+			in:              MarginInput{Symbol: "BTCUSDT", Direction: "LONG", Size: 1, EntryPrice: 10000, MarkPrice: 11000, Leverage: 10},
+			wantLiquidation: 8925 / 0.975,
+			wantMaintenance: 200,
+			wantRatio:       0.1,
+			wantBracket:     2,
+		},
+		{
+			// (5000 - 100 - 200 + 75 - 10000) / (0.025 - 1)
+			name: "cross long shares the wallet with other positions",
+			in: MarginInput{Symbol: "BTCUSDT", Direction: "LONG", Size: 1, EntryPrice: 10000, Leverage: 10, MarginMode: "cross",
+				WalletBalance: 5000, OtherMaintMargin: 100, OtherUnrealizedPnL: -200},
+			wantLiquidation: 5225 / 0.975,
+			wantMaintenance: 275,
+			wantRatio:       275.0 / 4800,
+			wantBracket:     2,
+		},
+		{
+			name:            "unleveraged long cannot be liquidated",
+			in:              MarginInput{Symbol: "BTCUSDT", Direction: "LONG", Size: 1, EntryPrice: 10000, Leverage: 1},
+			wantLiquidation: 0,
+			wantMaintenance: 175,
+			wantRatio:       0.0175,
+			wantBracket:     2,
+		},
+		{
+			// The 300k notional sits in tier 5, but at the liquidation price the
+			// notional of about 244k is back in tier 4
+			name:            "liquidation uses the tier of the notional at that price",
+			in:              MarginInput{Symbol: "BTCUSDT", Direction: "LONG", Size: 30, EntryPrice: 10000, Leverage: 4},
+			wantLiquidation: (75000 + 5700 - 300000) / (30*0.1 - 30),
+			wantMaintenance: 300000*0.125 - 11950,
+			wantRatio:       (300000*0.125 - 11950) / 75000,
+			wantBracket:     5,
+		},
+		{name: "zero size", in: MarginInput{Size: 0, EntryPrice: 10000, Leverage: 10}, wantErr: true},
+		{name: "zero leverage", in: MarginInput{Size: 1, EntryPrice: 10000}, wantErr: true},
+		{name: "unknown margin mode", in: MarginInput{Size: 1, EntryPrice: 10000, Leverage: 10, MarginMode: "portfolio"}, wantErr: true},
+		{name: "cross without a wallet balance", in: MarginInput{Size: 1, EntryPrice: 10000, Leverage: 10, MarginMode: MarginModeCross}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := ComputeMargin(defaultLeverageBrackets, tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(estimate.LiquidationPrice-tt.wantLiquidation) > 1e-6 {
+				t.Errorf("liquidation price = %f, want %f", estimate.LiquidationPrice, tt.wantLiquidation)
+			}
+			if math.Abs(estimate.MaintenanceMargin-tt.wantMaintenance) > 1e-6 {
+				t.Errorf("maintenance margin = %f, want %f", estimate.MaintenanceMargin, tt.wantMaintenance)
+			}
+			if math.Abs(estimate.MarginRatio-tt.wantRatio) > 1e-9 {
+				t.Errorf("margin ratio = %f, want %f", estimate.MarginRatio, tt.wantRatio)
+			}
+			if estimate.Bracket != tt.wantBracket {
+				t.Errorf("bracket = %d, want %d", estimate.Bracket, tt.wantBracket)
+			}
+		})
+	}
+
+	if _, err := ComputeMargin(nil, MarginInput{Size: 1, EntryPrice: 1, Leverage: 1}); err == nil {
+		t.Error("missing brackets should be an error")
+	}
+}
+
+func TestStopBeyondLiquidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		direction   string
+		stopLoss    float64
+		liquidation float64
+		want        bool
+	}{
+		{"long stop above liquidation", "LONG", 9500, 9150, false},
+		{"long stop below liquidation", "LONG", 9000, 9150, true},
+		{"long stop at liquidation", "LONG", 9150, 9150, true},
+		{"short stop below liquidation", "SHORT", 10500, 10800, false},
+		{"short stop above liquidation", "SHORT", 11000, 10800, true},
+		{"no stop", "LONG", 0, 9150, false},
+		{"no liquidation price", "LONG", 9000, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StopBeyondLiquidation(tt.direction, tt.stopLoss, tt.liquidation); got != tt.want {
+				t.Errorf("StopBeyondLiquidation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bracketExchange serves leverage brackets; the other Exchange methods are unused
+type bracketExchange struct {
+	Exchange
+	brackets []LeverageBracket
+	err      error
+	calls    *int
+}
+
+func (e bracketExchange) GetLeverageBrackets(symbol string) ([]LeverageBracket, error) {
+	*e.calls++
+	return e.brackets, e.err
+}
+
+func TestMarginServiceBrackets(t *testing.T) {
+	venueBrackets := []LeverageBracket{{Bracket: 1, InitialLeverage: 100, NotionalCap: 1e9, MaintMarginRatio: 0.004}}
+
+	tests := []struct {
+		name            string
+		factoryErr      error
+		brackets        []LeverageBracket
+		bracketsErr     error
+		wantApproximate bool
+		wantCalls       int // fetches over two lookups
+	}{
+		{name: "venue brackets are cached", brackets: venueBrackets, wantCalls: 1},
+		{name: "fetch failure falls back to defaults", bracketsErr: errors.New("timeout"), wantApproximate: true, wantCalls: 2},
+		{name: "empty answer falls back to defaults", wantApproximate: true, wantCalls: 2},
+		{name: "unknown venue falls back to defaults", factoryErr: errors.New("unsupported venue"), wantApproximate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			service := NewMarginService(func(venue string, isTestnet bool) (Exchange, error) {
+				if tt.factoryErr != nil {
+					return nil, tt.factoryErr
+				}
+				return bracketExchange{brackets: tt.brackets, err: tt.bracketsErr, calls: &calls}, nil
+			})
+
+			for i := 0; i < 2; i++ {
+				brackets, approximate := service.Brackets(VenueBybit, "BTCUSDT", false)
+				if approximate != tt.wantApproximate {
+					t.Fatalf("approximate = %v, want %v", approximate, tt.wantApproximate)
+				}
+				want := venueBrackets
+				if tt.wantApproximate {
+					want = defaultLeverageBrackets
+				}
+				if len(brackets) != len(want) || brackets[0] != want[0] {
+					t.Fatalf("got brackets %+v, want %+v", brackets, want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("brackets fetched %d times, want %d", calls, tt.wantCalls)
+			}
+
+			estimate, err := service.Estimate(MarginInput{Venue: VenueBybit, Symbol: "BTCUSDT", Direction: "LONG", Size: 1, EntryPrice: 10000, Leverage: 10}, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if estimate.Approximate != tt.wantApproximate {
+				t.Errorf("estimate approximate = %v, want %v", estimate.Approximate, tt.wantApproximate)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Meta modes: how the agents' signals are combined into the final one
+const (
+	MetaModeLLM           = "llm"           // the meta-agent prompt decides
+	MetaModeDeterministic = "deterministic" // AggregateSignals decides
+	MetaModeLLMVeto       = "llm_veto"      // the meta-agent decides, AggregateSignals can veto the trade
+)
+
+// Price policies choosing entry, SL and TP from the agreeing agents
+const (
+	PricePolicyHighestConfidence = "highest_confidence" // prices of the most confident agreeing agent
+	PricePolicyWeighted          = "weighted"           // confidence-weighted average of the agreeing agents
+	PricePolicyConservative      = "conservative"       // nearest TP and widest SL of the agreeing agents
+)
+
+// Consensus rules of AggregateSignals, mirroring the meta-agent prompt
+const (
+	MetaRuleAllAgree    = "all_agree"
+	MetaRuleMajority    = "majority"
+	MetaRuleLoneAgent   = "lone_agent"
+	MetaRuleNoConsensus = "no_consensus"
+)
+
+// Consensus thresholds
+const (
+	allAgreeConfidence = 60
+	majorityConfidence = 70 // average of the majority
+	loneConfidence     = 90
+	strongOpposition   = 60 // an opposing agent above this blocks the majority and lone agent rules
+)
+
+// MetaDecision is the outcome of the deterministic aggregation
+type MetaDecision struct {
+	Rule   string   // one of the MetaRule constants
+	Agents []string // agents whose prices were used; empty when there is no trade
+	Signal *AgentSignal
+}
+
+// Trade reports whether a rule allowed a trade
+func (d *MetaDecision) Trade() bool {
+	return d.Rule != MetaRuleNoConsensus
+}
+
+// resolveMetaMode validates the requested mode and price policy, defaulting to
+// META_MODE / META_PRICE_POLICY and then to llm / highest_confidence
+func resolveMetaMode(mode, policy string) (string, string, error) {
+	if mode == "" {
+		mode = os.Getenv("META_MODE")
+	}
+	if mode == "" {
+		mode = MetaModeLLM
+	}
+	if policy == "" {
+		policy = os.Getenv("META_PRICE_POLICY")
+	}
+	if policy == "" {
+		policy = PricePolicyHighestConfidence
+	}
+
+	if !containsString([]string{MetaModeLLM, MetaModeDeterministic, MetaModeLLMVeto}, mode) {
+		return "", "", fmt.Errorf("%w: unknown meta mode %s, use llm, deterministic or llm_veto", ErrInvalidAgentConfig, mode)
+	}
+	if !containsString([]string{PricePolicyHighestConfidence, PricePolicyWeighted, PricePolicyConservative}, policy) {
+		return "", "", fmt.Errorf("%w: unknown price policy %s, use highest_confidence, weighted or conservative", ErrInvalidAgentConfig, policy)
+	}
+	return mode, policy, nil
+}
+
+// AggregateSignals applies the meta-agent consensus rules to the agents' signals:
+// take the trade when all agents agree with confidence above 60, when a majority
+// agrees with an average confidence above 70, or when a lone agent is above 90.
+// An agent opposing with confidence above 60 blocks the last two rules. Agents
+// with confidence 0 or without prices have no setup and vote for nothing.
+func AggregateSignals(symbol string, outputs []AgentOutput, policy string) (*MetaDecision, error) {
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no agent outputs to aggregate")
+	}
+
+	signals := make([]*AgentSignal, len(outputs))
+	votes := map[string][]int{}
+	for i, out := range outputs {
+		parsed, err := ParseAgentSignal(out.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("%s agent output: %w", out.Name, err)
+		}
+		signals[i] = parsed
+		if hasSetup(parsed) {
+			votes[parsed.Direction] = append(votes[parsed.Direction], i)
+		}
+	}
+
+	opposed := func(direction string) bool {
+		for dir, idx := range votes {
+			if dir == direction {
+				continue
+			}
+			for _, i := range idx {
+				if signals[i].Confidence > strongOpposition {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	rule, agreeing := MetaRuleNoConsensus, []int(nil)
+	for _, direction := range []string{"LONG", "SHORT"} {
+		idx := votes[direction]
+		switch {
+		case len(idx) == len(signals) && minConfidence(signals, idx) > allAgreeConfidence:
+			rule, agreeing = MetaRuleAllAgree, idx
+		case len(idx)*2 > len(signals) && averageConfidence(signals, idx) > majorityConfidence && !opposed(direction):
+			rule, agreeing = MetaRuleMajority, idx
+		}
+		if agreeing != nil {
+			break
+		}
+	}
+	if agreeing == nil {
+		best := -1
+		for _, idx := range votes {
+			for _, i := range idx {
+				if signals[i].Confidence > loneConfidence && (best < 0 || signals[i].Confidence > signals[best].Confidence) && !opposed(signals[i].Direction) {
+					best = i
+				}
+			}
+		}
+		if best >= 0 {
+			rule, agreeing = MetaRuleLoneAgent, []int{best}
+		}
+	}
+
+	decision := &MetaDecision{Rule: rule}
+	if agreeing == nil {
+		decision.Signal = &AgentSignal{
+			Symbol:    symbol,
+			Direction: likelyDirection(signals),
+			Thoughts:  "No consensus among the agents.\n\n" + summarizeAgents(outputs, signals),
+		}
+		return decision, nil
+	}
+
+	signal := pickPrices(signals, agreeing, policy)
+	signal.Symbol = symbol
+	signal.Confidence = int(math.Round(averageConfidence(signals, agreeing)))
+	for _, i := range agreeing {
+		decision.Agents = append(decision.Agents, outputs[i].Name)
+	}
+	signal.Thoughts = fmt.Sprintf("Consensus rule %s on %s from the %s agents; prices use the %s policy.\n\n%s",
+		rule, signal.Direction, strings.Join(decision.Agents, ", "), policy, summarizeAgents(outputs, signals))
+	decision.Signal = signal
+	return decision, nil
+}
+
+// vetoSignal applies a deterministic decision to the meta-agent's signal. A trade
+// the rules do not allow, or in the other direction, becomes a no setup signal;
+// otherwise the meta-agent's signal is kept. It reports whether it vetoed.
+func vetoSignal(signal *AgentSignal, decision *MetaDecision) bool {
+	if !hasSetup(signal) {
+		return false
+	}
+	reason := ""
+	switch {
+	case !decision.Trade():
+		reason = "the consensus rules allow no trade"
+	case decision.Signal.Direction != signal.Direction:
+		reason = fmt.Sprintf("the consensus rules pick %s (%s)", decision.Signal.Direction, decision.Rule)
+	default:
+		return false
+	}
+
+	signal.Thoughts = fmt.Sprintf("Vetoed %s at confidence %d: %s. Meta-agent reasoning: %s", signal.Direction, signal.Confidence, reason, signal.Thoughts)
+	signal.Entry, signal.SL, signal.TP, signal.RR, signal.Confidence = 0, 0, 0, 0, 0
+	return true
+}
+
+// pickPrices builds entry, SL and TP from the agreeing agents
+func pickPrices(signals []*AgentSignal, agreeing []int, policy string) *AgentSignal {
+	first := signals[agreeing[0]]
+	out := &AgentSignal{Direction: first.Direction}
+
+	switch policy {
+	case PricePolicyWeighted:
+		weight := 0.0
+		for _, i := range agreeing {
+			w := float64(signals[i].Confidence)
+			out.Entry += signals[i].Entry * w
+			out.SL += signals[i].SL * w
+			out.TP += signals[i].TP * w
+			weight += w
+		}
+		out.Entry, out.SL, out.TP = out.Entry/weight, out.SL/weight, out.TP/weight
+	case PricePolicyConservative:
+		out.Entry, out.SL, out.TP = first.Entry, first.SL, first.TP
+		for _, i := range agreeing[1:] {
+			s := signals[i]
+			out.Entry += s.Entry
+			if out.Direction == "LONG" {
+				out.SL, out.TP = math.Min(out.SL, s.SL), math.Min(out.TP, s.TP)
+			} else {
+				out.SL, out.TP = math.Max(out.SL, s.SL), math.Max(out.TP, s.TP)
+			}
+		}
+		out.Entry /= float64(len(agreeing))
+	default:
+		best := first
+		for _, i := range agreeing[1:] {
+			if signals[i].Confidence > best.Confidence {
+				best = signals[i]
+			}
+		}
+		out.Entry, out.SL, out.TP = best.Entry, best.SL, best.TP
+	}
+
+	if risk := math.Abs(out.Entry - out.SL); risk > 0 {
+		out.RR = math.Round(math.Abs(out.TP-out.Entry)/risk*100) / 100
+	}
+	return out
+}
+
+// hasSetup reports whether an agent proposed a trade
+func hasSetup(s *AgentSignal) bool {
+	return s.Confidence > 0 && s.Entry > 0 && s.SL > 0 && s.TP > 0
+}
+
+func minConfidence(signals []*AgentSignal, idx []int) int {
+	lowest := 100
+	for _, i := range idx {
+		lowest = min(lowest, signals[i].Confidence)
+	}
+	return lowest
+}
+
+func averageConfidence(signals []*AgentSignal, idx []int) float64 {
+	if len(idx) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, i := range idx {
+		sum += signals[i].Confidence
+	}
+	return float64(sum) / float64(len(idx))
+}
+
+// likelyDirection is the direction with the most total confidence, LONG on a tie
+func likelyDirection(signals []*AgentSignal) string {
+	long, short := 0, 0
+	for _, s := range signals {
+		if s.Direction == "SHORT" {
+			short += s.Confidence
+		} else {
+			long += s.Confidence
+		}
+	}
+	if short > long {
+		return "SHORT"
+	}
+	return "LONG"
+}
+
+// summarizeAgents lists each agent's call and reasoning for the final thoughts
+func summarizeAgents(outputs []AgentOutput, signals []*AgentSignal) string {
+	parts := make([]string, len(signals))
+	for i, s := range signals {
+		call := "no setup"
+		if hasSetup(s) {
+			call = fmt.Sprintf("%s at confidence %d", s.Direction, s.Confidence)
+		}
+		parts[i] = fmt.Sprintf("%s agent: %s. %s", agentTitle(outputs[i].Name), call, s.Thoughts)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// agentOutput writes a signal as an agent would
+func agentOutput(name, direction string, entry, sl, tp float64, confidence int) AgentOutput {
+	raw, _ := json.Marshal(AgentSignal{Symbol: "BTCUSDT", Direction: direction, Entry: entry, SL: sl, TP: tp, Confidence: confidence, Thoughts: name + " reasoning"})
+	return AgentOutput{Name: name, JSON: string(raw)}
+}
+
+func noSetupOutput(name string) AgentOutput {
+	return agentOutput(name, "LONG", 0, 0, 0, 0)
+}
+
+func TestAggregateSignals(t *testing.T) {
+	tests := []struct {
+		name           string
+		outputs        []AgentOutput
+		wantRule       string
+		wantAgents     string
+		wantDirection  string
+		wantConfidence int
+		wantEntry      float64
+	}{
+		{
+			name: "all agree above 60",
+			outputs: []AgentOutput{
+				agentOutput("trend", "LONG", 100, 95, 110, 65),
+				agentOutput("reversal", "LONG", 101, 96, 111, 70),
+				agentOutput("volume", "LONG", 102, 97, 112, 80),
+			},
+			wantRule:       MetaRuleAllAgree,
+			wantAgents:     "trend,reversal,volume",
+			wantDirection:  "LONG",
+			wantConfidence: 72,
+			wantEntry:      102,
+		},
+		{
+			name: "agreement at exactly 60 is not enough",
+			outputs: []AgentOutput{
+				agentOutput("trend", "LONG", 100, 95, 110, 60),
+				agentOutput("reversal", "LONG", 100, 95, 110, 70),
+				agentOutput("volume", "LONG", 100, 95, 110, 80),
+			},
+			wantRule:      MetaRuleNoConsensus,
+			wantDirection: "LONG",
+		},
+		{
+			name: "majority above 70 against a weak opponent",
+			outputs: []AgentOutput{
+				agentOutput("trend", "SHORT", 100, 105, 90, 80),
+				agentOutput("reversal", "SHORT", 100, 104, 92, 75),
+				agentOutput("volume", "LONG", 100, 95, 110, 50),
+			},
+			wantRule:       MetaRuleMajority,
+			wantAgents:     "trend,reversal",
+			wantDirection:  "SHORT",
+			wantConfidence: 78,
+			wantEntry:      100,
+		},
+		{
+			name: "a strong opponent blocks the majority",
+			outputs: []AgentOutput{
+				agentOutput("trend", "SHORT", 100, 105, 90, 80),
+				agentOutput("reversal", "SHORT", 100, 104, 92, 75),
+				agentOutput("volume", "LONG", 100, 95, 110, 65),
+			},
+			wantRule:      MetaRuleNoConsensus,
+			wantDirection: "SHORT",
+		},
+		{
+			name: "lone agent above 90",
+			outputs: []AgentOutput{
+				agentOutput("trend", "LONG", 100, 95, 110, 95),
+				noSetupOutput("reversal"),
+				noSetupOutput("volume"),
+			},
+			wantRule:       MetaRuleLoneAgent,
+			wantAgents:     "trend",
+			wantDirection:  "LONG",
+			wantConfidence: 95,
+			wantEntry:      100,
+		},
+		{
+			name: "a strong opponent blocks the lone agent",
+			outputs: []AgentOutput{
+				agentOutput("trend", "LONG", 100, 95, 110, 95),
+				agentOutput("reversal", "SHORT", 100, 105, 90, 70),
+				noSetupOutput("volume"),
+			},
+			wantRule:      MetaRuleNoConsensus,
+			wantDirection: "LONG",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := AggregateSignals("BTCUSDT", tt.outputs, PricePolicyHighestConfidence)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision.Rule != tt.wantRule || strings.Join(decision.Agents, ",") != tt.wantAgents {
+				t.Fatalf("rule %s from %v, want %s from %s", decision.Rule, decision.Agents, tt.wantRule, tt.wantAgents)
+			}
+			s := decision.Signal
+			if s.Symbol != "BTCUSDT" || s.Direction != tt.wantDirection || s.Confidence != tt.wantConfidence || s.Entry != tt.wantEntry {
+				t.Errorf("signal = %+v, want %s at confidence %d, entry %g", s, tt.wantDirection, tt.wantConfidence, tt.wantEntry)
+			}
+			if decision.Trade() != (tt.wantRule != MetaRuleNoConsensus) {
+				t.Errorf("Trade() = %v for rule %s", decision.Trade(), decision.Rule)
+			}
+			// Whatever the outcome, the answer must still be a valid signal
+			raw, _ := json.Marshal(s)
+			if _, err := ParseAgentSignal(string(raw)); err != nil {
+				t.Errorf("aggregated signal is not valid: %v", err)
+			}
+		})
+	}
+
+	if _, err := AggregateSignals("BTCUSDT", nil, PricePolicyHighestConfidence); err == nil {
+		t.Error("no outputs should be an error")
+	}
+	broken := []AgentOutput{{Name: "trend", JSON: "no idea"}}
+	if _, err := AggregateSignals("BTCUSDT", broken, PricePolicyHighestConfidence); err == nil || !strings.Contains(err.Error(), "trend agent") {
+		t.Errorf("error = %v, want one naming the trend agent", err)
+	}
+}
+
+func TestAggregateSignalsPricePolicies(t *testing.T) {
+	longs := []AgentOutput{
+		agentOutput("trend", "LONG", 100, 95, 110, 80),
+		agentOutput("volume", "LONG", 102, 96, 108, 70),
+	}
+	shorts := []AgentOutput{
+		agentOutput("trend", "SHORT", 100, 105, 90, 80),
+		agentOutput("volume", "SHORT", 100, 104, 92, 70),
+	}
+
+	tests := []struct {
+		name                      string
+		policy                    string
+		outputs                   []AgentOutput
+		wantEntry, wantSL, wantTP float64
+		wantRR                    float64
+	}{
+		{"highest confidence", PricePolicyHighestConfidence, longs, 100, 95, 110, 2},
+		{"weighted", PricePolicyWeighted, longs, 15140.0 / 150, 14320.0 / 150, 16360.0 / 150, 1.49},
+		{"conservative long: widest stop, nearest target", PricePolicyConservative, longs, 101, 95, 108, 1.17},
+		{"conservative short", PricePolicyConservative, shorts, 100, 105, 92, 1.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := AggregateSignals("BTCUSDT", tt.outputs, tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s := decision.Signal
+			for _, pair := range [][2]float64{{s.Entry, tt.wantEntry}, {s.SL, tt.wantSL}, {s.TP, tt.wantTP}, {s.RR, tt.wantRR}} {
+				if math.Abs(pair[0]-pair[1]) > 1e-9 {
+					t.Fatalf("entry %g, sl %g, tp %g, rr %g; want %g, %g, %g, %g",
+						s.Entry, s.SL, s.TP, s.RR, tt.wantEntry, tt.wantSL, tt.wantTP, tt.wantRR)
+				}
+			}
+		})
+	}
+}
+
+func TestVetoSignal(t *testing.T) {
+	longDecision := &MetaDecision{Rule: MetaRuleMajority, Signal: &AgentSignal{Direction: "LONG"}}
+	shortDecision := &MetaDecision{Rule: MetaRuleLoneAgent, Signal: &AgentSignal{Direction: "SHORT"}}
+	noTrade := &MetaDecision{Rule: MetaRuleNoConsensus, Signal: &AgentSignal{Direction: "LONG"}}
+
+	tests := []struct {
+		name       string
+		signal     AgentSignal
+		decision   *MetaDecision
+		wantVeto   bool
+		wantReason string
+	}{
+		{name: "agreeing trade is kept", signal: AgentSignal{Direction: "LONG", Entry: 100, SL: 95, TP: 110, RR: 2, Confidence: 70}, decision: longDecision},
+		{name: "no setup is left alone", signal: AgentSignal{Direction: "LONG"}, decision: noTrade},
+		{
+			name:       "trade without consensus",
+			signal:     AgentSignal{Direction: "LONG", Entry: 100, SL: 95, TP: 110, RR: 2, Confidence: 70, Thoughts: "looks good"},
+			decision:   noTrade,
+			wantVeto:   true,
+			wantReason: "Vetoed LONG at confidence 70: the consensus rules allow no trade. Meta-agent reasoning: looks good",
+		},
+		{
+			name:       "trade against the consensus",
+			signal:     AgentSignal{Direction: "LONG", Entry: 100, SL: 95, TP: 110, RR: 2, Confidence: 70, Thoughts: "looks good"},
+			decision:   shortDecision,
+			wantVeto:   true,
+			wantReason: "Vetoed LONG at confidence 70: the consensus rules pick SHORT (lone_agent). Meta-agent reasoning: looks good",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := tt.signal
+			if got := vetoSignal(&signal, tt.decision); got != tt.wantVeto {
+				t.Fatalf("vetoSignal = %v, want %v", got, tt.wantVeto)
+			}
+			if !tt.wantVeto {
+				if signal != tt.signal {
+					t.Errorf("signal changed to %+v", signal)
+				}
+				return
+			}
+			if hasSetup(&signal) || signal.RR != 0 || signal.Thoughts != tt.wantReason {
+				t.Errorf("vetoed signal = %+v, want no setup with thoughts %q", signal, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxBufferedDepthEvents caps the diff events held while waiting for a snapshot
+const maxBufferedDepthEvents = 1000
+
+// DepthSnapshot is the /fapi/v1/depth REST response
+type DepthSnapshot struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
+
+// PriceLevel is a single price/quantity level of the order book
+type PriceLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// OrderBookWall is a resting level that is large relative to its neighbours
+type OrderBookWall struct {
+	Price       float64 `json:"price"`
+	Quantity    float64 `json:"quantity"`
+	DistancePct float64 `json:"distancePct"`
+}
+
+// DepthImbalance compares resting bid and ask size within a band around the mid price
+type DepthImbalance struct {
+	BandPct   float64 `json:"bandPct"`
+	BidVolume float64 `json:"bidVolume"`
+	AskVolume float64 `json:"askVolume"`
+	Imbalance float64 `json:"imbalance"` // (bid-ask)/(bid+ask), positive = bid-heavy
+}
+
+// OrderBookFeatures is a compact summary of the order book fed to the Volume agent
+type OrderBookFeatures struct {
+	Symbol     string           `json:"symbol"`
+	BestBid    float64          `json:"bestBid"`
+	BestAsk    float64          `json:"bestAsk"`
+	MidPrice   float64          `json:"midPrice"`
+	Spread     float64          `json:"spread"`
+	SpreadBps  float64          `json:"spreadBps"`
+	Imbalances []DepthImbalance `json:"imbalances"`
+	BidWalls   []OrderBookWall  `json:"bidWalls"`
+	AskWalls   []OrderBookWall  `json:"askWalls"`
+	Levels     int              `json:"levels"`
+	FromStream bool             `json:"fromStream"`
+}
+
+// depthEvent is a diff-depth stream update
+type depthEvent struct {
+	Symbol        string     `json:"s"`
+	FirstUpdateID int64      `json:"U"`
+	FinalUpdateID int64      `json:"u"`
+	PrevUpdateID  int64      `json:"pu"`
+	Bids          [][]string `json:"b"`
+	Asks          [][]string `json:"a"`
+}
+
+// localOrderBook is maintained from a REST snapshot plus diff-depth events,
+// following Binance's "manage a local order book correctly" procedure
+type localOrderBook struct {
+	bids         map[float64]float64
+	asks         map[float64]float64
+	lastUpdateID int64
+	initialized  bool // snapshot applied
+	synced       bool // first diff event after the snapshot applied
+	buffered     []depthEvent
+}
+
+func newLocalOrderBook() *localOrderBook {
+	return &localOrderBook{
+		bids: make(map[float64]float64),
+		asks: make(map[float64]float64),
+	}
+}
+
+// applySnapshot resets the book from a REST snapshot and replays buffered events
+func (b *localOrderBook) applySnapshot(snapshot *DepthSnapshot) {
+	b.bids = make(map[float64]float64, len(snapshot.Bids))
+	b.asks = make(map[float64]float64, len(snapshot.Asks))
+	applyLevels(b.bids, snapshot.Bids)
+	applyLevels(b.asks, snapshot.Asks)
+	b.lastUpdateID = snapshot.LastUpdateID
+	b.initialized = true
+	b.synced = false
+
+	buffered := b.buffered
+	b.buffered = nil
+	for _, event := range buffered {
+		if !b.apply(event) {
+			return
+		}
+	}
+}
+
+// apply processes one diff event and reports whether the book is still consistent
+func (b *localOrderBook) apply(event depthEvent) bool {
+	if !b.initialized {
+		b.buffered = append(b.buffered, event)
+		if len(b.buffered) > maxBufferedDepthEvents {
+			b.buffered = b.buffered[len(b.buffered)-maxBufferedDepthEvents:]
+		}
+		return true
+	}
+
+	// Drop events that are already reflected in the snapshot
+	if event.FinalUpdateID < b.lastUpdateID {
+		return true
+	}
+
+	if !b.synced {
+		if event.FirstUpdateID > b.lastUpdateID {
+			// Snapshot is older than the stream; it has to be refetched
+			b.reset()
+			return false
+		}
+	} else if event.PrevUpdateID != b.lastUpdateID {
+		b.reset()
+		return false
+	}
+
+	applyLevels(b.bids, event.Bids)
+	applyLevels(b.asks, event.Asks)
+	b.lastUpdateID = event.FinalUpdateID
+	b.synced = true
+	return true
+}
+
+func (b *localOrderBook) reset() {
+	b.initialized = false
+	b.synced = false
+	b.buffered = nil
+}
+
+func applyLevels(side map[float64]float64, levels [][]string) {
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, err := strconv.ParseFloat(level[0], 64)
+		if err != nil {
+			continue
+		}
+		qty, err := strconv.ParseFloat(level[1], 64)
+		if err != nil {
+			continue
+		}
+		if qty == 0 {
+			delete(side, price)
+		} else {
+			side[price] = qty
+		}
+	}
+}
+
+// sortedLevels returns the levels of one side, best price first
+func sortedLevels(side map[float64]float64, descending bool) []PriceLevel {
+	levels := make([]PriceLevel, 0, len(side))
+	for price, qty := range side {
+		levels = append(levels, PriceLevel{Price: price, Quantity: qty})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].Price > levels[j].Price
+		}
+		return levels[i].Price < levels[j].Price
+	})
+	return levels
+}
+
+// ComputeOrderBookFeatures derives spread, depth imbalance and walls from sorted bid/ask levels
+func ComputeOrderBookFeatures(symbol string, bids, asks []PriceLevel) (*OrderBookFeatures, error) {
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil, fmt.Errorf("order book for %s is empty", symbol)
+	}
+
+	bestBid := bids[0].Price
+	bestAsk := asks[0].Price
+	mid := (bestBid + bestAsk) / 2
+
+	features := &OrderBookFeatures{
+		Symbol:    symbol,
+		BestBid:   bestBid,
+		BestAsk:   bestAsk,
+		MidPrice:  mid,
+		Spread:    bestAsk - bestBid,
+		SpreadBps: (bestAsk - bestBid) / mid * 10000,
+		Levels:    len(bids) + len(asks),
+	}
+
+	for _, band := range []float64{0.25, 0.5, 1, 2} {
+		bidVol := depthWithin(bids, mid, band)
+		askVol := depthWithin(asks, mid, band)
+		imbalance := 0.0
+		if bidVol+askVol > 0 {
+			imbalance = (bidVol - askVol) / (bidVol + askVol)
+		}
+		features.Imbalances = append(features.Imbalances, DepthImbalance{
+			BandPct:   band,
+			BidVolume: bidVol,
+			AskVolume: askVol,
+			Imbalance: imbalance,
+		})
+	}
+
+	features.BidWalls = findWalls(bids, mid, 2, 5, 3)
+	features.AskWalls = findWalls(asks, mid, 2, 5, 3)
+
+	return features, nil
+}
+
+func depthWithin(levels []PriceLevel, mid, bandPct float64) float64 {
+	total := 0.0
+	for _, level := range levels {
+		if math.Abs(level.Price-mid)/mid*100 > bandPct {
+			break
+		}
+		total += level.Quantity
+	}
+	return total
+}
+
+// findWalls returns up to maxWalls levels within bandPct of mid whose size is at
+// least multiplier times the average level size in that band, largest first
+func findWalls(levels []PriceLevel, mid, bandPct, multiplier float64, maxWalls int) []OrderBookWall {
+	var inBand []PriceLevel
+	total := 0.0
+	for _, level := range levels {
+		if math.Abs(level.Price-mid)/mid*100 > bandPct {
+			break
+		}
+		inBand = append(inBand, level)
+		total += level.Quantity
+	}
+	if len(inBand) == 0 {
+		return []OrderBookWall{}
+	}
+
+	avg := total / float64(len(inBand))
+	walls := []OrderBookWall{}
+	for _, level := range inBand {
+		if level.Quantity >= avg*multiplier {
+			walls = append(walls, OrderBookWall{
+				Price:       level.Price,
+				Quantity:    level.Quantity,
+				DistancePct: (level.Price - mid) / mid * 100,
+			})
+		}
+	}
+	sort.Slice(walls, func(i, j int) bool {
+		return walls[i].Quantity > walls[j].Quantity
+	})
+	if len(walls) > maxWalls {
+		walls = walls[:maxWalls]
+	}
+
+	return walls
+}
+
+// formatOrderBookSection renders order book features as a compact prompt block
+func formatOrderBookSection(features *OrderBookFeatures) string {
+	if features == nil {
+		return "Order Book: not available\n\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Order Book (%s, %d levels):\n", features.Symbol, features.Levels))
+	sb.WriteString(fmt.Sprintf("best_bid: %.6f, best_ask: %.6f, spread: %.6f (%.2f bps)\n",
+		features.BestBid, features.BestAsk, features.Spread, features.SpreadBps))
+
+	sb.WriteString("imbalance (bid-ask)/(bid+ask), positive = bid-heavy:")
+	for i, imb := range features.Imbalances {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(fmt.Sprintf(" ±%.2f%%: %+.2f (bid %.2f / ask %.2f)", imb.BandPct, imb.Imbalance, imb.BidVolume, imb.AskVolume))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("bid_walls: " + formatWalls(features.BidWalls) + "\n")
+	sb.WriteString("ask_walls: " + formatWalls(features.AskWalls) + "\n\n")
+
+	return sb.String()
+}
+
+func formatWalls(walls []OrderBookWall) string {
+	if len(walls) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(walls))
+	for i, w := range walls {
+		parts[i] = fmt.Sprintf("%.6f x %.2f (%+.2f%%)", w.Price, w.Quantity, w.DistancePct)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLocalOrderBookSync(t *testing.T) {
+	snapshot := &DepthSnapshot{
+		LastUpdateID: 100,
+		Bids:         [][]string{{"99", "1"}, {"98", "2"}},
+		Asks:         [][]string{{"101", "1"}},
+	}
+
+	tests := []struct {
+		name       string
+		before     []depthEvent // sent before the snapshot arrives
+		after      []depthEvent
+		wantOK     bool // result of the last event after the snapshot
+		wantSynced bool
+		wantLastID int64
+		wantBids   map[float64]float64
+	}{
+		{
+			name:       "buffered event straddling the snapshot is replayed",
+			before:     []depthEvent{{FirstUpdateID: 95, FinalUpdateID: 105, Bids: [][]string{{"99", "3"}}}},
+			wantOK:     true,
+			wantSynced: true,
+			wantLastID: 105,
+			wantBids:   map[float64]float64{99: 3, 98: 2},
+		},
+		{
+			name:       "event already in the snapshot is dropped",
+			after:      []depthEvent{{FirstUpdateID: 90, FinalUpdateID: 99, Bids: [][]string{{"99", "5"}}}},
+			wantOK:     true,
+			wantLastID: 100,
+			wantBids:   map[float64]float64{99: 1, 98: 2},
+		},
+		{
+			name:       "snapshot older than the stream is discarded",
+			after:      []depthEvent{{FirstUpdateID: 102, FinalUpdateID: 110}},
+			wantOK:     false,
+			wantLastID: 100,
+			wantBids:   map[float64]float64{99: 1, 98: 2},
+		},
+		{
+			name: "consecutive events apply and zero quantities delete",
+			after: []depthEvent{
+				{FirstUpdateID: 99, FinalUpdateID: 101, Bids: [][]string{{"97", "4"}}},
+				{FirstUpdateID: 102, FinalUpdateID: 103, PrevUpdateID: 101, Bids: [][]string{{"98", "0"}}},
+			},
+			wantOK:     true,
+			wantSynced: true,
+			wantLastID: 103,
+			wantBids:   map[float64]float64{99: 1, 97: 4},
+		},
+		{
+			name: "a missed event resets the book",
+			after: []depthEvent{
+				{FirstUpdateID: 99, FinalUpdateID: 101},
+				{FirstUpdateID: 105, FinalUpdateID: 106, PrevUpdateID: 104},
+			},
+			wantOK:     false,
+			wantLastID: 101,
+			wantBids:   map[float64]float64{99: 1, 98: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := newLocalOrderBook()
+			for _, event := range tt.before {
+				book.apply(event)
+			}
+			book.applySnapshot(snapshot)
+			ok := true
+			for _, event := range tt.after {
+				ok = book.apply(event)
+			}
+
+			if ok != tt.wantOK {
+				t.Fatalf("apply returned %v, want %v", ok, tt.wantOK)
+			}
+			if book.initialized != tt.wantOK {
+				t.Errorf("initialized = %v, want %v", book.initialized, tt.wantOK)
+			}
+			if book.synced != tt.wantSynced {
+				t.Errorf("synced = %v, want %v", book.synced, tt.wantSynced)
+			}
+			if book.lastUpdateID != tt.wantLastID {
+				t.Errorf("lastUpdateID = %d, want %d", book.lastUpdateID, tt.wantLastID)
+			}
+			if len(book.bids) != len(tt.wantBids) {
+				t.Fatalf("bids = %v, want %v", book.bids, tt.wantBids)
+			}
+			for price, qty := range tt.wantBids {
+				if book.bids[price] != qty {
+					t.Errorf("bid %g = %g, want %g", price, book.bids[price], qty)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalOrderBookBuffersUntilSnapshot(t *testing.T) {
+	book := newLocalOrderBook()
+	for i := 0; i < maxBufferedDepthEvents+10; i++ {
+		if !book.apply(depthEvent{FirstUpdateID: int64(i), FinalUpdateID: int64(i)}) {
+			t.Fatal("events before the snapshot should be buffered")
+		}
+	}
+	if len(book.buffered) != maxBufferedDepthEvents {
+		t.Fatalf("buffered %d events, want %d", len(book.buffered), maxBufferedDepthEvents)
+	}
+	if book.buffered[0].FinalUpdateID != 10 {
+		t.Fatalf("oldest buffered event is %d, want 10", book.buffered[0].FinalUpdateID)
+	}
+}
+
+func TestComputeOrderBookFeatures(t *testing.T) {
+	tests := []struct {
+		name          string
+		bids, asks    []PriceLevel
+		wantSpreadBps float64
+		wantImbalance []float64 // per band: 0.25%, 0.5%, 1%, 2%
+		wantBidWalls  []float64
+		wantAskWalls  []float64
+	}{
+		{
+			name:          "balanced book",
+			bids:          []PriceLevel{{Price: 99.9, Quantity: 1}, {Price: 98.5, Quantity: 1}},
+			asks:          []PriceLevel{{Price: 100.1, Quantity: 1}, {Price: 101.5, Quantity: 1}},
+			wantSpreadBps: 20,
+			wantImbalance: []float64{0, 0, 0, 0},
+		},
+		{
+			name:          "bid-heavy near the mid, ask-heavy further out",
+			bids:          []PriceLevel{{Price: 99.9, Quantity: 3}, {Price: 99.7, Quantity: 1}},
+			asks:          []PriceLevel{{Price: 100.1, Quantity: 1}, {Price: 101.5, Quantity: 7}},
+			wantSpreadBps: 20,
+			wantImbalance: []float64{0.5, 0.6, 0.6, -1.0 / 3},
+		},
+		{
+			name: "a level five times the band average is a wall",
+			bids: []PriceLevel{
+				{Price: 99.95, Quantity: 1}, {Price: 99.85, Quantity: 1}, {Price: 99.65, Quantity: 1},
+				{Price: 99.55, Quantity: 1}, {Price: 99.45, Quantity: 1}, {Price: 99.35, Quantity: 1},
+				{Price: 99.25, Quantity: 1}, {Price: 99.15, Quantity: 1}, {Price: 99.05, Quantity: 1},
+				{Price: 98.5, Quantity: 30},
+				{Price: 90, Quantity: 500}, // outside the 2% band
+			},
+			asks:          []PriceLevel{{Price: 100.05, Quantity: 1}},
+			wantSpreadBps: 10,
+			wantImbalance: []float64{1.0 / 3, 0.6, 0.8, 0.95},
+			wantBidWalls:  []float64{98.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features, err := ComputeOrderBookFeatures("BTCUSDT", tt.bids, tt.asks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(features.SpreadBps-tt.wantSpreadBps) > 1e-9 {
+				t.Errorf("spread = %g bps, want %g", features.SpreadBps, tt.wantSpreadBps)
+			}
+			if len(features.Imbalances) != len(tt.wantImbalance) {
+				t.Fatalf("got %d bands, want %d", len(features.Imbalances), len(tt.wantImbalance))
+			}
+			for i, want := range tt.wantImbalance {
+				if got := features.Imbalances[i].Imbalance; math.Abs(got-want) > 1e-9 {
+					t.Errorf("imbalance within %g%% = %g, want %g", features.Imbalances[i].BandPct, got, want)
+				}
+			}
+			checkWalls(t, "bid", features.BidWalls, tt.wantBidWalls)
+			checkWalls(t, "ask", features.AskWalls, tt.wantAskWalls)
+		})
+	}
+
+	if _, err := ComputeOrderBookFeatures("BTCUSDT", nil, []PriceLevel{{Price: 1, Quantity: 1}}); err == nil {
+		t.Error("an empty side should be an error")
+	}
+}
+
+func checkWalls(t *testing.T, side string, walls []OrderBookWall, want []float64) {
+	t.Helper()
+	if len(walls) != len(want) {
+		t.Fatalf("%s walls = %v, want prices %v", side, walls, want)
+	}
+	for i, price := range want {
+		if walls[i].Price != price {
+			t.Errorf("%s wall %d at %g, want %g", side, i, walls[i].Price, price)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScanCriteria configures how the symbol universe is filtered and ranked.
+// Zero values fall back to the defaults in DefaultScanCriteria.
+type ScanCriteria struct {
+	TopN           int     `json:"topN"`
+	MinQuoteVolume float64 `json:"minQuoteVolume"` // minimum 24h quote volume in USDT
+	Interval       string  `json:"interval"`       // candle interval used for ATR and volume spike
+	Lookback       int     `json:"lookback"`       // candles used for ATR and the volume average
+	PrefilterLimit int     `json:"prefilterLimit"` // most liquid symbols that get candle-based metrics
+
+	// Relative weight of each metric in the final score; 0 disables a metric
+	QuoteVolumeWeight float64 `json:"quoteVolumeWeight"`
+	VolatilityWeight  float64 `json:"volatilityWeight"`
+	VolumeSpikeWeight float64 `json:"volumeSpikeWeight"`
+	FundingWeight     float64 `json:"fundingWeight"`
+}
+
+// ScanCandidate is a ranked symbol with the metrics and reasons behind its score
+type ScanCandidate struct {
+	Symbol      string   `json:"symbol"`
+	Score       float64  `json:"score"`
+	Price       float64  `json:"price"`
+	QuoteVolume float64  `json:"quoteVolume"`
+	Change24h   float64  `json:"change24h"`
+	ATRPercent  float64  `json:"atrPercent"`
+	VolumeSpike float64  `json:"volumeSpike"` // last closed candle volume / average of the lookback
+	FundingRate float64  `json:"fundingRate"`
+	Reasons     []string `json:"reasons"`
+}
+
+// ScannerService ranks USDT-margined perpetuals to pick signal candidates automatically
+type ScannerService struct {
+	binanceService *BinanceService
+	exchangeInfo   *ExchangeInfoService
+}
+
+// DefaultScanCriteria returns the criteria used when a field is left unset
+func DefaultScanCriteria() ScanCriteria {
+	return ScanCriteria{
+		TopN:              10,
+		MinQuoteVolume:    50_000_000,
+		Interval:          "1h",
+		Lookback:          24,
+		PrefilterLimit:    40,
+		QuoteVolumeWeight: 1,
+		VolatilityWeight:  1,
+		VolumeSpikeWeight: 1,
+		FundingWeight:     1,
+	}
+}
+
+func NewScannerService(binanceService *BinanceService) *ScannerService {
+	return &ScannerService{
+		binanceService: binanceService,
+		exchangeInfo:   SharedExchangeInfo(false),
+	}
+}
+
+// Scan returns the top candidates of the USDT perpetual universe under the given criteria
+func (s *ScannerService) Scan(criteria ScanCriteria) ([]ScanCandidate, error) {
+	criteria = withScanDefaults(criteria)
+	if _, err := IntervalDuration(criteria.Interval); err != nil {
+		return nil, err
+	}
+
+	symbols, err := s.exchangeInfo.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange info: %w", err)
+	}
+	perpetuals := make(map[string]bool)
+	for _, info := range symbols {
+		if info.ContractType == "PERPETUAL" && info.QuoteAsset == "USDT" && info.IsTrading() {
+			perpetuals[info.Symbol] = true
+		}
+	}
+
+	tickers, err := s.binanceService.GetAllTickers()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*ScanCandidate
+	for _, t := range tickers {
+		if !perpetuals[t.Symbol] || t.QuoteVolume < criteria.MinQuoteVolume {
+			continue
+		}
+		candidates = append(candidates, &ScanCandidate{
+			Symbol:      t.Symbol,
+			Price:       t.Price,
+			QuoteVolume: t.QuoteVolume,
+			Change24h:   t.PriceChangePercent,
+		})
+	}
+	if len(candidates) == 0 {
+		return []ScanCandidate{}, nil
+	}
+
+	// Candle-based metrics cost one klines request per symbol, so only the most liquid get them
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].QuoteVolume > candidates[j].QuoteVolume
+	})
+	if len(candidates) > criteria.PrefilterLimit {
+		candidates = candidates[:criteria.PrefilterLimit]
+	}
+
+	if criteria.FundingWeight > 0 {
+		if premiums, err := s.binanceService.GetAllPremiumIndexes(); err == nil {
+			funding := make(map[string]float64, len(premiums))
+			for _, p := range premiums {
+				funding[p.Symbol] = p.LastFundingRate
+			}
+			for _, c := range candidates {
+				c.FundingRate = funding[c.Symbol]
+			}
+		}
+	}
+
+	if criteria.VolatilityWeight > 0 || criteria.VolumeSpikeWeight > 0 {
+		s.computeCandleMetrics(candidates, criteria)
+	}
+
+	scoreCandidates(candidates, criteria)
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > criteria.TopN {
+		candidates = candidates[:criteria.TopN]
+	}
+
+	result := make([]ScanCandidate, len(candidates))
+	for i, c := range candidates {
+		result[i] = *c
+	}
+
+	return result, nil
+}
+
+// computeCandleMetrics fills ATR% and volume spike using a small pool of workers
+func (s *ScannerService) computeCandleMetrics(candidates []*ScanCandidate, criteria ScanCriteria) {
+	jobs := make(chan *ScanCandidate)
+	var wg sync.WaitGroup
+
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				// REST only, so a scan does not subscribe the stream to every symbol it looks at
+				klines, err := s.binanceService.fetchKlines(c.Symbol, criteria.Interval, criteria.Lookback+2)
+				if err != nil {
+					continue
+				}
+				// Score the last closed candle; the forming one would make the result depend on when the scan runs
+				if n := len(klines); n > 0 && klines[n-1].CloseTime >= time.Now().UnixMilli() {
+					klines = klines[:n-1]
+				}
+				if len(klines) < 2 {
+					continue
+				}
+				last := klines[len(klines)-1]
+				if atr := averageTrueRange(klines, criteria.Lookback); atr > 0 && last.Close > 0 {
+					c.ATRPercent = atr / last.Close * 100
+				}
+
+				avgVolume := 0.0
+				for _, k := range klines[:len(klines)-1] {
+					avgVolume += k.Volume
+				}
+				avgVolume /= float64(len(klines) - 1)
+				if avgVolume > 0 {
+					c.VolumeSpike = last.Volume / avgVolume
+				}
+			}
+		}()
+	}
+
+	for _, c := range candidates {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// scoreCandidates ranks each metric as a percentile across the candidates and
+// combines them by weight. Metrics in the top quartile become reasons.
+func scoreCandidates(candidates []*ScanCandidate, criteria ScanCriteria) {
+	type metric struct {
+		weight float64
+		value  func(c *ScanCandidate) float64
+		reason func(c *ScanCandidate) string
+	}
+	metrics := []metric{
+		{criteria.QuoteVolumeWeight, func(c *ScanCandidate) float64 { return c.QuoteVolume }, func(c *ScanCandidate) string {
+			return fmt.Sprintf("24h quote volume %.0fM USDT", c.QuoteVolume/1e6)
+		}},
+		{criteria.VolatilityWeight, func(c *ScanCandidate) float64 { return c.ATRPercent }, func(c *ScanCandidate) string {
+			return fmt.Sprintf("ATR is %.2f%% of price on %s", c.ATRPercent, criteria.Interval)
+		}},
+		{criteria.VolumeSpikeWeight, func(c *ScanCandidate) float64 { return c.VolumeSpike }, func(c *ScanCandidate) string {
+			return fmt.Sprintf("last closed %s candle volume is %.1fx the %d-candle average", criteria.Interval, c.VolumeSpike, criteria.Lookback)
+		}},
+		{criteria.FundingWeight, func(c *ScanCandidate) float64 { return math.Abs(c.FundingRate) }, func(c *ScanCandidate) string {
+			side := "longs paying shorts"
+			if c.FundingRate < 0 {
+				side = "shorts paying longs"
+			}
+			return fmt.Sprintf("funding rate %.4f%% (%s)", c.FundingRate*100, side)
+		}},
+	}
+
+	totalWeight := 0.0
+	for _, m := range metrics {
+		if m.weight > 0 {
+			totalWeight += m.weight
+		}
+	}
+	if totalWeight == 0 {
+		return
+	}
+
+	for _, c := range candidates {
+		c.Reasons = []string{}
+	}
+
+	for _, m := range metrics {
+		if m.weight <= 0 {
+			continue
+		}
+		pcts := percentileRanks(candidates, m.value)
+		for i, c := range candidates {
+			c.Score += m.weight * pcts[i] / totalWeight * 100
+			if pcts[i] >= 0.75 && m.value(c) > 0 {
+				c.Reasons = append(c.Reasons, m.reason(c))
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		c.Score = math.Round(c.Score*100) / 100
+		if len(c.Reasons) == 0 {
+			c.Reasons = append(c.Reasons, "balanced scores across all criteria")
+		}
+	}
+}
+
+// percentileRanks maps each candidate's metric to its rank in [0, 1]
+func percentileRanks(candidates []*ScanCandidate, value func(c *ScanCandidate) float64) []float64 {
+	idx := make([]int, len(candidates))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return value(candidates[idx[a]]) < value(candidates[idx[b]])
+	})
+
+	ranks := make([]float64, len(candidates))
+	if len(candidates) == 1 {
+		ranks[0] = 1
+		return ranks
+	}
+	for rank, i := range idx {
+		ranks[i] = float64(rank) / float64(len(candidates)-1)
+	}
+	return ranks
+}
+
+// averageTrueRange is Wilder's ATR over the given period, or the mean true range
+// when fewer candles are available
+func averageTrueRange(klines []Kline, period int) float64 {
+	if len(klines) < 2 || period <= 0 {
+		return 0
+	}
+
+	trs := make([]float64, 0, len(klines)-1)
+	for i := 1; i < len(klines); i++ {
+		trs = append(trs, math.Max(klines[i].High-klines[i].Low,
+			math.Max(math.Abs(klines[i].High-klines[i-1].Close), math.Abs(klines[i].Low-klines[i-1].Close))))
+	}
+
+	n := period
+	if len(trs) < n {
+		n = len(trs)
+	}
+	atr := 0.0
+	for _, tr := range trs[:n] {
+		atr += tr
+	}
+	atr /= float64(n)
+	for _, tr := range trs[n:] {
+		atr = (atr*float64(period-1) + tr) / float64(period)
+	}
+
+	return atr
+}
+
+func withScanDefaults(criteria ScanCriteria) ScanCriteria {
+	defaults := DefaultScanCriteria()
+	if criteria.TopN <= 0 {
+		criteria.TopN = defaults.TopN
+	}
+	if criteria.MinQuoteVolume <= 0 {
+		criteria.MinQuoteVolume = defaults.MinQuoteVolume
+	}
+	if criteria.Interval == "" {
+		criteria.Interval = defaults.Interval
+	}
+	if criteria.Lookback <= 1 {
+		criteria.Lookback = defaults.Lookback
+	}
+	if criteria.PrefilterLimit <= 0 {
+		criteria.PrefilterLimit = defaults.PrefilterLimit
+	}
+	if criteria.QuoteVolumeWeight == 0 && criteria.VolatilityWeight == 0 && criteria.VolumeSpikeWeight == 0 && criteria.FundingWeight == 0 {
+		criteria.QuoteVolumeWeight = defaults.QuoteVolumeWeight
+		criteria.VolatilityWeight = defaults.VolatilityWeight
+		criteria.VolumeSpikeWeight = defaults.VolumeSpikeWeight
+		criteria.FundingWeight = defaults.FundingWeight
+	}
+	return criteria
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPercentileRanks(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{"empty", nil, []float64{}},
+		{"a single candidate ranks first", []float64{5}, []float64{1}},
+		{"ranks spread evenly from 0 to 1", []float64{30, 10, 20}, []float64{1, 0, 0.5}},
+		{"negative values rank lowest", []float64{-1, 4, 2, 3, 0}, []float64{0, 1, 0.5, 0.75, 0.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := make([]*ScanCandidate, len(tt.values))
+			for i, v := range tt.values {
+				candidates[i] = &ScanCandidate{QuoteVolume: v}
+			}
+			got := percentileRanks(candidates, func(c *ScanCandidate) float64 { return c.QuoteVolume })
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAverageTrueRange(t *testing.T) {
+	candles := []Kline{
+		{High: 10, Low: 8, Close: 9},
+		{High: 11, Low: 9, Close: 10},  // TR 2
+		{High: 14, Low: 10, Close: 13}, // TR 4
+		{High: 12, Low: 11, Close: 11}, // TR 2, from the previous close
+	}
+
+	tests := []struct {
+		name   string
+		klines []Kline
+		period int
+		want   float64
+	}{
+		{"a single candle has no range", candles[:1], 14, 0},
+		{"zero period", candles, 0, 0},
+		{"fewer candles than the period average every range", candles[:3], 14, 3},
+		{"gap from the previous close counts", []Kline{{Close: 9}, {High: 12, Low: 11, Close: 11}}, 14, 3},
+		{"wilder smoothing after the seed", candles, 2, (3*1 + 2) / 2.0},
+		{"period of one is the last range", candles, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := averageTrueRange(tt.klines, tt.period); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("averageTrueRange = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreCandidates(t *testing.T) {
+	newCandidates := func() []*ScanCandidate {
+		return []*ScanCandidate{
+			{Symbol: "AUSDT", QuoteVolume: 300e6, ATRPercent: 1, VolumeSpike: 1, FundingRate: 0.0001},
+			{Symbol: "BUSDT", QuoteVolume: 100e6, ATRPercent: 3, VolumeSpike: 4, FundingRate: -0.0005},
+			{Symbol: "CUSDT", QuoteVolume: 200e6, ATRPercent: 2, VolumeSpike: 2, FundingRate: 0.0002},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		criteria    ScanCriteria
+		wantScores  []float64
+		wantReasons []string // a reason each candidate must have
+	}{
+		{
+			name:        "quote volume only",
+			criteria:    ScanCriteria{QuoteVolumeWeight: 1},
+			wantScores:  []float64{100, 0, 50},
+			wantReasons: []string{"24h quote volume 300M", "balanced scores", "balanced scores"},
+		},
+		{
+			name:        "all metrics weighted equally",
+			criteria:    ScanCriteria{QuoteVolumeWeight: 1, VolatilityWeight: 1, VolumeSpikeWeight: 1, FundingWeight: 1},
+			wantScores:  []float64{25, 75, 50},
+			wantReasons: []string{"24h quote volume", "shorts paying longs", "balanced scores"},
+		},
+		{
+			name:        "weights are relative",
+			criteria:    ScanCriteria{QuoteVolumeWeight: 3, VolatilityWeight: 1},
+			wantScores:  []float64{75, 25, 50},
+			wantReasons: []string{"24h quote volume", "ATR is 3.00% of price", "balanced scores"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := newCandidates()
+			scoreCandidates(candidates, tt.criteria)
+			for i, c := range candidates {
+				if c.Score != tt.wantScores[i] {
+					t.Errorf("%s scored %g, want %g", c.Symbol, c.Score, tt.wantScores[i])
+				}
+				if !strings.Contains(strings.Join(c.Reasons, "; "), tt.wantReasons[i]) {
+					t.Errorf("%s reasons %q do not mention %q", c.Symbol, c.Reasons, tt.wantReasons[i])
+				}
+			}
+		})
+	}
+
+	candidates := newCandidates()
+	scoreCandidates(candidates, ScanCriteria{})
+	if candidates[0].Score != 0 || candidates[0].Reasons != nil {
+		t.Error("without weights candidates should be left unscored")
+	}
+}
+
+func TestWithScanDefaults(t *testing.T) {
+	got := withScanDefaults(ScanCriteria{Lookback: 1})
+	if got != DefaultScanCriteria() {
+		t.Errorf("zero criteria should take the defaults, got %+v", got)
+	}
+
+	custom := ScanCriteria{TopN: 3, Interval: "4h", Lookback: 12, FundingWeight: 2}
+	got = withScanDefaults(custom)
+	if got.TopN != 3 || got.Interval != "4h" || got.Lookback != 12 {
+		t.Errorf("set fields should be kept, got %+v", got)
+	}
+	if got.FundingWeight != 2 || got.QuoteVolumeWeight != 0 {
+		t.Errorf("a chosen weight should disable the other defaults, got %+v", got)
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"saturday-autotrade/models"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AgentMeta labels the meta-agent's exchanges; custom agents cannot take this name
+const AgentMeta = "meta"
+
+var (
+	// ErrInvalidSignalID is wrapped when a signal ID is not a valid ObjectID
+	ErrInvalidSignalID = errors.New("invalid signal ID format")
+	// ErrNotRecorded is wrapped when a signal has no recorded session or exchanges
+	ErrNotRecorded = errors.New("signal not recorded")
+)
+
+// AgentLLM answers agent prompts with signal JSON and the time the model took.
+// Live runs record every exchange; replays answer from a recording, with the
+// recorded latency.
+type AgentLLM interface {
+	Ask(agent, model, prompt string) (response string, latencyMs int64, err error)
+}
+
+// SignalSession is everything a signal run read from the network, recorded under
+// the signal ID so the run can be replayed
+type SignalSession struct {
+	ID           primitive.ObjectID           `json:"_id,omitempty" bson:"_id,omitempty"`
+	SignalID     primitive.ObjectID           `json:"signalId" bson:"signalId"`
+	Request      models.GenerateSignalRequest `json:"request" bson:"request"`
+	Symbol       string                       `json:"symbol" bson:"symbol"`
+	Model        string                       `json:"model" bson:"model"`
+	Agents       []string                     `json:"agents" bson:"agents"` // resolved agent names, in run order
+	MetaMode     string                       `json:"metaMode" bson:"metaMode"`
+	PricePolicy  string                       `json:"pricePolicy" bson:"pricePolicy"`
+	MarketData   map[string][]Kline           `json:"marketData" bson:"marketData"`
+	CurrentPrice float64                      `json:"currentPrice" bson:"currentPrice"`
+	OrderBook    *OrderBookFeatures           `json:"orderBook,omitempty" bson:"orderBook,omitempty"`
+	Derivatives  *DerivativesContext          `json:"derivatives,omitempty" bson:"derivatives,omitempty"`
+	Missing      []string                     `json:"missing,omitempty" bson:"missing,omitempty"` // optional context that could not be fetched
+	Error        string                       `json:"error,omitempty" bson:"error,omitempty"`     // why the run produced no signal
+	CreatedAt    time.Time                    `json:"createdAt" bson:"createdAt"`
+}
+
+// SignalReplay is the outcome of re-running a recorded session
+type SignalReplay struct {
+	Signal        *models.TradingSignal
+	Original      *models.TradingSignal // nil when the recorded run saved no signal
+	PromptChanges []string              // agents whose prompt differs from the recording
+}
+
+// recordingLLM sends prompts to the LLM service and keeps every exchange
+type recordingLLM struct {
+	llm      *LLMService
+	signalID primitive.ObjectID
+	symbol   string
+
+	mu        sync.Mutex
+	exchanges []models.LLMExchange
+}
+
+func (r *recordingLLM) Ask(agent, model, prompt string) (string, int64, error) {
+	exchange := models.LLMExchange{
+		SignalID:  r.signalID,
+		Symbol:    r.symbol,
+		Agent:     agent,
+		Model:     model,
+		Prompt:    prompt,
+		CreatedAt: time.Now(),
+	}
+	resp, err := r.llm.SendStructuredRequest(model, prompt, SignalOutput(), &exchange)
+	exchange.LatencyMs = time.Since(exchange.CreatedAt).Milliseconds()
+	exchange.Response = resp
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.mu.Unlock()
+	return resp, exchange.LatencyMs, err
+}
+
+// replayLLM answers each agent with its recorded responses, in recorded order
+type replayLLM struct {
+	mu        sync.Mutex
+	exchanges map[string][]models.LLMExchange
+	changed   []string
+}
+
+func newReplayLLM(exchanges []models.LLMExchange) *replayLLM {
+	r := &replayLLM{exchanges: make(map[string][]models.LLMExchange)}
+	for _, e := range exchanges {
+		r.exchanges[e.Agent] = append(r.exchanges[e.Agent], e)
+	}
+	return r
+}
+
+func (r *replayLLM) Ask(agent, model, prompt string) (string, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.exchanges[agent]
+	if len(queue) == 0 {
+		return "", 0, fmt.Errorf("no recorded %s exchange to replay", agent)
+	}
+	recorded := queue[0]
+	r.exchanges[agent] = queue[1:]
+
+	if recorded.Prompt != prompt {
+		r.changed = append(r.changed, agent)
+	}
+	if recorded.Error != "" {
+		return "", recorded.LatencyMs, errors.New(recorded.Error)
+	}
+	return recorded.Response, recorded.LatencyMs, nil
+}
+
+// ensureIndexes keeps one session per signal and makes the exchanges of a signal
+// quick to load for replays
+func (s *TradingService) ensureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.sessionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "signalId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.exchangeCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "signalId", Value: 1}, {Key: "createdAt", Value: 1}}},
+		// Spend and budget queries match on createdAt alone
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}},
+	})
+	return err
+}
+
+// saveSignalSession stores a run's market data and LLM exchanges. Failures are
+// logged and do not fail the run; exchanges that could not be stored are counted
+// as unrecorded spend so the daily budget still sees them.
+func (s *TradingService) saveSignalSession(session *SignalSession, exchanges []models.LLMExchange) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session.CreatedAt = time.Now()
+	if _, err := s.sessionCollection.InsertOne(ctx, session); err != nil {
+		log.Printf("LLM: failed to record session of signal %s: %v", session.SignalID.Hex(), err)
+	}
+
+	if len(exchanges) == 0 {
+		return
+	}
+	docs := make([]interface{}, len(exchanges))
+	for i := range exchanges {
+		docs[i] = exchanges[i]
+	}
+	if _, err := s.exchangeCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false)); err != nil {
+		failed := exchanges
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 && bulkErr.WriteConcernError == nil {
+			failed = make([]models.LLMExchange, 0, len(bulkErr.WriteErrors))
+			for _, writeErr := range bulkErr.WriteErrors {
+				failed = append(failed, exchanges[writeErr.Index])
+			}
+		}
+		unrecordedSpend.add(failed)
+		log.Printf("LLM: failed to record %d of %d exchanges of signal %s: %v", len(failed), len(exchanges), session.SignalID.Hex(), err)
+	}
+}
+
+// GetLLMExchanges returns the recorded LLM exchanges of a signal, oldest first
+
+func (s *TradingService) GetLLMExchanges(id string) ([]models.LLMExchange, error) {
+
+	signalID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignalID, err)
+	}
+	exchanges, err := s.loadLLMExchanges(signalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("%w: no LLM exchanges for signal %s", ErrNotRecorded, id)
+	}
+	return exchanges, nil
+}
+
+func (s *TradingService) loadLLMExchanges(signalID primitive.ObjectID) ([]models.LLMExchange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := s.exchangeCollection.Find(ctx, bson.M{"signalId": signalID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve LLM exchanges: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	exchanges := []models.LLMExchange{}
+	if err := cursor.All(ctx, &exchanges); err != nil {
+		return nil, fmt.Errorf("failed to decode LLM exchanges: %w", err)
+	}
+	return exchanges, nil
+}
+
+// ReplaySignal re-runs a recorded signal from its stored market data and LLM
+// answers without any network call. The replayed signal is not saved.
+
+func (s *TradingService) ReplaySignal(id string) (*SignalReplay, error) {
+
+	signalID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignalID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var session SignalSession
+	if err := s.sessionCollection.FindOne(ctx, bson.M{"signalId": signalID}).Decode(&session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no recorded session for signal %s", ErrNotRecorded, id)
+		}
+		return nil, fmt.Errorf("failed to retrieve recorded session: %w", err)
+	}
+	exchanges, err := s.loadLLMExchanges(signalID)
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := s.agents.Resolve(session.Symbol, session.Agents, session.Request.CustomAgents)
+	if err != nil {
+		return nil, err
+	}
+
+	llm := newReplayLLM(exchanges)
+	signal, err := runSignal(&session, agents, llm)
+	if err != nil {
+		return nil, fmt.Errorf("replay failed: %w", err)
+	}
+	signal.ID = primitive.NewObjectID()
+
+	sort.Strings(llm.changed)
+	replay := &SignalReplay{Signal: signal, PromptChanges: llm.changed}
+	if original, err := s.GetTradingSignalByID(id); err == nil {
+		replay.Original = original
+	}
+	return replay, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"saturday-autotrade/models"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedLLM answers each agent with a fixed response and records the
+// exchanges the way recordingLLM does
+type scriptedLLM struct {
+	responses map[string]string
+
+	mu        sync.Mutex
+	exchanges []models.LLMExchange
+}
+
+func (s *scriptedLLM) Ask(agent, model, prompt string) (string, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latency := int64(100 + len(s.exchanges))
+	s.exchanges = append(s.exchanges, models.LLMExchange{Agent: agent, Model: model, Prompt: prompt, Response: s.responses[agent], LatencyMs: latency})
+	return s.responses[agent], latency, nil
+}
+
+func signalJSON(direction string, entry, sl, tp float64, confidence int, thoughts string) string {
+	raw, _ := json.Marshal(AgentSignal{Symbol: "BTCUSDT", Direction: direction, Entry: entry, SL: sl, TP: tp, Confidence: confidence, Thoughts: thoughts})
+	return string(raw)
+}
+
+// recordedSession builds the same session on every call, as if loaded from the
+// database; runSignal adds indicators to its candles in place
+func recordedSession() *SignalSession {
+	series := func(count int, step int64) []Kline {
+		klines := make([]Kline, count)
+		for i := range klines {
+			mid := 100 + 5*math.Sin(float64(i)/7)
+			open := int64(i) * step
+			klines[i] = Kline{OpenTime: open, CloseTime: open + step - 1, Open: mid - 0.2, High: mid + 1, Low: mid - 1, Close: mid + 0.2, Volume: 10 + float64(i%5)}
+		}
+		return klines
+	}
+	return &SignalSession{
+		Request:      models.GenerateSignalRequest{Symbol: "BTCUSDT", Timeframes: []string{"1h", "4h"}},
+		Symbol:       "BTCUSDT",
+		Model:        "gpt-4o",
+		Agents:       []string{AgentTrend, AgentVolume},
+		MetaMode:     MetaModeLLMVeto,
+		PricePolicy:  PricePolicyHighestConfidence,
+		MarketData:   map[string][]Kline{"1h": series(200, 3600000), "4h": series(120, 4*3600000)},
+		CurrentPrice: 100,
+	}
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	agents := []Agent{trendAgent{}, volumeAgent{}}
+	live := &scriptedLLM{responses: map[string]string{
+		AgentTrend:  signalJSON("LONG", 100, 97, 106, 75, "trend up"),
+		AgentVolume: signalJSON("LONG", 100.2, 97.5, 105, 70, "buyers step in"),
+		AgentMeta:   signalJSON("LONG", 100, 97, 106, 72, "both agree"),
+	}}
+
+	original, err := runSignal(recordedSession(), agents, live)
+	if err != nil {
+		t.Fatalf("live run failed: %v", err)
+	}
+	if len(live.exchanges) != 3 {
+		t.Fatalf("recorded %d exchanges, want one per agent and the meta-agent", len(live.exchanges))
+	}
+
+	for i := 0; i < 2; i++ {
+		replayer := newReplayLLM(live.exchanges)
+		replayed, err := runSignal(recordedSession(), agents, replayer)
+		if err != nil {
+			t.Fatalf("replay %d failed: %v", i, err)
+		}
+		if len(replayer.changed) != 0 {
+			t.Fatalf("replay %d built different prompts for %v", i, replayer.changed)
+		}
+		if !sameSignal(original, replayed) {
+			t.Fatalf("replay %d = %+v, want %+v", i, replayed, original)
+		}
+	}
+
+	// Other market data gives other prompts, which the replay reports
+	moved := recordedSession()
+	moved.CurrentPrice = 101
+	replayer := newReplayLLM(live.exchanges)
+	if _, err := runSignal(moved, agents, replayer); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	sort.Strings(replayer.changed)
+	if !reflect.DeepEqual(replayer.changed, []string{AgentTrend, AgentVolume}) {
+		t.Errorf("changed prompts = %v, want the trend and volume agents", replayer.changed)
+	}
+}
+
+// sameSignal compares everything but the run's timestamps
+func sameSignal(a, b *models.TradingSignal) bool {
+	issues := func(s *models.TradingSignal) []models.ValidationIssue { return s.Validation.Issues }
+	return a.Direction == b.Direction && a.Entry == b.Entry && a.SL == b.SL && a.TP == b.TP && a.RR == b.RR &&
+		a.Confidence == b.Confidence && a.Thoughts == b.Thoughts && a.MetaRule == b.MetaRule && a.Vetoed == b.Vetoed &&
+		a.Validation.Executable == b.Validation.Executable && reflect.DeepEqual(issues(a), issues(b)) &&
+		reflect.DeepEqual(a.Agents, b.Agents) && reflect.DeepEqual(a.DetectedEvents, b.DetectedEvents)
+}
+
+func TestReplayLLM(t *testing.T) {
+	exchanges := []models.LLMExchange{
+		{Agent: AgentTrend, Prompt: "first", Response: "a", LatencyMs: 10},
+		{Agent: AgentTrend, Prompt: "second", Response: "b", LatencyMs: 20},
+		{Agent: AgentVolume, Prompt: "volume", Error: "rate limited", LatencyMs: 30},
+	}
+	replayer := newReplayLLM(exchanges)
+
+	tests := []struct {
+		agent, prompt string
+		wantResp      string
+		wantLatency   int64
+		wantErr       string
+	}{
+		{agent: AgentTrend, prompt: "first", wantResp: "a", wantLatency: 10},
+		{agent: AgentTrend, prompt: "changed", wantResp: "b", wantLatency: 20},
+		{agent: AgentTrend, prompt: "third", wantErr: "no recorded trend exchange"},
+		{agent: AgentVolume, prompt: "volume", wantLatency: 30, wantErr: "rate limited"},
+	}
+
+	// Exchanges of an agent replay in recorded order, so the cases run in sequence
+	for _, tt := range tests {
+		resp, latency, err := replayer.Ask(tt.agent, "gpt-4o", tt.prompt)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("%s/%s: error = %v, want one containing %q", tt.agent, tt.prompt, err, tt.wantErr)
+			}
+		} else if err != nil {
+			t.Fatalf("%s/%s: unexpected error: %v", tt.agent, tt.prompt, err)
+		}
+		if resp != tt.wantResp || latency != tt.wantLatency {
+			t.Errorf("%s/%s = %q in %dms, want %q in %dms", tt.agent, tt.prompt, resp, latency, tt.wantResp, tt.wantLatency)
+		}
+	}
+
+	if !reflect.DeepEqual(replayer.changed, []string{AgentTrend}) {
+		t.Errorf("changed prompts = %v, want [trend]", replayer.changed)
+	}
+}
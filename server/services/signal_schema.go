@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// signalSchema is the JSON schema every agent and the meta-agent answer with
+var signalSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "symbol": {"type": "string"},
+    "direction": {"type": "string", "enum": ["LONG", "SHORT"]},
+    "entry": {"type": "number", "minimum": 0},
+    "sl": {"type": "number", "minimum": 0},
+    "tp": {"type": "number", "minimum": 0},
+    "rr": {"type": "number", "minimum": 0},
+    "confidence": {"type": "integer", "minimum": 0, "maximum": 100},
+    "thoughts": {"type": "string", "minLength": 1}
+  },
+  "required": ["symbol", "direction", "entry", "sl", "tp", "rr", "confidence", "thoughts"],
+  "additionalProperties": false
+}`)
+
+var signalFields = []string{"symbol", "direction", "entry", "sl", "tp", "rr", "confidence", "thoughts"}
+
+// AgentSignal is a trading signal as written by an agent
+type AgentSignal struct {
+	Symbol     string  `json:"symbol"`
+	Direction  string  `json:"direction"`
+	Entry      float64 `json:"entry"`
+	SL         float64 `json:"sl"`
+	TP         float64 `json:"tp"`
+	RR         float64 `json:"rr"`
+	Confidence int     `json:"confidence"`
+	Thoughts   string  `json:"thoughts"`
+}
+
+// SignalOutput asks the model to submit a signal through a function call and
+// validates the arguments against the signal schema
+func SignalOutput() *StructuredOutput {
+	return &StructuredOutput{
+		Name:        "submit_signal",
+		Description: "Submit the trading signal. Prices are 0 and confidence is 0 when there is no valid setup.",
+		Schema:      signalSchema,
+		Validate: func(raw string) error {
+			_, err := ParseAgentSignal(raw)
+			return err
+		},
+	}
+}
+
+// ParseAgentSignal extracts the JSON object from an agent answer and checks it
+// against the signal schema and the price rules the prompts state. The error
+// lists every problem found so it can be sent back to the model.
+func ParseAgentSignal(raw string) (*AgentSignal, error) {
+	object, ok := ExtractJSONObject(raw)
+	if !ok {
+		return nil, fmt.Errorf("output does not contain a JSON object")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(object), &fields); err != nil {
+		return nil, fmt.Errorf("output is not valid JSON: %w", err)
+	}
+
+	var problems []string
+	for _, name := range signalFields {
+		if _, ok := fields[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing field %q", name))
+		}
+	}
+	for name := range fields {
+		if !containsString(signalFields, name) {
+			problems = append(problems, fmt.Sprintf("unexpected field %q", name))
+		}
+	}
+
+	var signal AgentSignal
+	decoder := json.NewDecoder(bytes.NewReader([]byte(object)))
+	if err := decoder.Decode(&signal); err != nil {
+		problems = append(problems, fmt.Sprintf("wrong field type: %v", err))
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	if signal.Direction != "LONG" && signal.Direction != "SHORT" {
+		problems = append(problems, fmt.Sprintf(`direction must be "LONG" or "SHORT", got %q`, signal.Direction))
+	}
+	if signal.Confidence < 0 || signal.Confidence > 100 {
+		problems = append(problems, fmt.Sprintf("confidence must be an integer from 0 to 100, got %d", signal.Confidence))
+	}
+	if signal.Entry < 0 || signal.SL < 0 || signal.TP < 0 || signal.RR < 0 {
+		problems = append(problems, "entry, sl, tp and rr must not be negative")
+	}
+	if strings.TrimSpace(signal.Thoughts) == "" {
+		problems = append(problems, "thoughts must not be empty")
+	}
+
+	noSetup := signal.Entry == 0 && signal.SL == 0 && signal.TP == 0
+	switch {
+	case noSetup && signal.Confidence != 0:
+		problems = append(problems, "confidence must be 0 when all prices are 0")
+	case !noSetup && (signal.Entry == 0 || signal.SL == 0 || signal.TP == 0):
+		problems = append(problems, "entry, sl and tp must all be set, or all be 0 when there is no setup")
+	case !noSetup && signal.Direction == "LONG" && !(signal.SL < signal.Entry && signal.Entry < signal.TP):
+		problems = append(problems, "for LONG, sl must be below entry and tp above entry")
+	case !noSetup && signal.Direction == "SHORT" && !(signal.TP < signal.Entry && signal.Entry < signal.SL):
+		problems = append(problems, "for SHORT, sl must be above entry and tp below entry")
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return &signal, nil
+}
+
+// ExtractJSONObject returns the first balanced top-level JSON object in s,
+// skipping any prose or markdown fences around it
+func ExtractJSONObject(s string) (string, bool) {
+	start := strings.IndexByte(s, '{')
+	for start >= 0 {
+		depth, inString, escaped := 0, false, false
+		for i := start; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case escaped:
+				escaped = false
+			case inString && c == '\\':
+				escaped = true
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '{':
+				depth++
+			case c == '}':
+				depth--
+				if depth == 0 {
+					candidate := s[start : i+1]
+					if json.Valid([]byte(candidate)) {
+						return candidate, true
+					}
+					i = len(s)
+				}
+			}
+		}
+
+		next := strings.IndexByte(s[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return "", false
+}
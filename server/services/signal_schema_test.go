@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "bare object", input: `{"a":1}`, want: `{"a":1}`, wantOK: true},
+		{name: "markdown fence", input: "Here you go:\n```json\n{\"a\":{\"b\":2}}\n```", want: `{"a":{"b":2}}`, wantOK: true},
+		{name: "braces inside strings", input: `note {"a":"} and {","b":"\"}"} done`, want: `{"a":"} and {","b":"\"}"}`, wantOK: true},
+		{name: "skips an invalid candidate", input: `{not json} then {"a":1}`, want: `{"a":1}`, wantOK: true},
+		{name: "first of two objects", input: `{"a":1} {"b":2}`, want: `{"a":1}`, wantOK: true},
+		{name: "unbalanced", input: `{"a":1`},
+		{name: "no object", input: "no setup today"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractJSONObject(tt.input)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ExtractJSONObject = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseAgentSignal(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		want     AgentSignal
+		wantErrs []string // substrings the error must list
+	}{
+		{
+			name: "long setup in prose",
+			raw:  `Signal: {"symbol":"BTCUSDT","direction":"LONG","entry":100,"sl":95,"tp":110,"rr":2,"confidence":70,"thoughts":"breakout"}`,
+			want: AgentSignal{Symbol: "BTCUSDT", Direction: "LONG", Entry: 100, SL: 95, TP: 110, RR: 2, Confidence: 70, Thoughts: "breakout"},
+		},
+		{
+			name: "no setup",
+			raw:  `{"symbol":"BTCUSDT","direction":"SHORT","entry":0,"sl":0,"tp":0,"rr":0,"confidence":0,"thoughts":"chop"}`,
+			want: AgentSignal{Symbol: "BTCUSDT", Direction: "SHORT", Thoughts: "chop"},
+		},
+		{name: "no JSON", raw: "I am not sure", wantErrs: []string{"does not contain a JSON object"}},
+		{
+			name:     "every problem is listed",
+			raw:      `{"symbol":"BTCUSDT","direction":"UP","entry":100,"sl":95,"tp":110,"confidence":120,"thoughts":" ","extra":1}`,
+			wantErrs: []string{`missing field "rr"`, `unexpected field "extra"`, "direction must be", "confidence must be", "thoughts must not be empty"},
+		},
+		{
+			name:     "fractional confidence",
+			raw:      `{"symbol":"BTCUSDT","direction":"LONG","entry":100,"sl":95,"tp":110,"rr":2,"confidence":70.5,"thoughts":"x"}`,
+			wantErrs: []string{"wrong field type"},
+		},
+		{
+			name:     "negative price",
+			raw:      `{"symbol":"BTCUSDT","direction":"LONG","entry":100,"sl":-5,"tp":110,"rr":2,"confidence":70,"thoughts":"x"}`,
+			wantErrs: []string{"must not be negative"},
+		},
+		{
+			name:     "confidence without a setup",
+			raw:      `{"symbol":"BTCUSDT","direction":"LONG","entry":0,"sl":0,"tp":0,"rr":0,"confidence":40,"thoughts":"x"}`,
+			wantErrs: []string{"confidence must be 0"},
+		},
+		{
+			name:     "partial setup",
+			raw:      `{"symbol":"BTCUSDT","direction":"LONG","entry":100,"sl":0,"tp":110,"rr":2,"confidence":70,"thoughts":"x"}`,
+			wantErrs: []string{"must all be set"},
+		},
+		{
+			name:     "long stop above entry",
+			raw:      `{"symbol":"BTCUSDT","direction":"LONG","entry":100,"sl":105,"tp":110,"rr":2,"confidence":70,"thoughts":"x"}`,
+			wantErrs: []string{"for LONG"},
+		},
+		{
+			name:     "short target above entry",
+			raw:      `{"symbol":"BTCUSDT","direction":"SHORT","entry":100,"sl":105,"tp":110,"rr":2,"confidence":70,"thoughts":"x"}`,
+			wantErrs: []string{"for SHORT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal, err := ParseAgentSignal(tt.raw)
+			if len(tt.wantErrs) > 0 {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", signal)
+				}
+				for _, want := range tt.wantErrs {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *signal != tt.want {
+				t.Errorf("signal = %+v, want %+v", *signal, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"saturday-autotrade/models"
+	"strings"
+	"time"
+)
+
+// Validation issue codes
+const (
+	IssueNoSetup            = "no_setup"
+	IssueMissingPrice       = "missing_price"
+	IssueSideMismatch       = "side_mismatch"
+	IssueEntryDeviation     = "entry_deviation"
+	IssueOutsideRange       = "outside_range"
+	IssueRRRepaired         = "rr_repaired"
+	IssueConfidenceRepaired = "confidence_repaired"
+	IssuePriceUnavailable   = "price_unavailable"
+	IssueRangeUnavailable   = "range_unavailable"
+)
+
+// Validation issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Sanity limits
+const (
+	maxEntryDeviation = 0.005 // entry may differ from the current price by 0.5%
+	rangeBandMargin   = 0.5   // prices may lie half the recent range beyond its high or low
+	rrTolerance       = 0.01
+)
+
+// ErrSignalNotExecutable is returned when executing a signal that failed validation
+var ErrSignalNotExecutable = errors.New("signal is not executable")
+
+// ValidateSignal checks a signal's prices against the current price and the
+// recent high/low of the given candles. RR is recomputed from entry, SL and TP,
+// and a confidence left on a signal with all prices 0 is reset. Signals without
+// a setup or with any error are marked not executable.
+func ValidateSignal(signal *models.TradingSignal, currentPrice float64, candles map[string][]Kline) *models.SignalValidation {
+	v := &models.SignalValidation{CurrentPrice: currentPrice, CheckedAt: time.Now()}
+	add := func(code, severity, format string, args ...interface{}) {
+		v.Issues = append(v.Issues, models.ValidationIssue{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case signal.Entry == 0 && signal.SL == 0 && signal.TP == 0:
+		v.NoSetup = true
+		if signal.Confidence != 0 {
+			add(IssueConfidenceRepaired, SeverityWarning, "confidence %d reset to 0 because all prices are 0", signal.Confidence)
+			signal.Confidence = 0
+		}
+		add(IssueNoSetup, SeverityError, "no setup: all prices are 0")
+		return v
+	case signal.Entry <= 0 || signal.SL <= 0 || signal.TP <= 0:
+		add(IssueMissingPrice, SeverityError, "entry, sl and tp must all be positive (entry %g, sl %g, tp %g)", signal.Entry, signal.SL, signal.TP)
+		return v
+	case signal.Confidence == 0:
+		v.NoSetup = true
+		add(IssueNoSetup, SeverityError, "no setup: confidence is 0")
+	}
+
+	sideOK := false
+	switch signal.Direction {
+	case "LONG":
+		sideOK = signal.SL < signal.Entry && signal.Entry < signal.TP
+		if !sideOK {
+			add(IssueSideMismatch, SeverityError, "LONG needs sl < entry < tp, got sl %g, entry %g, tp %g", signal.SL, signal.Entry, signal.TP)
+		}
+	case "SHORT":
+		sideOK = signal.TP < signal.Entry && signal.Entry < signal.SL
+		if !sideOK {
+			add(IssueSideMismatch, SeverityError, "SHORT needs tp < entry < sl, got tp %g, entry %g, sl %g", signal.TP, signal.Entry, signal.SL)
+		}
+	default:
+		add(IssueSideMismatch, SeverityError, "direction must be LONG or SHORT, got %q", signal.Direction)
+	}
+
+	if sideOK {
+		rr := math.Round(math.Abs(signal.TP-signal.Entry)/math.Abs(signal.Entry-signal.SL)*100) / 100
+		if math.Abs(signal.RR-rr) > rrTolerance {
+			add(IssueRRRepaired, SeverityWarning, "rr %g replaced by %g computed from entry, sl and tp", signal.RR, rr)
+		}
+		signal.RR = rr
+	}
+
+	if currentPrice > 0 {
+		if deviation := math.Abs(signal.Entry-currentPrice) / currentPrice; deviation > maxEntryDeviation {
+			add(IssueEntryDeviation, SeverityError, "entry %g is %.2f%% away from the current price %g", signal.Entry, deviation*100, currentPrice)
+		}
+	} else {
+		add(IssuePriceUnavailable, SeverityWarning, "current price unavailable, entry not checked")
+	}
+
+	if low, high, ok := recentRange(candles); ok {
+		v.RangeLow, v.RangeHigh = low, high
+		margin := (high - low) * rangeBandMargin
+		for _, p := range []struct {
+			name  string
+			value float64
+		}{{"entry", signal.Entry}, {"sl", signal.SL}, {"tp", signal.TP}} {
+			if p.value < low-margin || p.value > high+margin {
+				add(IssueOutsideRange, SeverityError, "%s %g is outside the band %g-%g around the recent range %g-%g", p.name, p.value, low-margin, high+margin, low, high)
+			}
+		}
+	} else {
+		add(IssueRangeUnavailable, SeverityWarning, "no recent candles, prices not checked against the range")
+	}
+
+	v.Executable = !v.NoSetup && len(validationErrors(v)) == 0
+	return v
+}
+
+// recentRange is the lowest low and highest high over every timeframe
+func recentRange(candles map[string][]Kline) (float64, float64, bool) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, tfCandles := range candles {
+		for _, k := range tfCandles {
+			low = math.Min(low, k.Low)
+			high = math.Max(high, k.High)
+		}
+	}
+	return low, high, high >= low && low > 0
+}
+
+// validationErrors returns the messages of the issues that block execution
+func validationErrors(v *models.SignalValidation) []string {
+	var messages []string
+	for _, issue := range v.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.Message)
+		}
+	}
+	return messages
+}
+
+// validationSummary explains why a signal is not executable
+func validationSummary(v *models.SignalValidation) string {
+	return strings.Join(validationErrors(v), "; ")
+}
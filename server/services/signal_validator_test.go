@@ -0,0 +1,161 @@
+package services
+
+import (
+	"saturday-autotrade/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateSignal(t *testing.T) {
+	// A recent range of 95-105 accepts prices within 90-110
+	candles := map[string][]Kline{
+		"1h":  {{Low: 97, High: 105}},
+		"15m": {{Low: 95, High: 101}},
+	}
+	long := func(entry, sl, tp, rr float64, confidence int) models.TradingSignal {
+		return models.TradingSignal{Direction: "LONG", Entry: entry, SL: sl, TP: tp, RR: rr, Confidence: confidence}
+	}
+
+	tests := []struct {
+		name           string
+		signal         models.TradingSignal
+		price          float64
+		candles        map[string][]Kline
+		wantCodes      []string
+		wantExecutable bool
+		wantRR         float64
+		wantConfidence int
+	}{
+		{
+			name:           "valid long",
+			signal:         long(100, 96, 108, 2, 70),
+			price:          100.2,
+			candles:        candles,
+			wantExecutable: true,
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+		{
+			name:           "valid short",
+			signal:         models.TradingSignal{Direction: "SHORT", Entry: 100, SL: 104, TP: 92, RR: 2, Confidence: 60},
+			price:          100,
+			candles:        candles,
+			wantExecutable: true,
+			wantRR:         2,
+			wantConfidence: 60,
+		},
+		{
+			name:           "rr is recomputed",
+			signal:         long(100, 96, 108, 3, 70),
+			price:          100,
+			candles:        candles,
+			wantCodes:      []string{IssueRRRepaired},
+			wantExecutable: true,
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+		{
+			name:      "no setup resets the confidence",
+			signal:    long(0, 0, 0, 0, 40),
+			price:     100,
+			candles:   candles,
+			wantCodes: []string{IssueConfidenceRepaired, IssueNoSetup},
+		},
+		{
+			name:      "missing price",
+			signal:    long(100, 0, 108, 2, 70),
+			price:     100,
+			candles:   candles,
+			wantCodes: []string{IssueMissingPrice},
+			wantRR:    2,
+			// Confidence is only repaired for signals without any price
+			wantConfidence: 70,
+		},
+		{
+			name:      "zero confidence is no setup",
+			signal:    long(100, 96, 108, 2, 0),
+			price:     100,
+			candles:   candles,
+			wantCodes: []string{IssueNoSetup},
+			wantRR:    2,
+		},
+		{
+			name:           "stop on the wrong side",
+			signal:         long(100, 102, 108, 2, 70),
+			price:          100,
+			candles:        candles,
+			wantCodes:      []string{IssueSideMismatch},
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+		{
+			name:           "entry too far from the price",
+			signal:         long(100, 96, 108, 2, 70),
+			price:          101,
+			candles:        candles,
+			wantCodes:      []string{IssueEntryDeviation},
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+		{
+			name:           "target outside the recent range band",
+			signal:         long(100, 96, 112, 3, 70),
+			price:          100,
+			candles:        candles,
+			wantCodes:      []string{IssueOutsideRange},
+			wantRR:         3,
+			wantConfidence: 70,
+		},
+		{
+			name:           "price unavailable only warns",
+			signal:         long(100, 96, 108, 2, 70),
+			candles:        candles,
+			wantCodes:      []string{IssuePriceUnavailable},
+			wantExecutable: true,
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+		{
+			name:           "candles unavailable only warn",
+			signal:         long(100, 96, 108, 2, 70),
+			price:          100,
+			wantCodes:      []string{IssueRangeUnavailable},
+			wantExecutable: true,
+			wantRR:         2,
+			wantConfidence: 70,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := tt.signal
+			v := ValidateSignal(&signal, tt.price, tt.candles)
+
+			codes := make([]string, len(v.Issues))
+			for i, issue := range v.Issues {
+				codes[i] = issue.Code
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.wantCodes, ",") {
+				t.Errorf("issues = %+v, want codes %v", v.Issues, tt.wantCodes)
+			}
+			if v.Executable != tt.wantExecutable {
+				t.Errorf("executable = %v, want %v", v.Executable, tt.wantExecutable)
+			}
+			if signal.RR != tt.wantRR || signal.Confidence != tt.wantConfidence {
+				t.Errorf("rr %g, confidence %d; want %g, %d", signal.RR, signal.Confidence, tt.wantRR, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestValidateSignalRecordsTheRange(t *testing.T) {
+	signal := models.TradingSignal{Direction: "LONG", Entry: 100, SL: 96, TP: 108, RR: 2, Confidence: 70}
+	v := ValidateSignal(&signal, 100, map[string][]Kline{"1h": {{Low: 95, High: 105}}})
+
+	if v.RangeLow != 95 || v.RangeHigh != 105 || v.CurrentPrice != 100 {
+		t.Errorf("validation = %+v, want range 95-105 at price 100", v)
+	}
+	if summary := validationSummary(v); summary != "" {
+		t.Errorf("a valid signal should have no summary, got %q", summary)
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownSymbol    = errors.New("unknown symbol")
+	ErrSymbolNotTrading = errors.New("symbol is not trading")
+)
+
+// preferredQuoteAssets decides which contract a bare base asset resolves to
+var preferredQuoteAssets = []string{"USDT", "USDC"}
+
+// SymbolRegistry turns user input such as "btc", "BTC/USDT" or "BTC-USDT" into a
+// canonical futures symbol and rejects unknown or delisted contracts
+type SymbolRegistry struct {
+	exchangeInfo *ExchangeInfoService
+}
+
+var (
+	symbolRegistriesMu sync.Mutex
+	symbolRegistries   = map[bool]*SymbolRegistry{}
+)
+
+// SharedSymbolRegistry returns the registry backed by the mainnet or testnet exchange info cache
+func SharedSymbolRegistry(isTestnet bool) *SymbolRegistry {
+	symbolRegistriesMu.Lock()
+	defer symbolRegistriesMu.Unlock()
+
+	if r, ok := symbolRegistries[isTestnet]; ok {
+		return r
+	}
+	r := NewSymbolRegistry(SharedExchangeInfo(isTestnet))
+	symbolRegistries[isTestnet] = r
+	return r
+}
+
+func NewSymbolRegistry(exchangeInfo *ExchangeInfoService) *SymbolRegistry {
+	return &SymbolRegistry{exchangeInfo: exchangeInfo}
+}
+
+// Normalize returns the canonical symbol for user input, or an error wrapping
+// ErrUnknownSymbol / ErrSymbolNotTrading
+func (r *SymbolRegistry) Normalize(input string) (string, error) {
+	info, err := r.Resolve(input)
+	if err != nil {
+		return "", err
+	}
+	return info.Symbol, nil
+}
+
+// Resolve returns the trading rules of the contract the input refers to
+func (r *SymbolRegistry) Resolve(input string) (*SymbolInfo, error) {
+	raw := strings.ToUpper(strings.TrimSpace(input))
+	if raw == "" {
+		return nil, fmt.Errorf("%w: symbol is required", ErrUnknownSymbol)
+	}
+	// Drop a settlement suffix such as "BTC/USDT:USDT"
+	if i := strings.IndexByte(raw, ':'); i >= 0 {
+		raw = raw[:i]
+	}
+	compact := strings.NewReplacer("/", "", "-", "", " ", "").Replace(raw)
+
+	// Exact symbols first; delivery contracts keep their underscore (BTCUSDT_250328)
+	for _, candidate := range []string{raw, compact, strings.ReplaceAll(compact, "_", "")} {
+		info, ok, err := r.exchangeInfo.Lookup(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load exchange info: %w", err)
+		}
+		if ok {
+			return checkTrading(info)
+		}
+	}
+
+	// Otherwise treat the input as a base asset, also matching 1000x contracts (PEPE -> 1000PEPEUSDT)
+	base := strings.ReplaceAll(compact, "_", "")
+	symbols, err := r.exchangeInfo.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange info: %w", err)
+	}
+
+	var best *SymbolInfo
+	for i := range symbols {
+		info := &symbols[i]
+		if info.ContractType != "PERPETUAL" || (info.BaseAsset != base && info.BaseAsset != "1000"+base) {
+			continue
+		}
+		if best == nil || betterBaseMatch(info, best, base) {
+			best = info
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, input)
+	}
+
+	return checkTrading(best)
+}
+
+// betterBaseMatch reports whether a ranks above b as the contract for a base asset:
+// still trading, then the preferred quote, then the exact base over its 1000x
+// contract, then the symbol name, so the choice never depends on listing order
+func betterBaseMatch(a, b *SymbolInfo, base string) bool {
+	if a.IsTrading() != b.IsTrading() {
+		return a.IsTrading()
+	}
+	if ra, rb := quoteRank(a.QuoteAsset), quoteRank(b.QuoteAsset); ra != rb {
+		return ra < rb
+	}
+	if ea, eb := a.BaseAsset == base, b.BaseAsset == base; ea != eb {
+		return ea
+	}
+	return a.Symbol < b.Symbol
+}
+
+// quoteRank is the position of a quote asset in preferredQuoteAssets, after them when absent
+func quoteRank(quote string) int {
+	for i, preferred := range preferredQuoteAssets {
+		if quote == preferred {
+			return i
+		}
+	}
+	return len(preferredQuoteAssets)
+}
+
+func checkTrading(info *SymbolInfo) (*SymbolInfo, error) {
+	if !info.IsTrading() {
+		return nil, fmt.Errorf("%w: %s status is %s", ErrSymbolNotTrading, info.Symbol, info.Status)
+	}
+	return info, nil
+}
+
+// IsSymbolError reports whether an error came from symbol validation rather than an upstream failure
+func IsSymbolError(err error) bool {
+	return errors.Is(err, ErrUnknownSymbol) || errors.Is(err, ErrSymbolNotTrading)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type listedSymbol struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"`
+	Status       string `json:"status"`
+	BaseAsset    string `json:"baseAsset"`
+	QuoteAsset   string `json:"quoteAsset"`
+}
+
+// registryFor builds a symbol registry over an exchangeInfo stand-in listing the
+// symbols in the given order
+func registryFor(t *testing.T, symbols []listedSymbol) *SymbolRegistry {
+	body, err := json.Marshal(map[string]interface{}{"symbols": symbols})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return NewSymbolRegistry(NewExchangeInfoService(server.URL, time.Minute))
+}
+
+func TestSymbolRegistryResolve(t *testing.T) {
+	listing := []listedSymbol{
+		{"BTCUSDT", "PERPETUAL", "TRADING", "BTC", "USDT"},
+		{"BTCUSDC", "PERPETUAL", "TRADING", "BTC", "USDC"},
+		{"BTCUSDT_250328", "CURRENT_QUARTER", "TRADING", "BTC", "USDT"},
+		{"1000PEPEUSDT", "PERPETUAL", "TRADING", "1000PEPE", "USDT"},
+		{"PEPEUSDC", "PERPETUAL", "TRADING", "PEPE", "USDC"},
+		{"1000SHIBUSDT", "PERPETUAL", "TRADING", "1000SHIB", "USDT"},
+		{"SHIBUSDT", "PERPETUAL", "TRADING", "SHIB", "USDT"},
+		{"ETHBTC", "PERPETUAL", "TRADING", "ETH", "BTC"},
+		{"ETHUSDC", "PERPETUAL", "TRADING", "ETH", "USDC"},
+		{"ETHUSDT", "PERPETUAL", "SETTLING", "ETH", "USDT"},
+		{"LUNAUSDT", "PERPETUAL", "SETTLING", "LUNA", "USDT"},
+	}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{input: "btcusdt", want: "BTCUSDT"},
+		{input: "BTC/USDT", want: "BTCUSDT"},
+		{input: "btc-usdc", want: "BTCUSDC"},
+		{input: "BTC/USDT:USDT", want: "BTCUSDT"},
+		{input: "BTCUSDT_250328", want: "BTCUSDT_250328"},
+		{input: "btc", want: "BTCUSDT"},
+		{input: "pepe", want: "1000PEPEUSDT"}, // the USDT quote outranks the exact base
+		{input: "shib", want: "SHIBUSDT"},     // the exact base wins within a quote
+		{input: "eth", want: "ETHUSDC"},       // a trading contract beats the preferred quote
+		{input: "luna", wantErr: ErrSymbolNotTrading},
+		{input: "LUNAUSDT", wantErr: ErrSymbolNotTrading},
+		{input: "doge", wantErr: ErrUnknownSymbol},
+		{input: " ", wantErr: ErrUnknownSymbol},
+	}
+
+	// The same input resolves to the same contract whatever the listing order
+	orders := map[string][]listedSymbol{"listed": listing, "reversed": make([]listedSymbol, len(listing))}
+	for i, s := range listing {
+		orders["reversed"][len(listing)-1-i] = s
+	}
+
+	for order, symbols := range orders {
+		registry := registryFor(t, symbols)
+		for _, tt := range tests {
+			t.Run(order+"/"+tt.input, func(t *testing.T) {
+				got, err := registry.Normalize(tt.input)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) || !IsSymbolError(err) {
+						t.Fatalf("error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Normalize(%q) = %s, want %s", tt.input, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBetterBaseMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SymbolInfo
+		want bool
+	}{
+		{
+			name: "trading beats settling",
+			a:    SymbolInfo{Symbol: "XUSDC", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDC"},
+			b:    SymbolInfo{Symbol: "XUSDT", Status: "SETTLING", BaseAsset: "X", QuoteAsset: "USDT"},
+			want: true,
+		},
+		{
+			name: "preferred quote beats the exact base",
+			a:    SymbolInfo{Symbol: "1000XUSDT", Status: "TRADING", BaseAsset: "1000X", QuoteAsset: "USDT"},
+			b:    SymbolInfo{Symbol: "XUSDC", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDC"},
+			want: true,
+		},
+		{
+			name: "unlisted quotes rank last",
+			a:    SymbolInfo{Symbol: "XBTC", Status: "TRADING", BaseAsset: "X", QuoteAsset: "BTC"},
+			b:    SymbolInfo{Symbol: "XUSDC", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDC"},
+			want: false,
+		},
+		{
+			name: "exact base beats the 1000x contract",
+			a:    SymbolInfo{Symbol: "1000XUSDT", Status: "TRADING", BaseAsset: "1000X", QuoteAsset: "USDT"},
+			b:    SymbolInfo{Symbol: "XUSDT", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDT"},
+			want: false,
+		},
+		{
+			name: "ties break on the symbol",
+			a:    SymbolInfo{Symbol: "XUSDT", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDT"},
+			b:    SymbolInfo{Symbol: "XUSDT2", Status: "TRADING", BaseAsset: "X", QuoteAsset: "USDT"},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := betterBaseMatch(&tt.a, &tt.b, "X"); got != tt.want {
+				t.Errorf("betterBaseMatch(a, b) = %v, want %v", got, tt.want)
+			}
+			if got := betterBaseMatch(&tt.b, &tt.a, "X"); got == tt.want {
+				t.Errorf("betterBaseMatch(b, a) = %v, want %v", got, !tt.want)
+			}
+		})
+	}
+}
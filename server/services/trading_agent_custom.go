@@ -0,0 +1,25 @@
+package services
+
+import "fmt"
+
+// PromptAgent is a user-defined agent: its instructions replace the specialist
+// briefing, while the market data and output rules are the same as for every agent
+type PromptAgent struct {
+	name         string
+	instructions string
+}
+
+func NewPromptAgent(name, instructions string) *PromptAgent {
+	return &PromptAgent{name: name, instructions: instructions}
+}
+
+func (a *PromptAgent) Name() string { return a.name }
+
+func (a *PromptAgent) BuildPrompt(in *AgentInput) string {
+	return fmt.Sprintf(`You are the %s agent. Analyze the following multi-timeframe market data for %s and generate a trading signal. Use only the data provided. Output ONLY valid JSON as specified.
+
+Your instructions:
+%s
+
+%s%s%s`, a.name, in.Symbol, a.instructions, formatIndicatorSection(in.Readings), formatDerivativesSection(in.Derivatives), buildAgentPromptCommon(in.CurrentPrice, in.Candles, in.Levels))
+}
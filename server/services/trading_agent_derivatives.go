@@ -0,0 +1,31 @@
+package services
+
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+)
+
+func BuildDerivativesAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels, readings map[string][]indicators.Reading, derivatives *DerivativesContext) string {
+	return fmt.Sprintf(`You are the Funding/Derivatives Agent. Analyze the following futures positioning data and multi-timeframe market data for %s and generate a trading signal focused on funding, open interest and trader positioning. Use only the data provided. Output ONLY valid JSON as specified.
+
+Inputs: Funding rate and mark/index premium, open interest history, top-trader long/short ratios, taker buy/sell volume, and multi-timeframe candles.
+
+Look for: Crowded positioning (extreme funding with one-sided top-trader ratios), open interest building into a move versus unwinding out of it, taker aggression that price does not follow, and funding that pays the side you would take.
+
+- Rising price with rising open interest shows new longs; rising price with falling open interest is short covering and tends to fade.
+- Extreme positive funding with price stalling favors SHORT squeezes of late longs; extreme negative funding with price holding favors LONG squeezes of late shorts.
+- Use the candles and key levels only to place ENTRY, SL and TP; the direction must come from the derivatives data.
+- If no derivatives context is provided, set confidence to 0 and explain in "thoughts" that positioning data was unavailable.
+
+In "thoughts", quote the funding rate, open interest change and ratios you relied on.
+
+%s%s%s`, symbol, formatDerivativesSection(derivatives), formatIndicatorSection(readings), buildAgentPromptCommon(currentPrice, candles, levels))
+}
+
+type derivativesAgent struct{}
+
+func (derivativesAgent) Name() string { return AgentDerivatives }
+
+func (derivativesAgent) BuildPrompt(in *AgentInput) string {
+	return BuildDerivativesAgentPrompt(in.Symbol, in.CurrentPrice, in.Candles, in.Levels, in.Readings, in.Derivatives)
+}
@@ -1,13 +1,23 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
-func BuildMetaAgentPrompt(trendJson, reversalJson, volumeJson string) string {
-	return fmt.Sprintf(`You are the Meta-Agent. You receive the JSON outputs of three specialized agents (Trend, Reversal, Volume). Your job is to aggregate their recommendations and output a FINAL trading signal as JSON (same format as the agents).
+func BuildMetaAgentPrompt(outputs []AgentOutput) string {
+	names := make([]string, len(outputs))
+	var inputs strings.Builder
+	for i, out := range outputs {
+		names[i] = agentTitle(out.Name)
+		fmt.Fprintf(&inputs, "%s Agent JSON:\n%s\n\n", agentTitle(out.Name), out.JSON)
+	}
+
+	return fmt.Sprintf(`You are the Meta-Agent. You receive the JSON outputs of %d specialized agents (%s). Your job is to aggregate their recommendations and output a FINAL trading signal as JSON (same format as the agents).
 
 Rules:
-- If all three agents agree (same direction, confidence > 60), take the trade.
-- If 2/3 agree, take the trade if average confidence > 70 and no major contradiction in "thoughts".
+- If all agents agree (same direction, confidence > 60), take the trade.
+- If a majority agree, take the trade if their average confidence > 70 and no major contradiction in "thoughts".
 - If no consensus, only trade if one agent is extremely confident (confidence > 90) and others are not strongly opposed.
 - If no valid setup, output confidence 0 and explain why in "thoughts".
 
@@ -15,16 +25,7 @@ Output ONLY valid JSON in the same format as the agents. In "thoughts", summariz
 - thoughts should be a detailed summary of the agents reasoning, not just a list of their outputs. make it a paragraph style summary.
 - thoughts should not only summarize, but also justify why the chosen direction and prices are selected over the alternatives.
 
-Trend Agent JSON:
-%s
-
-Reversal Agent JSON:
-%s
-
-Volume Agent JSON:
-%s
-
-Your output should be a single JSON object with the following fields:
+%sYour output should be a single JSON object with the following fields:
 Output ONLY valid, well-formatted JSON in this structure DON'T USE MARKDOWN OR ANY OTHER FORMAT:
 {
   "symbol": "{{symbol}}",
@@ -55,10 +56,20 @@ Rules:
 - Multi-timeframe logic is required.
 - **If no valid setup exists, fill all prices with 0, set confidence to 0, and in "thoughts" explain clearly why there is no valid trade setup right now. Direction must still be "LONG" or "SHORT" (pick the most probable, but never use "NONE").**
 
-`, trendJson, reversalJson, volumeJson)
+`, len(outputs), strings.Join(names, ", "), inputs.String())
+}
+
+func CallMetaAgent(llm AgentLLM, outputs []AgentOutput, model string) (string, error) {
+	prompt := BuildMetaAgentPrompt(outputs)
+	resp, _, err := llm.Ask(AgentMeta, model, prompt)
+	return resp, err
 }
 
-func CallMetaAgent(llmService *LLMService, trendJson, reversalJson, volumeJson, model string) (string, error) {
-	prompt := BuildMetaAgentPrompt(trendJson, reversalJson, volumeJson)
-	return llmService.SendRequest(model, prompt)
+// agentTitle turns an agent name such as "trend" or "my-agent" into a prompt label
+func agentTitle(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
 }
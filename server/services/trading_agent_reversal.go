@@ -1,8 +1,17 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+	"saturday-autotrade/models"
+)
+
+func BuildReversalAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels, readings map[string][]indicators.Reading, events []models.DetectedEvent, derivatives *DerivativesContext) string {
+	timeframes := make([]string, 0, len(candles))
+	for tf := range candles {
+		timeframes = append(timeframes, tf)
+	}
 
-func BuildReversalAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline) string {
 	return fmt.Sprintf(`You are the Reversal Agent. Analyze the following multi-timeframe market data for %s and generate a trading signal focused on reversals, divergences, and exhaustion signals. Use only the data provided. Output ONLY valid JSON as specified.
 
 Inputs: Multi-timeframe candles (OHLCV), RSI, MACD, OBV if available.
@@ -14,10 +23,19 @@ Inputs: Multi-timeframe candles (OHLCV), RSI, MACD, OBV if available.
 
 Look for: Bullish/bearish RSI divergence, oversold/overbought, pin bars, fakeouts.
 
-%s`, symbol, buildAgentPromptCommon(currentPrice, candles))
+The patterns and divergences listed under "Detected patterns and divergences" were confirmed by deterministic detectors. Only cite a pattern or divergence by candle number if it appears in that list; if you see one the detectors missed, say so explicitly in "thoughts" and give it less weight.
+
+If band indicators (Bollinger, Keltner) or StochRSI are provided, use closes outside the bands and StochRSI %%K/%%D crosses in extreme zones (above 80 or below 20) as exhaustion evidence, not as signals on their own.
+
+If derivatives context is provided, treat extreme funding rates and one-sided top-trader positioning as signs of a crowded trade that can fuel a reversal.
+
+%s%s%s%s`, symbol, formatReversalEventSection(events, timeframes), formatIndicatorSection(readings), formatDerivativesSection(derivatives), buildAgentPromptCommon(currentPrice, candles, levels))
 }
 
-func CallReversalAgent(llmService *LLMService, symbol string, currentPrice float64, candles map[string][]Kline, model string) (string, error) {
-	prompt := BuildReversalAgentPrompt(symbol, currentPrice, candles)
-	return llmService.SendRequest(model, prompt)
+type reversalAgent struct{}
+
+func (reversalAgent) Name() string { return AgentReversal }
+
+func (reversalAgent) BuildPrompt(in *AgentInput) string {
+	return BuildReversalAgentPrompt(in.Symbol, in.CurrentPrice, in.Candles, in.Levels, in.Readings, in.Events, in.Derivatives)
 }
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+)
+
+func BuildStructureAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels, readings map[string][]indicators.Reading) string {
+	return fmt.Sprintf(`You are the Market Structure Agent. Analyze the following multi-timeframe market data for %s and generate a trading signal focused on market structure: swing sequences, breaks of structure and the reaction at support/resistance zones. Use only the data provided. Output ONLY valid JSON as specified.
+
+Inputs: Multi-timeframe candles, and the detected swing highs/lows, S/R zones and pivots listed under "Key levels".
+
+Look for:
+- The swing sequence on each timeframe: higher highs and higher lows is bullish structure, lower highs and lower lows is bearish.
+- Break of structure (a close beyond the last swing in the trend direction) as continuation, and change of character (a close beyond the last swing against the trend) as an early reversal.
+- How price reacts at the S/R zones with the most touches: rejections, clean breaks, or retests of a broken zone from the other side.
+
+- Place SL beyond the swing or zone that would invalidate the structure, and TP at the next opposing zone or pivot.
+- If the higher timeframe structure conflicts with the lower one, favor the higher timeframe and explain the conflict in "thoughts".
+- In "thoughts", reference the exact swings (by candle number) and zones you used.
+
+%s%s`, symbol, formatIndicatorSection(readings), buildAgentPromptCommon(currentPrice, candles, levels))
+}
+
+type structureAgent struct{}
+
+func (structureAgent) Name() string { return AgentStructure }
+
+func (structureAgent) BuildPrompt(in *AgentInput) string {
+	return BuildStructureAgentPrompt(in.Symbol, in.CurrentPrice, in.Candles, in.Levels, in.Readings)
+}
@@ -1,8 +1,11 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+)
 
-func BuildTrendAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline) string {
+func BuildTrendAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels, readings map[string][]indicators.Reading, derivatives *DerivativesContext) string {
 	return fmt.Sprintf(`You are the Trend Agent. Analyze the following multi-timeframe market data for %s and generate a trading signal focused on overall trend, structure, and momentum. Use only the data provided. Output ONLY valid JSON as specified.
 
 Inputs: Multi-timeframe candles (OHLCV).
@@ -11,14 +14,20 @@ Inputs: Multi-timeframe candles (OHLCV).
 - If trends differ across timeframes, favor the direction supported by at least two out of three. Explain any conflicts in "thoughts".
 - If the data does not show a clear trend or conflicting structures, set confidence to 0 and explain why in "thoughts".
 - If moving averages are present, use crossovers or bounces to support your trend call, referencing which MA and candle number.
+- If ADX is present, treat readings above 25 as a trending market and below 20 as ranging; use +DI/-DI to confirm the side.
 
 
 Look for: Higher highs/lows, breakdowns, trend confirmation, moving average crossovers.
 
-%s`, symbol, buildAgentPromptCommon(currentPrice, candles))
+If derivatives context is provided, use it to judge trend quality: rising open interest with price confirms new positioning behind the move, while falling open interest suggests short covering or long liquidation.
+
+%s%s%s`, symbol, formatIndicatorSection(readings), formatDerivativesSection(derivatives), buildAgentPromptCommon(currentPrice, candles, levels))
 }
 
-func CallTrendAgent(llmService *LLMService, symbol string, currentPrice float64, candles map[string][]Kline, model string) (string, error) {
-	prompt := BuildTrendAgentPrompt(symbol, currentPrice, candles)
-	return llmService.SendRequest(model, prompt)
+type trendAgent struct{}
+
+func (trendAgent) Name() string { return AgentTrend }
+
+func (trendAgent) BuildPrompt(in *AgentInput) string {
+	return BuildTrendAgentPrompt(in.Symbol, in.CurrentPrice, in.Candles, in.Levels, in.Readings, in.Derivatives)
 }
@@ -1,14 +1,25 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"saturday-autotrade/indicators"
+)
 
-func BuildVolumeAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline) string {
+func BuildVolumeAgentPrompt(symbol string, currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels, readings map[string][]indicators.Reading, profiles map[string]*indicators.VolumeProfile, orderBook *OrderBookFeatures, derivatives *DerivativesContext) string {
 	return fmt.Sprintf(`You are the Volume/Orderflow Agent. Analyze the following multi-timeframe market data for %s and generate a trading signal focused on volume, breakouts, and fakeouts. Use only the data provided. Output ONLY valid JSON as specified.
 
-Inputs: Multi-timeframe Candles with volume, tick count, possibly LOB data if available.
+Inputs: Multi-timeframe Candles with volume, taker buy volume, per-candle delta (taker buy minus taker sell) and cumulative volume delta (CVD), tick count, and an order book summary (spread, depth imbalance, resting walls) if available.
 
 Look for: Volume spikes, volume at S/R, false breakouts, absorption, exhaustion.
 
+If a volume profile is provided, treat the POC and high volume nodes (HVN) as S/R volume clusters where price tends to stall, and low volume nodes (LVN) as thin areas price tends to move through quickly. Price leaving the value area and being accepted outside it signals a move; failing back inside it often returns price to the POC.
+
+Use Delta and CVD to see who is aggressing: price rising while CVD falls (or the reverse) is a divergence that often marks absorption; a large delta against the candle direction suggests passive orders absorbing the aggressor.
+
+If order book data is provided, use the depth imbalance and resting walls to judge where liquidity sits. A wall near a S/R level strengthens that level; a strongly one-sided imbalance supports moves in that direction. Books change quickly, so never use them as the only reason for a trade.
+If VWAP is provided, treat it as the fair price of the session: acceptance above or below it shows who is in control, and reclaims or losses of VWAP on volume are meaningful.
+If derivatives context is provided, use the taker buy/sell ratio to confirm which side is aggressing on volume spikes.
+
 In "thoughts", explain what volume patterns (spikes, exhaustion, absorption, S/R volume clusters) led to the decision, referencing specific candles or events where relevant.
 Prioritize signals where significant volume aligns with major support or resistance levels.
 Entry, TP, and SL must all be justified in "thoughts" with reference to actual price/volume action in the data.
@@ -17,10 +28,13 @@ If one or more timeframes is missing, still analyze available timeframes and not
 DON'T ASSUME ANYTHING. USE ONLY THE DATA PROVIDED.
 DO NOT MAKE UP DATA OR USE PLACEHOLDERS. USE REALISTIC MARKET PRICES.
 
-%s`, symbol, buildAgentPromptCommon(currentPrice, candles))
+%s%s%s%s%s`, symbol, formatVolumeProfileSection(profiles), formatIndicatorSection(readings), formatOrderBookSection(orderBook), formatDerivativesSection(derivatives), buildAgentPromptCommon(currentPrice, candles, levels))
 }
 
-func CallVolumeAgent(llmService *LLMService, symbol string, currentPrice float64, candles map[string][]Kline, model string) (string, error) {
-	prompt := BuildVolumeAgentPrompt(symbol, currentPrice, candles)
-	return llmService.SendRequest(model, prompt)
+type volumeAgent struct{}
+
+func (volumeAgent) Name() string { return AgentVolume }
+
+func (volumeAgent) BuildPrompt(in *AgentInput) string {
+	return BuildVolumeAgentPrompt(in.Symbol, in.CurrentPrice, in.Candles, in.Levels, in.Readings, in.Profiles, in.OrderBook, in.Derivatives)
 }
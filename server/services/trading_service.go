@@ -3,11 +3,15 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"math/rand"
 	"saturday-autotrade/config"
+	"saturday-autotrade/indicators"
 	"saturday-autotrade/models"
+	"sort"
 	"strconv"
 	"time"
 
@@ -20,23 +24,46 @@ import (
 type TradingService struct {
 	llmService            *LLMService
 	binanceService        *BinanceService
+	candleStore           *CandleStoreService
+	scanner               *ScannerService
+	newExchange           ExchangeFactory
+	margin                *MarginService
+	symbols               *SymbolRegistry
+	agents                *AgentRegistry
 	collection            *mongo.Collection
+	sessionCollection     *mongo.Collection
+	exchangeCollection    *mongo.Collection
 	positionCollection    *mongo.Collection
 	transactionCollection *mongo.Collection
 }
 
 func NewTradingService() *TradingService {
+	binanceService := NewBinanceService()
 
-	return &TradingService{
+	service := &TradingService{
 		llmService:            NewLLMService(),
-		binanceService:        NewBinanceService(),
+		binanceService:        binanceService,
+		candleStore:           NewCandleStoreService(binanceService),
+		scanner:               NewScannerService(binanceService),
+		newExchange:           NewExchange,
+		margin:                NewMarginService(NewExchange),
+		symbols:               SharedSymbolRegistry(false),
+		agents:                SharedAgentRegistry(),
 		collection:            config.DB.Collection("trading_signals"),
+		sessionCollection:     config.DB.Collection("signal_sessions"),
+		exchangeCollection:    config.DB.Collection("llm_exchanges"),
 		positionCollection:    config.DB.Collection("positions"),
 		transactionCollection: config.DB.Collection("transactions"),
 	}
+
+	if err := service.ensureIndexes(); err != nil {
+		log.Printf("TradingService: Failed to create indexes: %v", err)
+	}
+
+	return service
 }
 
-// ExecuteTrade executes a trading signal on Binance Futures
+// ExecuteTrade executes a trading signal on the signal's venue
 func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bool) (*models.ExecuteTradeResponse, error) {
 
 	// Check if signal is already executed
@@ -47,8 +74,26 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 		}, fmt.Errorf("signal is already executed")
 	}
 
-	// Execute trade using real Binance API
-	executionResult, err := s.executeBinanceTrade(signal, isTestnet)
+	// Refuse signals without a setup or with prices that failed the sanity checks
+	if signal.Validation != nil && !signal.Validation.Executable {
+		reason := validationSummary(signal.Validation)
+		return &models.ExecuteTradeResponse{
+			Success: false,
+			Message: "Signal failed validation: " + reason,
+		}, fmt.Errorf("%w: %s", ErrSignalNotExecutable, reason)
+	}
+
+	venue := NormalizeVenue(signal.Venue)
+	exchange, err := s.newExchange(venue, isTestnet)
+	if err != nil {
+		return &models.ExecuteTradeResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	// Execute trade on the venue
+	executionResult, err := s.executeExchangeTrade(exchange, signal)
 	if err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
@@ -61,7 +106,7 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 	}
 
 	// Get current price for position creation
-	currentPrice, err := s.binanceService.GetPrice(signal.Symbol)
+	currentPrice, err := exchange.GetPrice(signal.Symbol)
 	if err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
@@ -73,10 +118,12 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 	positionReq := &models.CreatePositionRequest{
 		Symbol:     signal.Symbol,
 		Direction:  signal.Direction,
-		Size:       1.0,
+		Size:       executionResult.Quantity,
 		EntryPrice: signal.Entry,
 		Leverage:   signal.Leverage,
 		IsTestnet:  isTestnet,
+		Venue:      venue,
+		MarginMode: executionResult.MarginMode,
 		StopLoss:   signal.SL,
 		TakeProfit: signal.TP,
 	}
@@ -111,6 +158,7 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 		PositionID:  positionIDString,
 		SignalID:    signal.ID.Hex(),
 		IsTestnet:   isTestnet,
+		Venue:       venue,
 		OrderID:     executionResult.TransactionId,
 		Description: description,
 	}
@@ -129,8 +177,8 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 	// Update signal in database with execution details
 	now := time.Now()
 	executionPrice := signal.Entry
-	if currentPrice != nil {
-		executionPrice = currentPrice.Price
+	if currentPrice > 0 {
+		executionPrice = currentPrice
 	}
 
 	updateData := bson.M{
@@ -140,6 +188,7 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 			"transactionId":  executionResult.TransactionId,
 			"executionPrice": executionPrice,
 			"isTestnet":      isTestnet,
+			"venue":          venue,
 			"updatedAt":      now,
 		},
 	}
@@ -158,40 +207,43 @@ func (s *TradingService) ExecuteTrade(signal *models.TradingSignal, isTestnet bo
 	return executionResult, nil
 }
 
-// executeBinanceTrade executes a trade using real Binance API
+// executeExchangeTrade places the entry, stop-loss and take-profit orders on a venue
 
-func (s *TradingService) executeBinanceTrade(signal *models.TradingSignal, isTestnet bool) (*models.ExecuteTradeResponse, error) {
+func (s *TradingService) executeExchangeTrade(exchange Exchange, signal *models.TradingSignal) (*models.ExecuteTradeResponse, error) {
 
-	// Initialize Binance Futures service
-	futuresService := NewBinanceFuturesService(isTestnet)
+	isTestnet := exchange.IsTestnet()
 
 	// Check if API is configured, fall back to mock if not
-	if !futuresService.IsConfigured() {
+	if !exchange.IsConfigured() {
 		return s.mockTradeExecution(signal, isTestnet), nil
 	}
 
+	// Prefer isolated margin, but trade in whatever mode the venue keeps: the switch
+	// fails while the symbol has open orders or a position, and some venues only
+	// set it for the whole account
+	if err := exchange.SetMarginMode(signal.Symbol, MarginModeIsolated); err != nil && !errors.Is(err, ErrMarginModePerAccount) {
+		log.Printf("TradingService: Failed to switch %s to isolated margin on %s: %v", signal.Symbol, exchange.Name(), err)
+	}
+	marginMode, err := exchange.GetMarginMode(signal.Symbol)
+	if err != nil {
+		log.Printf("TradingService: Failed to read the %s margin mode on %s, assuming isolated: %v", signal.Symbol, exchange.Name(), err)
+		marginMode = MarginModeIsolated
+	}
+
 	// Set leverage for the symbol
-	_, err := futuresService.SetLeverage(signal.Symbol, signal.Leverage)
+	err = exchange.SetLeverage(signal.Symbol, signal.Leverage)
 	if err != nil {
 		// Continue anyway, leverage might already be set
 	}
 
-	const riskPercent = 0.2 // 20% risk per trade
-
-	accountInfo, err := futuresService.GetAccountInfo()
+	balance, err := exchange.GetBalance("USDT")
 	if err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to get account info for margin validation: %v", err),
 		}, err
 	}
-	var availableBalance float64
-	for _, asset := range accountInfo.Assets {
-		if asset.Asset == "USDT" {
-			availableBalance, _ = strconv.ParseFloat(asset.AvailableBalance, 64)
-			break
-		}
-	}
+	availableBalance := balance.AvailableBalance
 	if availableBalance <= 0 {
 		return &models.ExecuteTradeResponse{
 			Success: false,
@@ -199,62 +251,68 @@ func (s *TradingService) executeBinanceTrade(signal *models.TradingSignal, isTes
 		}, fmt.Errorf("no available USDT balance")
 	}
 
-	riskAmount := availableBalance * riskPercent
-	positionSize := riskAmount * float64(signal.Leverage)
-	tradeQuantity := positionSize / signal.Entry
+	tradeQuantity := tradeQuantityFor(availableBalance, signal.Leverage, signal.Entry)
 
-	// Fetch stepSize and minQty for the symbol
-	stepSize, minQty, err := futuresService.GetSymbolStepSizeAndMinQty(signal.Symbol)
+	// Fetch the symbol's trading rules from the venue
+	symbolInfo, err := exchange.GetSymbolInfo(signal.Symbol)
 	if err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to get step size: %v", err),
+			Message: fmt.Sprintf("Failed to get symbol rules: %v", err),
 		}, err
 	}
-	tradeQuantity = TruncateToStepSize(tradeQuantity, stepSize)
+	tradeQuantity = symbolInfo.TruncateQuantity(tradeQuantity, true)
 
-	if tradeQuantity < minQty {
+	if err := symbolInfo.ValidateOrder(tradeQuantity, signal.Entry, 0, true); err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
-			Message: fmt.Sprintf("Trade quantity %.8f is less than the minimum allowed %.8f for %s", tradeQuantity, minQty, signal.Symbol),
-		}, fmt.Errorf("trade quantity %.8f is less than minQty %.8f", tradeQuantity, minQty)
+			Message: fmt.Sprintf("Trade rejected by %s filters: %v", signal.Symbol, err),
+		}, err
 	}
 
-	// // Calculate trade quantity (for demo, using a small fixed amount)
-	// tradeQuantity := 0.001 // Small amount for testing
-	// if signal.Symbol == "DOGEUSDT" || signal.Symbol == "ADAUSDT" {
-	// 	tradeQuantity = 100.0 // Higher quantity for lower-priced tokens
-	// }
-
-	// Validate margin and balance
-	err = futuresService.ValidateMarginAndBalance(signal.Symbol, tradeQuantity, signal.Entry, signal.Leverage)
-	if err != nil {
-		return &models.ExecuteTradeResponse{
-			Success: false,
-			Message: fmt.Sprintf("Insufficient balance: %v", err),
-		}, err
+	// Refuse trades that would be liquidated before the stop-loss is reached
+	estimate, err := s.margin.Estimate(MarginInput{
+		Venue:         exchange.Name(),
+		Symbol:        signal.Symbol,
+		Direction:     signal.Direction,
+		Size:          tradeQuantity,
+		EntryPrice:    signal.Entry,
+		Leverage:      signal.Leverage,
+		MarginMode:    marginMode,
+		WalletBalance: balance.WalletBalance,
+	}, isTestnet)
+	if err == nil {
+		if estimate.MaxLeverage > 0 && signal.Leverage > estimate.MaxLeverage {
+			err := fmt.Errorf("leverage %dx exceeds the %dx maximum for a %.2f USDT position", signal.Leverage, estimate.MaxLeverage, estimate.Notional)
+			return &models.ExecuteTradeResponse{
+				Success: false,
+				Message: fmt.Sprintf("Trade rejected: %v", err),
+			}, err
+		}
+		if StopBeyondLiquidation(signal.Direction, signal.SL, estimate.LiquidationPrice) {
+			err := fmt.Errorf("stop-loss %.6f is beyond the liquidation price %.6f", signal.SL, estimate.LiquidationPrice)
+			return &models.ExecuteTradeResponse{
+				Success: false,
+				Message: fmt.Sprintf("Trade rejected: %v", err),
+			}, err
+		}
 	}
 
 	// Determine order side based on signal direction
 	orderSide := "BUY"
-	positionSide := "LONG"
 	if signal.Direction == "SHORT" {
 		orderSide = "SELL"
-		positionSide = "SHORT"
 	}
 
-	positionSide = "BOTH" // Use BOTH for dual-side positions in Binance Futures
-
 	// Create main order (market order for immediate execution)
 	mainOrderReq := &OrderRequest{
-		Symbol:       signal.Symbol,
-		Side:         orderSide,
-		PositionSide: positionSide,
-		Type:         "MARKET",
-		Quantity:     tradeQuantity,
+		Symbol:   signal.Symbol,
+		Side:     orderSide,
+		Type:     "MARKET",
+		Quantity: tradeQuantity,
 	}
 
-	mainOrder, err := futuresService.PlaceOrder(mainOrderReq)
+	mainOrder, err := exchange.PlaceOrder(mainOrderReq)
 	if err != nil {
 		return &models.ExecuteTradeResponse{
 			Success: false,
@@ -269,61 +327,66 @@ func (s *TradingService) executeBinanceTrade(signal *models.TradingSignal, isTes
 	}
 
 	stopOrderReq := &OrderRequest{
-		Symbol:       signal.Symbol,
-		Side:         stopSide,
-		PositionSide: positionSide,
-		Type:         "STOP_MARKET",
-		Quantity:     tradeQuantity,
-		StopPrice:    signal.SL,
-		ReduceOnly:   true,
-		WorkingType:  "MARK_PRICE",
-		TimeInForce:  "GTE_GTC",
-	}
-
-	stopOrder, err := futuresService.PlaceOrder(stopOrderReq)
+		Symbol:      signal.Symbol,
+		Side:        stopSide,
+		Type:        "STOP_MARKET",
+		Quantity:    tradeQuantity,
+		StopPrice:   signal.SL,
+		ReduceOnly:  true,
+		WorkingType: "MARK_PRICE",
+		TimeInForce: "GTE_GTC",
+	}
+
+	stopOrder, err := exchange.PlaceOrder(stopOrderReq)
 	if err != nil {
 		// log.Printf("TradingService: Failed to place stop-loss order: %v", err)
 		// log.Printf("TradingService: Continuing without stop-loss order")
 	} else {
-		// log.Printf("TradingService: Stop-loss order placed successfully - OrderID: %d", stopOrder.OrderID)
+		// log.Printf("TradingService: Stop-loss order placed successfully - OrderID: %s", stopOrder.OrderID)
 	}
 	_ = stopOrder
 
 	// Place take-profit order
 	takeProfitOrderReq := &OrderRequest{
-		Symbol:       signal.Symbol,
-		Side:         stopSide, // Same side as stop-loss
-		PositionSide: positionSide,
-		Type:         "TAKE_PROFIT_MARKET",
-		Quantity:     tradeQuantity,
-		StopPrice:    signal.TP,
-		ReduceOnly:   true,
-		WorkingType:  "MARK_PRICE",
-		TimeInForce:  "GTE_GTC",
-	}
-
-	takeProfitOrder, err := futuresService.PlaceOrder(takeProfitOrderReq)
+		Symbol:      signal.Symbol,
+		Side:        stopSide, // Same side as stop-loss
+		Type:        "TAKE_PROFIT_MARKET",
+		Quantity:    tradeQuantity,
+		StopPrice:   signal.TP,
+		ReduceOnly:  true,
+		WorkingType: "MARK_PRICE",
+		TimeInForce: "GTE_GTC",
+	}
+
+	takeProfitOrder, err := exchange.PlaceOrder(takeProfitOrderReq)
 	if err != nil {
 		// log.Printf("TradingService: Failed to place take-profit order: %v", err)
 		// log.Printf("TradingService: Continuing without take-profit order")
 	} else {
-		// log.Printf("TradingService: Take-profit order placed successfully - OrderID: %d", takeProfitOrder.OrderID)
+		// log.Printf("TradingService: Take-profit order placed successfully - OrderID: %s", takeProfitOrder.OrderID)
 	}
 	_ = takeProfitOrder
 
+	filledQuantity := tradeQuantity
+	if mainOrder.ExecutedQty > 0 {
+		filledQuantity = mainOrder.ExecutedQty
+	}
+
 	// Create transaction ID that includes main order ID
-	transactionId := fmt.Sprintf("%s_%d_%s",
+	transactionId := fmt.Sprintf("%s_%s_%s",
 		map[bool]string{true: "testnet", false: "live"}[isTestnet],
 		mainOrder.OrderID,
 		signal.ID.Hex()[:8])
 
-	successMessage := fmt.Sprintf("Successfully executed %s trade for %s - OrderID: %d",
-		signal.Direction, signal.Symbol, mainOrder.OrderID)
+	successMessage := fmt.Sprintf("Successfully executed %s trade for %s on %s - OrderID: %s",
+		signal.Direction, signal.Symbol, exchange.Name(), mainOrder.OrderID)
 
 	return &models.ExecuteTradeResponse{
 		Success:       true,
 		Message:       successMessage,
 		TransactionId: transactionId,
+		MarginMode:    marginMode,
+		Quantity:      filledQuantity,
 	}, nil
 }
 
@@ -339,9 +402,12 @@ func (s *TradingService) mockTradeExecution(signal *models.TradingSignal, isTest
 		time.Now().Unix(),
 		signal.ID.Hex()[:8])
 
+	// Without credentials there is no balance to size from, so a nominal unit is traded
 	response := &models.ExecuteTradeResponse{
 		Success:       success,
 		TransactionId: transactionId,
+		MarginMode:    MarginModeIsolated,
+		Quantity:      1.0,
 	}
 
 	if success {
@@ -357,9 +423,116 @@ func (s *TradingService) mockTradeExecution(signal *models.TradingSignal, isTest
 
 func (s *TradingService) GetBinancePrice(symbol string) (*BinancePriceResponse, error) {
 
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
 	return s.binanceService.GetPrice(symbol)
 }
 
+// ScanCandidates ranks the USDT perpetual universe and returns the top candidates
+
+func (s *TradingService) ScanCandidates(criteria ScanCriteria) ([]ScanCandidate, error) {
+
+	return s.scanner.Scan(criteria)
+}
+
+// GetStoredCandles returns persisted candles for a symbol/interval within a time range
+
+func (s *TradingService) GetStoredCandles(symbol, interval string, start, end time.Time) ([]Kline, error) {
+
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.candleStore.GetCandles(symbol, interval, start, end)
+}
+
+// GetLevels returns swing points, S/R zones and pivots per timeframe, computed from
+// the same candle history the agents analyse
+
+func (s *TradingService) GetLevels(symbol string, timeframes []string) (map[string]*indicators.Levels, error) {
+
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make(map[string][]Kline, len(timeframes))
+	for _, tf := range timeframes {
+		klines, err := s.candleStore.GetRecentSeries(symbol, tf, signalCandleHistory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch market data for %s: %w", tf, err)
+		}
+		candles[tf] = klines
+	}
+
+	return computeLevels(candles), nil
+}
+
+// GetVolumeProfile spreads the volume of the latest lookback candles of a timeframe
+// across price bins
+
+func (s *TradingService) GetVolumeProfile(symbol, timeframe string, lookback, bins int) (*indicators.VolumeProfile, error) {
+
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	klines, err := s.candleStore.GetRecentSeries(symbol, timeframe, lookback)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch market data for %s: %w", timeframe, err)
+	}
+
+	profile := indicators.ComputeVolumeProfile(klinesToBars(klines), bins, indicators.DefaultValueArea)
+	if profile == nil {
+		return nil, fmt.Errorf("no volume traded for %s on %s", symbol, timeframe)
+	}
+	return profile, nil
+}
+
+// BackfillCandles fills gaps in the candle store for the requested range
+
+func (s *TradingService) BackfillCandles(req *models.BackfillCandlesRequest) (*models.BackfillCandlesResponse, error) {
+
+	symbol, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return &models.BackfillCandlesResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+	req.Symbol = symbol
+
+	end := time.Now()
+	if req.EndTime > 0 {
+		end = time.UnixMilli(req.EndTime)
+	}
+	start := time.UnixMilli(req.StartTime)
+	if !start.Before(end) {
+		return &models.BackfillCandlesResponse{
+			Success: false,
+			Message: ErrInvalidCandleRange.Error(),
+		}, ErrInvalidCandleRange
+	}
+
+	stored, err := s.candleStore.Backfill(req.Symbol, req.Interval, start, end)
+	if err != nil {
+		return &models.BackfillCandlesResponse{
+			Success: false,
+			Stored:  stored,
+			Message: fmt.Sprintf("Backfill failed: %v", err),
+		}, err
+	}
+
+	return &models.BackfillCandlesResponse{
+		Success: true,
+		Stored:  stored,
+		Message: fmt.Sprintf("Stored %d %s candles for %s", stored, req.Interval, req.Symbol),
+	}, nil
+}
+
 // SaveTradingSignal saves a trading signal to the database
 
 func (s *TradingService) SaveTradingSignal(signal *models.TradingSignal) error {
@@ -427,123 +600,269 @@ func (s *TradingService) GetTradingSignalByID(id string) (*models.TradingSignal,
 	return &signal, nil
 }
 
-// GenerateTradingSignalFromAI generates a trading signal using AI
+const (
+	// signalCandleHistory is enough history for Ichimoku's displaced senkou B (52 + 26 candles)
+	signalCandleHistory = 80
+	// agentPromptCandles is how many of the latest candles are listed in agent prompts
+	agentPromptCandles = 35
+)
+
+// GetModels returns the LLM models signals can be generated with and the default one
 
-func (s *TradingService) GenerateTradingSignalFromAI(symbol, model string, selectedTimeframes []string) (*models.TradingSignal, error) {
-	marketData := make(map[string][]Kline)
-	for _, tf := range selectedTimeframes {
-		klines, err := s.binanceService.GetKlines(symbol, tf, 70)
+func (s *TradingService) GetModels() ([]ModelInfo, string, error) {
+
+	return s.llmService.Models()
+}
+
+// GenerateTradingSignalFromAI generates a trading signal using AI. Analysis always
+// uses Binance market data; venue records where the signal is meant to be executed.
+// The request chooses the agents to run and the indicators each of them sees. The
+// market data and every LLM exchange are recorded under the signal ID so the run
+// can be replayed with ReplaySignal.
+
+func (s *TradingService) GenerateTradingSignalFromAI(req *models.GenerateSignalRequest) (*models.TradingSignal, error) {
+	symbol, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	modelInfo, err := s.llmService.ResolveModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := s.agents.Resolve(symbol, req.Agents, req.CustomAgents)
+	if err != nil {
+		return nil, err
+	}
+	if req.Indicators, err = ValidateAgentIndicators(req.Indicators, agents); err != nil {
+		return nil, err
+	}
+	metaMode, pricePolicy, err := resolveMetaMode(req.MetaMode, req.PricePolicy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLLMBudget(); err != nil {
+		return nil, err
+	}
+
+	session := &SignalSession{
+		SignalID:    primitive.NewObjectID(),
+		Request:     *req,
+		Symbol:      symbol,
+		Model:       modelInfo.Name,
+		MetaMode:    metaMode,
+		PricePolicy: pricePolicy,
+		MarketData:  make(map[string][]Kline),
+	}
+	for _, agent := range agents {
+		session.Agents = append(session.Agents, agent.Name())
+	}
+
+	for _, tf := range req.Timeframes {
+		klines, err := s.candleStore.GetRecentSeries(symbol, tf, signalCandleHistory)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch market data for %s: %w", tf, err)
 		}
-		marketData[tf] = klines
+		session.MarketData[tf] = klines
+	}
+
+	currentPrice, err := s.binanceService.GetPrice(symbol)
+	if err != nil || currentPrice == nil || currentPrice.Price <= 0 {
+		return nil, fmt.Errorf("failed to fetch current price for %s", symbol)
+	}
+	session.CurrentPrice = currentPrice.Price
+
+	// Order book features are optional context for the Volume agent
+	if orderBook, err := s.binanceService.GetOrderBookFeatures(symbol); err == nil {
+		session.OrderBook = orderBook
+	} else {
+		log.Printf("TradingService: Order book of %s unavailable for signal %s: %v", symbol, session.SignalID.Hex(), err)
+		session.Missing = append(session.Missing, "orderBook")
+	}
+
+	// Funding, open interest and positioning are optional context for all agents
+	if derivatives, err := s.binanceService.GetDerivativesContext(symbol, DerivativesPeriodFor(req.Timeframes), 12); err == nil {
+		session.Derivatives = derivatives
+		for _, section := range derivatives.Missing {
+			session.Missing = append(session.Missing, "derivatives."+section)
+		}
+	} else {
+		log.Printf("TradingService: Derivatives context of %s unavailable for signal %s: %v", symbol, session.SignalID.Hex(), err)
+		session.Missing = append(session.Missing, "derivatives")
+	}
+
+	recorder := &recordingLLM{llm: s.llmService, signalID: session.SignalID, symbol: symbol}
+	signal, err := runSignal(session, agents, recorder)
+	if err != nil {
+		session.Error = err.Error()
+	} else {
+		signal.Usage = &models.LLMUsage{}
+		for _, exchange := range recorder.exchanges {
+			signal.Usage.Add(exchange.Usage)
+		}
 	}
+	s.saveSignalSession(session, recorder.exchanges)
+	return signal, err
+}
+
+// runSignal turns a session's market data into a signal: indicators and detectors,
+// the agents in parallel, then the meta step. Its only outside calls go to llm.
+func runSignal(session *SignalSession, agents []Agent, llm AgentLLM) (*models.TradingSignal, error) {
+	req := &session.Request
 
 	allCandles := map[string][]Kline{}
-	for _, tf := range selectedTimeframes {
-		if arr, ok := marketData[tf]; ok {
+	for _, tf := range req.Timeframes {
+		if arr, ok := session.MarketData[tf]; ok {
 			// Calculate indicators **in place**
 			CalculateRSI(arr, 14)
 			CalculateMACD(arr)
 			CalculateOBV(arr)
+			CalculateVolumeDelta(arr)
 			allCandles[tf] = arr
 		}
 	}
 
+	// Short series (young listings, coarse renko boxes) are listed in full
 	candles := map[string][]Kline{}
 	for tf, tfCandles := range allCandles {
-		n := 35
-		if len(tfCandles) > n {
-			candles[tf] = tfCandles[len(tfCandles)-n:]
+		if n := agentPromptCandles; len(tfCandles) > n {
+			tfCandles = tfCandles[len(tfCandles)-n:]
 		}
+		candles[tf] = tfCandles
 	}
 
-	currentPrice, err := s.binanceService.GetPrice(symbol)
-	if err != nil {
-		currentPrice = &BinancePriceResponse{Price: 0}
+	input := &AgentInput{
+		Symbol:       session.Symbol,
+		CurrentPrice: session.CurrentPrice,
+		Candles:      candles,
+		Levels:       computeLevels(allCandles),
+		Events:       detectReversalEvents(allCandles),
+		Profiles:     computeVolumeProfiles(allCandles),
+		OrderBook:    session.OrderBook,
+		Derivatives:  session.Derivatives,
 	}
 
-	if currentPrice == nil || currentPrice.Price <= 0 {
-		return nil, fmt.Errorf("failed to fetch current price for %s", symbol)
+	// Run the agent LLM calls in parallel
+	type agentResult struct {
+		resp      string
+		latencyMs int64
+		err       error
+	}
+	results := make([]chan agentResult, len(agents))
+	for i, agent := range agents {
+		results[i] = make(chan agentResult, 1)
+
+		// Indicators use the full history so long lookbacks (EMA 50, Ichimoku 52) have values
+		agentInput := *input
+		agentInput.Readings = computeAgentIndicators(agent.Name(), req.Indicators, allCandles)
+
+		go func(agent Agent, in *AgentInput, ch chan<- agentResult) {
+			resp, latencyMs, err := llm.Ask(agent.Name(), session.Model, agent.BuildPrompt(in))
+			ch <- agentResult{resp, latencyMs, err}
+		}(agent, &agentInput, results[i])
+	}
+
+	// Wait for every agent so all exchanges are recorded, then report the first error
+	outputs := make([]AgentOutput, len(agents))
+	verdicts := make([]models.AgentVerdict, len(agents))
+	var agentErr error
+	for i, agent := range agents {
+		res := <-results[i]
+		if res.err != nil && agentErr == nil {
+			agentErr = fmt.Errorf("%s agent error: %w", agent.Name(), res.err)
+		}
+		outputs[i] = AgentOutput{Name: agent.Name(), JSON: res.resp}
+		verdicts[i] = models.AgentVerdict{Agent: agent.Name(), Model: session.Model, LatencyMs: res.latencyMs}
+		if parsed, err := ParseAgentSignal(res.resp); err == nil {
+			verdicts[i].Direction = parsed.Direction
+			verdicts[i].Entry, verdicts[i].SL, verdicts[i].TP, verdicts[i].RR = parsed.Entry, parsed.SL, parsed.TP, parsed.RR
+			verdicts[i].Confidence = parsed.Confidence
+			verdicts[i].Thoughts = parsed.Thoughts
+		}
+	}
+	if agentErr != nil {
+		return nil, agentErr
 	}
 
-	price := currentPrice.Price
-
-	// Run the three agent LLM calls in parallel
-	type agentResult struct {
-		resp string
-		err  error
-	}
-	trendCh := make(chan agentResult, 1)
-	reversalCh := make(chan agentResult, 1)
-	volumeCh := make(chan agentResult, 1)
-
-	go func() {
-		resp, err := CallTrendAgent(s.llmService, symbol, price, candles, model)
-		trendCh <- agentResult{resp, err}
-	}()
-	go func() {
-		resp, err := CallReversalAgent(s.llmService, symbol, price, candles, model)
-		reversalCh <- agentResult{resp, err}
-	}()
-	go func() {
-		resp, err := CallVolumeAgent(s.llmService, symbol, price, candles, model)
-		volumeCh <- agentResult{resp, err}
-	}()
-
-	var trendResp, reversalResp, volumeResp string
-	for i := 0; i < 3; i++ {
-		select {
-		case res := <-trendCh:
-			if res.err != nil {
-				return nil, fmt.Errorf("trend agent error: %w", res.err)
-			}
-			trendResp = res.resp
-		case res := <-reversalCh:
-			if res.err != nil {
-				return nil, fmt.Errorf("reversal agent error: %w", res.err)
-			}
-			reversalResp = res.resp
-		case res := <-volumeCh:
-			if res.err != nil {
-				return nil, fmt.Errorf("volume agent error: %w", res.err)
-			}
-			volumeResp = res.resp
+	// Aggregate after all agents are done, with the meta-agent, the consensus rules or both
+	var decision *MetaDecision
+	if session.MetaMode != MetaModeLLM {
+		var err error
+		decision, err = AggregateSignals(session.Symbol, outputs, session.PricePolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate agent signals: %w", err)
 		}
 	}
 
-	// Aggregate with meta-agent (after all three are done)
-	metaResp, err := CallMetaAgent(s.llmService, trendResp, reversalResp, volumeResp, model)
-	if err != nil {
-		return nil, fmt.Errorf("meta agent error: %w", err)
+	var final *AgentSignal
+	vetoed := false
+	if session.MetaMode == MetaModeDeterministic {
+		final = decision.Signal
+	} else {
+		metaResp, err := CallMetaAgent(llm, outputs, session.Model)
+		if err != nil {
+			return nil, fmt.Errorf("meta agent error: %w", err)
+		}
+		final, err = ParseAgentSignal(metaResp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse AI response: invalid AI response: %w\nRaw: %s", err, metaResp)
+		}
+		if session.MetaMode == MetaModeLLMVeto {
+			vetoed = vetoSignal(final, decision)
+		}
 	}
 
-	signal, err := s.parseAIResponse(metaResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	signal := agentSignalToModel(final)
+	signal.Agents = verdicts
+	signal.MetaMode = session.MetaMode
+	signal.Vetoed = vetoed
+	if decision != nil {
+		signal.MetaRule = decision.Rule
 	}
 
-	signal.ID = primitive.NewObjectID()
-	signal.Model = model
+	signal.ID = session.SignalID
+	signal.Model = session.Model
 	signal.Status = "Active"
 	signal.Leverage = 20 // Default leverage
 	signal.Timestamp = time.Now()
-	signal.TimeframesAnalyzed = selectedTimeframes
+	signal.TimeframesAnalyzed = req.Timeframes
+	signal.Venue = NormalizeVenue(req.Venue)
+	signal.DetectedEvents = input.Events
+	signal.Validation = ValidateSignal(signal, session.CurrentPrice, allCandles)
+	if derivatives := session.Derivatives; derivatives != nil && derivatives.Premium != nil {
+		signal.FundingRate = derivatives.Premium.LastFundingRate
+		if derivatives.Premium.NextFundingTime > 0 {
+			nextFunding := time.UnixMilli(derivatives.Premium.NextFundingTime)
+			signal.NextFundingTime = &nextFunding
+		}
+	}
 
 	return signal, nil
 }
 
-func buildAgentPromptCommon(currentPrice float64, candles map[string][]Kline) string {
+func buildAgentPromptCommon(currentPrice float64, candles map[string][]Kline, levels map[string]*indicators.Levels) string {
 	prompt := "current_price: " + strconv.FormatFloat(currentPrice, 'f', 6, 64) + "\n\n"
-	for tf, tfCandles := range candles {
-		n := 35
+	prompt += formatLevelSection(levels)
+	// Timeframes in a fixed order so the same data always yields the same prompt
+	timeframes := make([]string, 0, len(candles))
+	for tf := range candles {
+		timeframes = append(timeframes, tf)
+	}
+	sort.Strings(timeframes)
+	for _, tf := range timeframes {
+		tfCandles := candles[tf]
+		n := agentPromptCandles
 		if len(tfCandles) > n {
 			tfCandles = tfCandles[len(tfCandles)-n:]
 		}
-		prompt += fmt.Sprintf("Market Data (%s, last %d candles):\n\n", tf, len(tfCandles))
+		prompt += fmt.Sprintf("Market Data (%s, last %d candles):\n", tf, len(tfCandles))
+		if spec, err := ParseTimeframe(tf); err == nil && spec.Describe() != "" {
+			prompt += spec.Describe() + "\n"
+		}
+		prompt += "\n"
 		for i, candle := range tfCandles {
-			prompt += fmt.Sprintf("Candle %d: OpenTime: %d, Open: %.6f, High: %.6f, Low: %.6f, Close: %.6f, Volume: %.2f, Trades: %d, RSI: %.2f, MACD: %.5f, OBV: %.0f\n",
-				i+1, candle.OpenTime, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.NumberOfTrades, candle.RSI, candle.MACD, candle.OBV)
+			prompt += fmt.Sprintf("Candle %d: OpenTime: %d, Open: %.6f, High: %.6f, Low: %.6f, Close: %.6f, Volume: %.2f, TakerBuy: %.2f, Delta: %.2f, Trades: %d, RSI: %.2f, MACD: %.5f, OBV: %.0f, CVD: %.0f\n",
+				i+1, candle.OpenTime, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.TakerBuyBaseAssetVolume, candle.Delta, candle.NumberOfTrades, candle.RSI, candle.MACD, candle.OBV, candle.CVD)
 		}
 		prompt += "\n"
 	}
@@ -569,8 +888,8 @@ Rules:
 - Use realistic price levels based on the latest market data you received.
 - Do not use placeholder values like 0 or 1000; all prices must be realistic.
 - ENTRY price must *exactly* equal the provided "current_price" value. Do not adjust or use any other value.
-- SL and TP must be set according to recent candle data: use the most recent swing high/low, or clearly-identified support/resistance in the provided timeframe data.
-- If SL/TP placement is ambiguous, default to a volatility-based method: use 1x ATR (calculated from the last 14 candles) away from ENTRY.
+- SL and TP must be set according to recent candle data: use the most recent swing high/low, or clearly-identified support/resistance in the provided timeframe data. Prefer the swings, zones and pivots listed under "Key levels", which were detected from the data.
+- If SL/TP placement is ambiguous, default to a volatility-based method: use 1x ATR (the ATR indicator if provided, otherwise calculated from the last 14 candles) away from ENTRY.
 - DO NOT invent numbers for SL or TP. They must be justified by visible structure, recent price action, or volatility.
 - For each, explain the logic in "thoughts": reference the exact candle(s) or structure used.
 - TP must be above ENTRY for LONG, below ENTRY for SHORT; SL must be below ENTRY for LONG, above ENTRY for SHORT.
@@ -588,24 +907,8 @@ Rules:
 	return prompt
 }
 
-// parseAIResponse parses the AI (meta-agent) JSON output into a TradingSignal struct
-func (s *TradingService) parseAIResponse(aiResponse string) (*models.TradingSignal, error) {
-	var parsed struct {
-		Symbol     string  `json:"symbol"`
-		Direction  string  `json:"direction"`
-		Entry      float64 `json:"entry"`
-		SL         float64 `json:"sl"`
-		TP         float64 `json:"tp"`
-		RR         float64 `json:"rr"`
-		Confidence int     `json:"confidence"`
-		Thoughts   string  `json:"thoughts"`
-	}
-	// Try to unmarshal the response
-	err := json.Unmarshal([]byte(aiResponse), &parsed)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response JSON: %w\nRaw: %s", err, aiResponse)
-	}
-
+// agentSignalToModel copies a parsed agent signal into a new TradingSignal
+func agentSignalToModel(parsed *AgentSignal) *models.TradingSignal {
 	return &models.TradingSignal{
 		Symbol:     parsed.Symbol,
 		Direction:  parsed.Direction,
@@ -615,7 +918,7 @@ func (s *TradingService) parseAIResponse(aiResponse string) (*models.TradingSign
 		RR:         parsed.RR,
 		Confidence: parsed.Confidence,
 		Thoughts:   parsed.Thoughts,
-	}, nil
+	}
 }
 
 // ExecuteManualSignal executes a manually provided JSON signal
@@ -640,6 +943,25 @@ func (s *TradingService) ExecuteManualSignal(signalJson string, isTestnet bool)
 		}, fmt.Errorf("invalid signal: missing required fields")
 	}
 
+	signal.Symbol, err = s.symbols.Normalize(signal.Symbol)
+	if err != nil {
+		return &models.ExecuteManualSignalResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid signal: %v", err),
+		}, err
+	}
+
+	// Check the prices against the market before the signal is saved
+	recent := map[string][]Kline{}
+	if klines, err := s.candleStore.GetRecentSeries(signal.Symbol, "1h", signalCandleHistory); err == nil {
+		recent["1h"] = klines
+	}
+	price := 0.0
+	if current, err := s.binanceService.GetPrice(signal.Symbol); err == nil && current != nil {
+		price = current.Price
+	}
+	signal.Validation = ValidateSignal(&signal, price, recent)
+
 	// Set additional fields
 	signal.ID = primitive.NewObjectID()
 	signal.Status = "Active"
@@ -690,6 +1012,12 @@ func (s *TradingService) ExecuteManualSignal(signalJson string, isTestnet bool)
 
 func (s *TradingService) CreatePosition(req *models.CreatePositionRequest) (*models.Position, error) {
 
+	symbol, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	req.Symbol = symbol
+
 	position := &models.Position{
 		ID:           primitive.NewObjectID(),
 		Symbol:       req.Symbol,
@@ -700,20 +1028,24 @@ func (s *TradingService) CreatePosition(req *models.CreatePositionRequest) (*mod
 		Leverage:     req.Leverage,
 		Status:       "Open",
 		IsTestnet:    req.IsTestnet,
+		Venue:        NormalizeVenue(req.Venue),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 		StopLoss:     req.StopLoss,
 		TakeProfit:   req.TakeProfit,
+		MarginMode:   req.MarginMode,
 	}
 
 	// Calculate initial PnL (should be 0)
 	position.PnL = 0.0
 	position.PnLPercentage = 0.0
 
+	s.updatePositionMargin(position)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err := s.positionCollection.InsertOne(ctx, position)
+	_, err = s.positionCollection.InsertOne(ctx, position)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create position: %w", err)
 	}
@@ -746,6 +1078,7 @@ func (s *TradingService) GetPositions() ([]models.Position, error) {
 	for i := range positions {
 		if positions[i].Status == "Open" {
 			s.updatePositionPnL(&positions[i])
+			s.updatePositionMargin(&positions[i])
 		}
 	}
 
@@ -777,29 +1110,29 @@ func (s *TradingService) ClosePosition(positionID string) (*models.Position, err
 		return &position, fmt.Errorf("position is not open")
 	}
 
-	// --- REAL BINANCE CLOSE LOGIC ---
-	futuresService := NewBinanceFuturesService(position.IsTestnet)
-	if !futuresService.IsConfigured() {
-		return nil, fmt.Errorf("binance API not configured")
+	// --- REAL EXCHANGE CLOSE LOGIC ---
+	exchange, err := s.newExchange(position.Venue, position.IsTestnet)
+	if err != nil {
+		return nil, err
+	}
+	if !exchange.IsConfigured() {
+		return nil, fmt.Errorf("%s API not configured", exchange.Name())
 	}
 
-	// Fetch actual open position size from Binance
-	binancePositions, err := futuresService.GetAccountInfo()
+	// Fetch actual open position size from the venue
+	venuePositions, err := exchange.GetPositions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch Binance account info: %w", err)
+		return nil, fmt.Errorf("failed to fetch %s positions: %w", exchange.Name(), err)
 	}
 	var actualSize float64 = position.Size
-	for _, pos := range binancePositions.Positions {
-		if pos.Symbol == position.Symbol && pos.PositionSide == "BOTH" {
-			amt, err := strconv.ParseFloat(pos.PositionAmt, 64)
-			if err == nil && amt != 0 {
-				actualSize = math.Abs(amt)
-				break
-			}
+	for _, pos := range venuePositions {
+		if pos.Symbol == position.Symbol && pos.Size != 0 {
+			actualSize = pos.Size
+			break
 		}
 	}
 	if actualSize == 0 {
-		return nil, fmt.Errorf("no open position found on Binance to close")
+		return nil, fmt.Errorf("no open position found on %s to close", exchange.Name())
 	}
 
 	// Place reduce-only market order in the opposite direction
@@ -808,24 +1141,21 @@ func (s *TradingService) ClosePosition(positionID string) (*models.Position, err
 		closeSide = "BUY"
 	}
 	orderReq := &OrderRequest{
-		Symbol:       position.Symbol,
-		Side:         closeSide,
-		PositionSide: "BOTH",   // Always use BOTH since hedge mode is not used
-		Type:         "MARKET", // Always use MARKET for closing
-		Quantity:     actualSize,
-		ReduceOnly:   true,
-	}
-	order, err := futuresService.PlaceOrder(orderReq)
+		Symbol:     position.Symbol,
+		Side:       closeSide,
+		Type:       "MARKET", // Always use MARKET for closing
+		Quantity:   actualSize,
+		ReduceOnly: true,
+	}
+	order, err := exchange.PlaceOrder(orderReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to close position on Binance: %w", err)
+		return nil, fmt.Errorf("failed to close position on %s: %w", exchange.Name(), err)
 	}
 
 	// Get the close price from the order (if available)
 	closePrice := position.CurrentPrice
-	if order != nil {
-		if p, err := strconv.ParseFloat(order.Price, 64); err == nil && p > 0 {
-			closePrice = p
-		}
+	if order != nil && order.FillPrice() > 0 {
+		closePrice = order.FillPrice()
 	}
 	closedAt := time.Now()
 
@@ -886,6 +1216,7 @@ func (s *TradingService) CreateTransaction(req *models.CreateTransactionRequest)
 		PositionID:  positionID,
 		SignalID:    signalID,
 		IsTestnet:   req.IsTestnet,
+		Venue:       NormalizeVenue(req.Venue),
 		OrderID:     req.OrderID,
 		Description: req.Description,
 	}
@@ -901,11 +1232,15 @@ func (s *TradingService) CreateTransaction(req *models.CreateTransactionRequest)
 
 // updatePositionPnL updates the current price and PnL for a position
 func (s *TradingService) updatePositionPnL(position *models.Position) {
-	priceResp, err := s.binanceService.GetPrice(position.Symbol)
+	exchange, err := s.newExchange(position.Venue, position.IsTestnet)
+	if err != nil {
+		return
+	}
+	price, err := exchange.GetPrice(position.Symbol)
 	if err != nil {
 		return
 	}
-	position.CurrentPrice = priceResp.Price
+	position.CurrentPrice = price
 	if position.Direction == "LONG" {
 		position.PnL = (position.CurrentPrice - position.EntryPrice) * position.Size * float64(position.Leverage)
 	} else {
@@ -916,6 +1251,116 @@ func (s *TradingService) updatePositionPnL(position *models.Position) {
 	}
 }
 
+// updatePositionMargin refreshes liquidation price, maintenance margin and margin
+// ratio at the position's current price. Cross positions use the venue's USDT
+// wallet balance and ignore the account's other positions.
+func (s *TradingService) updatePositionMargin(position *models.Position) {
+	if position.MarginMode == "" {
+		position.MarginMode = MarginModeIsolated
+	}
+
+	input := MarginInput{
+		Venue:      position.Venue,
+		Symbol:     position.Symbol,
+		Direction:  position.Direction,
+		Size:       position.Size,
+		EntryPrice: position.EntryPrice,
+		MarkPrice:  position.CurrentPrice,
+		Leverage:   position.Leverage,
+		MarginMode: position.MarginMode,
+	}
+	if position.MarginMode == MarginModeCross {
+		exchange, err := s.newExchange(position.Venue, position.IsTestnet)
+		if err != nil {
+			return
+		}
+		balance, err := exchange.GetBalance("USDT")
+		if err != nil {
+			return
+		}
+		input.WalletBalance = balance.WalletBalance
+	}
+
+	estimate, err := s.margin.Estimate(input, position.IsTestnet)
+	if err != nil {
+		return
+	}
+	position.LiquidationPrice = estimate.LiquidationPrice
+	position.MaintenanceMargin = estimate.MaintenanceMargin
+	position.MarginRatio = estimate.MarginRatio
+}
+
+// PreviewMargin runs the margin engine on a proposed position before it is executed
+func (s *TradingService) PreviewMargin(req *models.MarginPreviewRequest) (*MarginEstimate, error) {
+	venue := ""
+	if req.SignalID != "" {
+		signal, err := s.GetTradingSignalByID(req.SignalID)
+		if err != nil {
+			return nil, err
+		}
+		venue = signal.Venue
+		if req.Symbol == "" {
+			req.Symbol = signal.Symbol
+		}
+		if req.Direction == "" {
+			req.Direction = signal.Direction
+		}
+		if req.EntryPrice <= 0 {
+			req.EntryPrice = signal.Entry
+		}
+		if req.Leverage <= 0 {
+			req.Leverage = signal.Leverage
+		}
+	}
+
+	if req.Symbol == "" || req.Direction == "" || req.EntryPrice <= 0 || req.Leverage <= 0 {
+		return nil, fmt.Errorf("symbol, direction, entryPrice and leverage are required")
+	}
+	symbol, err := s.symbols.Normalize(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	req.Symbol = symbol
+
+	needsBalance := req.Size <= 0 || (req.MarginMode == MarginModeCross && req.WalletBalance <= 0)
+	if needsBalance {
+		exchange, err := s.newExchange(venue, req.IsTestnet)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := exchange.GetBalance("USDT")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance: %w", err)
+		}
+		if req.Size <= 0 {
+			req.Size = tradeQuantityFor(balance.AvailableBalance, req.Leverage, req.EntryPrice)
+		}
+		if req.WalletBalance <= 0 {
+			req.WalletBalance = balance.WalletBalance
+		}
+	}
+
+	return s.margin.Estimate(MarginInput{
+		Venue:         venue,
+		Symbol:        req.Symbol,
+		Direction:     req.Direction,
+		Size:          req.Size,
+		EntryPrice:    req.EntryPrice,
+		Leverage:      req.Leverage,
+		MarginMode:    req.MarginMode,
+		WalletBalance: req.WalletBalance,
+	}, req.IsTestnet)
+}
+
+// tradeQuantityFor sizes a trade at 20% of the available balance, leveraged
+func tradeQuantityFor(availableBalance float64, leverage int, entry float64) float64 {
+	const riskPercent = 0.2 // 20% risk per trade
+
+	riskAmount := availableBalance * riskPercent
+	positionSize := riskAmount * float64(leverage)
+	return positionSize / entry
+}
+
 // GetTransactions retrieves recent transactions from the database
 func (s *TradingService) GetTransactions(limit int) ([]models.Transaction, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -970,11 +1415,6 @@ func (s *TradingService) GetPerformanceMetrics() (map[string]interface{}, error)
 	return metrics, nil
 }
 
-func TruncateToStepSize(quantity, stepSize float64) float64 {
-	steps := math.Floor(quantity / stepSize)
-	return steps * stepSize
-}
-
 // Exponential Moving Average (EMA)
 func ema(values []float64, period int) []float64 {
 	result := make([]float64, len(values))
@@ -1118,8 +1558,24 @@ func CalculateOBV(candles []Kline) {
 	}
 }
 
+// Volume Delta and Cumulative Volume Delta (CVD) from taker buy volume
+func CalculateVolumeDelta(candles []Kline) {
+	cvd := 0.0
+	for i := range candles {
+		takerSell := candles[i].Volume - candles[i].TakerBuyBaseAssetVolume
+		candles[i].Delta = candles[i].TakerBuyBaseAssetVolume - takerSell
+		cvd += candles[i].Delta
+		candles[i].CVD = cvd
+	}
+}
+
 // BuildChartDataPrompt returns only the chart data in the prompt style (Market Data... and candles)
 func (s *TradingService) BuildChartDataPrompt(symbol string, selectedTimeframes []string) (string, error) {
+	symbol, err := s.symbols.Normalize(symbol)
+	if err != nil {
+		return "", err
+	}
+
 	currentPrice, err := s.binanceService.GetPrice(symbol)
 	if err != nil {
 		return "", fmt.Errorf("failed to get current price: %w", err)
@@ -1127,13 +1583,14 @@ func (s *TradingService) BuildChartDataPrompt(symbol string, selectedTimeframes
 
 	marketData := make(map[string][]Kline)
 	for _, tf := range selectedTimeframes {
-		klines, err := s.binanceService.GetKlines(symbol, tf, 70)
+		klines, err := s.candleStore.GetRecentSeries(symbol, tf, 70)
 		if err != nil {
 			return "", fmt.Errorf("failed to fetch market data for %s: %w", tf, err)
 		}
 		CalculateRSI(klines, 14)
 		CalculateMACD(klines)
 		CalculateOBV(klines)
+		CalculateVolumeDelta(klines)
 		marketData[tf] = klines
 	}
 	prompt := "Candles and Indicators for " + symbol + "\n\n"
@@ -1145,8 +1602,8 @@ func (s *TradingService) BuildChartDataPrompt(symbol string, selectedTimeframes
 		}
 		prompt += fmt.Sprintf("Market Data (%s, last %d candles):\n", tf, len(tfCandles))
 		for i, candle := range tfCandles {
-			prompt += fmt.Sprintf("Candle %d: OpenTime: %d, Open: %.7f, High: %.7f, Low: %.7f, Close: %.7f, Volume: %.2f, Trades: %d, RSI: %.2f, MACD: %.5f, OBV: %.0f\n",
-				i+1, candle.OpenTime, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.NumberOfTrades, candle.RSI, candle.MACD, candle.OBV)
+			prompt += fmt.Sprintf("Candle %d: OpenTime: %d, Open: %.7f, High: %.7f, Low: %.7f, Close: %.7f, Volume: %.2f, TakerBuy: %.2f, Delta: %.2f, Trades: %d, RSI: %.2f, MACD: %.5f, OBV: %.0f, CVD: %.0f\n",
+				i+1, candle.OpenTime, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.TakerBuyBaseAssetVolume, candle.Delta, candle.NumberOfTrades, candle.RSI, candle.MACD, candle.OBV, candle.CVD)
 		}
 		prompt += "\n"
 	}